@@ -0,0 +1,140 @@
+package main
+
+import (
+	"auth/config"
+	validate "auth/internal/adapters/transport"
+	"auth/internal/app"
+	"auth/internal/domain/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Runs `admin create|promote|demote`
+func runAdmin(ctx context.Context, cfg config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("admin "+args[0], flag.ContinueOnError)
+	tenant := flags.String("tenant", cfg.App.Admin.Tenant, "tenant id")
+	email := flags.String("email", "", "user email")
+	name := flags.String("name", "", "admin name (create)")
+	password := flags.String("password", "", "admin password (create), temporary one is generated if empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if *email == "" {
+		return errors.New("--email is required")
+	}
+	if err := validate.Tenant(*tenant); err != nil {
+		return err
+	}
+
+	operator, postgresDB, err := app.NewOperator(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer postgresDB.DB.Close()
+
+	switch args[0] {
+	case "create":
+		if err := validate.NewUser(*name, *email, *password, models.AdminRole); err != nil {
+			return err
+		}
+
+		admin, tempPassword, err := operator.CreateAdmin(ctx, models.User{TenantID: *tenant, Name: *name, Email: *email}, *password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Admin %s created with ID %d in tenant %s\n", admin.Email, admin.ID, admin.TenantID)
+		if tempPassword != "" {
+			fmt.Printf("Temporary password (must be changed at first login): %s\n", tempPassword)
+		}
+	case "promote":
+		if err := operator.PromoteAdmin(ctx, *tenant, *email); err != nil {
+			return err
+		}
+		fmt.Printf("User %s is admin now\n", *email)
+	case "demote":
+		if err := operator.DemoteAdmin(ctx, *tenant, *email); err != nil {
+			return err
+		}
+		fmt.Printf("User %s is not admin anymore\n", *email)
+	default:
+		return errUsage
+	}
+	return nil
+}
+
+// Runs `user list|reset-password|disable`
+func runUser(ctx context.Context, cfg config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	tenant := flags.String("tenant", cfg.App.Admin.Tenant, "tenant id")
+	email := flags.String("email", "", "user email")
+	password := flags.String("password", "", "new password (reset-password), temporary one is generated if empty")
+	role := flags.String("role", "", "filter by role (list)")
+	status := flags.String("status", "", "filter by status (list)")
+	query := flags.String("query", "", "filter by email or name substring (list)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if err := validate.Tenant(*tenant); err != nil {
+		return err
+	}
+	if args[0] != "list" && *email == "" {
+		return errors.New("--email is required")
+	}
+
+	operator, postgresDB, err := app.NewOperator(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer postgresDB.DB.Close()
+
+	switch args[0] {
+	case "list":
+		filter := models.UserFilter{TenantID: *tenant, Role: *role, Status: *status, Query: *query}
+		if err := validate.UserFilter(filter); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tADMIN\tSTATUS\tCREATED AT")
+		if err := operator.ListUsers(ctx, filter, func(user models.User) error {
+			_, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", user.ID, user.Email, user.Name, user.Role,
+				strconv.FormatBool(user.IsAdmin), user.Status, user.Created_At.Format(time.RFC3339))
+			return err
+		}); err != nil {
+			return err
+		}
+		return w.Flush()
+	case "reset-password":
+		tempPassword, err := operator.ResetPassword(ctx, *tenant, *email, *password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Password of %s is reset\n", *email)
+		if tempPassword != "" {
+			fmt.Printf("Temporary password (must be changed at next login): %s\n", tempPassword)
+		}
+	case "disable":
+		if err := operator.DisableUser(ctx, *tenant, *email); err != nil {
+			return err
+		}
+		fmt.Printf("User %s is disabled\n", *email)
+	default:
+		return errUsage
+	}
+	return nil
+}
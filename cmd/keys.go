@@ -0,0 +1,39 @@
+package main
+
+import (
+	"auth/config"
+	"auth/internal/app"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+)
+
+// Runs `keys rotate`
+func runKeys(ctx context.Context, cfg config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "rotate" {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("keys rotate", flag.ContinueOnError)
+	grace := flags.Duration("grace", cfg.App.RefreshTTL, "period during which previous keys still verify issued tokens")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if *grace < 0 {
+		return fmt.Errorf("grace must not be negative: %s", *grace)
+	}
+
+	operator, postgresDB, err := app.NewOperator(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer postgresDB.DB.Close()
+
+	key, err := operator.RotateKey(ctx, *grace)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Signing key %s created, previous keys retire in %s\n", key.ID, *grace)
+	return nil
+}
@@ -4,14 +4,84 @@ import (
 	"auth/config"
 	"auth/internal/app"
 	"auth/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
-func main() {
+const usage = `Auth Service
+
+Usage:
+  auth [command] [flags]
+
+Commands:
+  serve                             Start HTTP and gRPC servers (default)
+  migrate up | down [n] | status    Apply, revert or list database migrations
+  admin create --email --name       Create administrator [--password] [--tenant]
+  admin promote --email             Grant admin rights to the user [--tenant]
+  admin demote --email              Revoke admin rights, the last admin can't be demoted [--tenant]
+  user list                         List users [--tenant] [--role] [--status] [--query]
+  user reset-password --email       Set password, temporary one is generated if empty [--password] [--tenant]
+  user disable --email              Disable user [--tenant]
+  keys rotate                       Create new token signing key [--grace]
+  help                              Show this message
+
+Configuration is read from .env and environment variables.`
+
+var errUsage = errors.New("invalid command")
 
+func main() {
 	cfg := config.New()
 
-	log := logger.SetLogger(cfg.App.Env)
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	if command == "serve" {
+		serve(cfg)
+		return
+	}
+
+	// Вывод команд идет в stdout, логи в stderr
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	// Прерывание отменяет выполняемые запросы к БД
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch command {
+	case "migrate":
+		err = runMigrate(cfg, log, os.Args[2:])
+	case "admin":
+		err = runAdmin(ctx, cfg, log, os.Args[2:])
+	case "user":
+		err = runUser(ctx, cfg, log, os.Args[2:])
+	case "keys":
+		err = runKeys(ctx, cfg, log, os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+	default:
+		err = errUsage
+	}
+
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", command, err)
+		os.Exit(1)
+	}
+}
+
+func serve(cfg config.Config) {
+	log := logger.SetLogger(cfg.App.Env, cfg.Log)
 	log.Info("Logger setup finished...")
 
 	app, err := app.New(cfg, log)
@@ -19,7 +89,7 @@ func main() {
 		log.Error("Failed to setup application", logger.Err(err))
 		os.Exit(1)
 	}
-	defer app.CleanUp(log)
+	defer app.Shutdown(log)
 
 	app.Start(log)
 }
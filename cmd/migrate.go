@@ -0,0 +1,74 @@
+package main
+
+import (
+	"auth/config"
+	"auth/migrations"
+	"auth/pkg/postgres"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Runs `migrate up|down|status` against the configured database
+func runMigrate(cfg config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	steps := 1
+	if args[0] == "down" && len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("steps must be positive number: %s", args[1])
+		}
+		steps = n
+	}
+
+	postgresDB, err := postgres.Connect(cfg.Db)
+	if err != nil {
+		return err
+	}
+	defer postgresDB.DB.Close()
+
+	migrator, err := postgres.NewMigrator(postgresDB.DB, migrations.FS, log)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		log.Info("Migrations applied", "count", applied)
+	case "down":
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Info("Migrations reverted", "count", reverted)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied_At != nil {
+				appliedAt = status.Applied_At.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"auth/pkg/logger"
 	"auth/pkg/postgres"
+	"auth/pkg/tracing"
 	"log/slog"
 	"os"
 	"time"
@@ -16,14 +18,65 @@ type (
 		HttpServer HttpServer
 		GrpcServer GrpcServer
 		Db         postgres.DatabaseConf
+		Tracing    tracing.TracingConf
+		Log        logger.LogConf
 	}
 
 	AppConf struct {
-		Env        string                    `env:"ENV" default:"local"` // Application environment: local | dev | prod
-		Secret     string                    `env:"SECRET"`              // Token generation secret
-		AccessTTL  time.Duration             `env:"ACCESSTTL"`           // Access token TTL
-		RefreshTTL time.Duration             `env:"REFRESHTTL"`          // Refresh token TTL
-		Admin      postgres.AdminCredentials // Admin credentials
+		Env        string           `env:"ENV" default:"local"` // Application environment: local | dev | prod
+		Secret     string           `env:"SECRET"`              // Token generation secret
+		AccessTTL  time.Duration    `env:"ACCESSTTL"`           // Access token TTL
+		RefreshTTL time.Duration    `env:"REFRESHTTL"`          // Refresh token TTL
+		Password   PasswordPolicy   // Default password policy, tenants can override it
+		Invite     Invite           // Organization invitations settings
+		Email      EmailChange      // Email change confirmation settings
+		Retention  Retention        // Deleted users retention settings
+		Webhook    Webhook          // Webhook delivery settings
+		Admin      AdminCredentials // Admin created on startup if its tenant has none
+		Shutdown   Shutdown         // Graceful shutdown settings
+	}
+
+	Shutdown struct {
+		Delay   time.Duration `env:"SHUTDOWN_DELAY" default:"5s"`    // Time between turning readiness off and stopping the servers, so load balancers stop routing new requests
+		Timeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"` // Max time for in-flight requests and background jobs to finish, then servers are stopped forcibly
+	}
+
+	AdminCredentials struct {
+		Tenant   string `env:"ADMIN_TENANT" default:"default"` // Tenant of the admin, created if it doesn't exist
+		Name     string `env:"ADMIN_NAME"`                     // Admin name
+		Password string `env:"ADMIN_PASSWORD"`                 // Admin password
+		Email    string `env:"ADMIN_EMAIL"`                    // Admin email, empty email or password disables the bootstrap
+	}
+
+	Invite struct {
+		URL string        `env:"INVITE_URL" default:"http://localhost/invitations/accept"` // Invitation accept page, token is passed as query param
+		TTL time.Duration `env:"INVITE_TTL" default:"72h"`                                 // Invitation lifetime
+	}
+
+	EmailChange struct {
+		URL string        `env:"EMAIL_CONFIRM_URL" default:"http://localhost/me/email/confirm"` // Email change confirmation page, token is passed as query param
+		TTL time.Duration `env:"EMAIL_CONFIRM_TTL" default:"24h"`                               // Confirmation link lifetime
+	}
+
+	Retention struct {
+		Period        time.Duration `env:"USER_RETENTION" default:"720h"` // Deleted user can be restored during this period, then it is deleted
+		PurgeInterval time.Duration `env:"PURGE_INTERVAL" default:"1h"`   // Interval between purges of expired users
+	}
+
+	Webhook struct {
+		Interval     time.Duration `env:"WEBHOOK_INTERVAL" default:"5s"`         // Interval between outbox dispatches
+		Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" default:"10s"`         // Timeout of one delivery request
+		MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`      // Attempts before delivery is moved to dead letters
+		Backoff      time.Duration `env:"WEBHOOK_BACKOFF" default:"30s"`         // Delay after the first failed attempt, doubled after each next one
+		Retention    time.Duration `env:"OUTBOX_RETENTION" default:"168h"`       // Delivered events and dead deliveries with their events are deleted after this period
+		AllowPrivate bool          `env:"WEBHOOK_ALLOW_PRIVATE" default:"false"` // Deliver to hosts resolving to loopback, private or link-local addresses
+	}
+
+	PasswordPolicy struct {
+		MinLength     int  `env:"PASSWORD_MIN_LENGTH" default:"8"`         // Min password length
+		RequireDigit  bool `env:"PASSWORD_REQUIRE_DIGIT" default:"false"`  // Require at least one digit
+		RequireUpper  bool `env:"PASSWORD_REQUIRE_UPPER" default:"false"`  // Require at least one upper-case letter
+		RequireSymbol bool `env:"PASSWORD_REQUIRE_SYMBOL" default:"false"` // Require at least one symbol
 	}
 
 	HttpServer struct {
@@ -33,6 +86,25 @@ type (
 		IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"60s"`         // Max keep-alive idle duration
 		ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"10s"`  // Max duration for reading headers
 		MaxHeaderBytes    int           `env:"HTTP_MAX_HEADER_BYTES" default:"1048576"` // Max size of request headers in bytes (default 1MB)
+		AuthPrecedence    string        `env:"HTTP_AUTH_PRECEDENCE" default:"header"`   // Where access token is looked up first: header (Authorization: Bearer) | cookie
+		TLS               HttpTLS       // HTTPS settings, plain HTTP without certificate
+		CORS              HttpCORS      // Cross-origin requests of browser apps, disabled without allowed origins
+	}
+
+	HttpCORS struct {
+		AllowedOrigins   string        `env:"HTTP_CORS_ALLOWED_ORIGINS"`                                                                                                         // Comma separated origins, e.g. https://app.example.com, * - any origin without credentials
+		AllowedMethods   string        `env:"HTTP_CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE"`                                                                     // Comma separated methods allowed in preflight
+		AllowedHeaders   string        `env:"HTTP_CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,X-CSRF-Token,X-Request-ID,X-Tenant-ID,X-Token-Delivery,X-Device-ID"` // Comma separated request headers allowed in preflight
+		ExposedHeaders   string        `env:"HTTP_CORS_EXPOSED_HEADERS" default:"X-CSRF-Token,X-Request-ID"`                                                                     // Comma separated response headers readable by scripts
+		AllowCredentials bool          `env:"HTTP_CORS_ALLOW_CREDENTIALS" default:"false"`                                                                                       // Allow cookies in cross-origin requests, incompatible with *
+		MaxAge           time.Duration `env:"HTTP_CORS_MAX_AGE" default:"10m"`                                                                                                   // How long browsers cache preflight results
+	}
+
+	HttpTLS struct {
+		CertFile     string `env:"HTTP_TLS_CERT"`                      // PEM certificate chain, empty cert and key serve plain HTTP
+		KeyFile      string `env:"HTTP_TLS_KEY"`                       // PEM private key
+		MinVersion   string `env:"HTTP_TLS_MIN_VERSION" default:"1.2"` // Min TLS version: 1.2 | 1.3
+		CipherSuites string `env:"HTTP_TLS_CIPHER_SUITES"`             // Comma separated TLS 1.2 cipher suites, empty - Go defaults
 	}
 
 	GrpcServer struct {
@@ -45,6 +117,17 @@ type (
 		KeepaliveGrace   time.Duration `env:"GRPC_KEEPALIVE_GRACE" default:"5m"`         // Grace period after max connection age
 		KeepalivePing    time.Duration `env:"GRPC_KEEPALIVE_PING" default:"1m"`          // Interval between pings
 		KeepaliveTimeout time.Duration `env:"GRPC_KEEPALIVE_TIMEOUT" default:"20s"`      // Time to wait for ping ack
+		HealthInterval   time.Duration `env:"GRPC_HEALTH_INTERVAL" default:"5s"`         // Interval of dependency checks reported by grpc.health.v1
+		TLS              GrpcTLS       // TLS and client certificates settings, plaintext without certificate
+	}
+
+	GrpcTLS struct {
+		CertFile          string `env:"GRPC_TLS_CERT"`                                // PEM certificate chain, empty cert and key serve plaintext
+		KeyFile           string `env:"GRPC_TLS_KEY"`                                 // PEM private key
+		MinVersion        string `env:"GRPC_TLS_MIN_VERSION" default:"1.2"`           // Min TLS version: 1.2 | 1.3
+		CipherSuites      string `env:"GRPC_TLS_CIPHER_SUITES"`                       // Comma separated TLS 1.2 cipher suites, empty - Go defaults
+		ClientCAFile      string `env:"GRPC_TLS_CLIENT_CA"`                           // PEM CAs of internal services, AdminService requires a client certificate signed by them
+		RequireClientCert bool   `env:"GRPC_TLS_REQUIRE_CLIENT_CERT" default:"false"` // Require client certificate for every service, not only AdminService. Needs GRPC_TLS_CLIENT_CA
 	}
 )
 
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"auth/internal/domain/models"
+	"log/slog"
+)
+
+// LogNotifier writes notifications to the log instead of sending them.
+// Stand-in for local development until a real mail provider is configured.
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) SendInvitation(email string, org models.Organization, link string) error {
+	n.log.Info("Invitation notification",
+		slog.String("to", email),
+		slog.Int("org_id", org.ID),
+		slog.String("org", org.Name),
+		slog.String("link", link),
+	)
+	return nil
+}
+
+func (n *LogNotifier) SendEmailConfirmation(email, link string) error {
+	n.log.Info("Email confirmation notification",
+		slog.String("to", email),
+		slog.String("link", link),
+	)
+	return nil
+}
+
+func (n *LogNotifier) SendNewDeviceLogin(email string, login models.LoginRecord) error {
+	n.log.Info("New device login notification",
+		slog.String("to", email),
+		slog.String("ip", login.IP),
+		slog.String("user_agent", login.UserAgent),
+		slog.Time("at", login.Created_At),
+	)
+	return nil
+}
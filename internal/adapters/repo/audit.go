@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewAuditDal(Db *sql.DB, Timeouts Timeouts) *AuditDal {
+	return &AuditDal{Db: Db, Timeouts: Timeouts}
+}
+
+// Appends entry to its tenant hash chain and sets its ID, time and hashes
+func (repo *AuditDal) Append(ctx context.Context, entry *models.AuditEntry) error {
+	const op = "AuditDal.Append"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Записи одной цепочки добавляются строго по очереди, иначе она разветвится
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit:' || $1 || ':' || $2))`, entry.TenantID, entry.Chain); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	err = tx.QueryRowContext(ctx, `SELECT Hash FROM AuditLog WHERE TenantID = $1 AND Chain = $2 ORDER BY ID DESC LIMIT 1`, entry.TenantID, entry.Chain).Scan(&entry.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Postgres хранит время с точностью до микросекунд, хэш считаем от сохраняемого значения
+	entry.Created_At = time.Now().UTC().Truncate(time.Microsecond)
+	entry.Hash = entry.ComputeHash()
+
+	if err := tx.QueryRowContext(ctx, `
+	INSERT INTO AuditLog (TenantID, Chain, ActorID, TargetID, Action, Outcome, Details, IP, UserAgent, RequestID, Created_At, PrevHash, Hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	RETURNING ID
+	`, entry.TenantID, entry.Chain, entry.ActorID, entry.TargetID, entry.Action, entry.Outcome, entry.Details,
+		entry.IP, entry.UserAgent, entry.RequestID, entry.Created_At, entry.PrevHash, entry.Hash).Scan(&entry.ID); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Returns page of tenant entries, newest first
+func (repo *AuditDal) ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	const op = "AuditDal.ListAudit"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	conds := []string{"TenantID = $1"}
+	args := []any{filter.TenantID}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		conds = append(conds, fmt.Sprintf("(ActorID = $%d OR TargetID = $%d)", len(args), len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conds = append(conds, fmt.Sprintf("Action = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conds = append(conds, fmt.Sprintf("Created_At >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conds = append(conds, fmt.Sprintf("Created_At < $%d", len(args)))
+	}
+	if filter.Cursor != "" {
+		beforeID, err := strconv.ParseInt(filter.Cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return models.AuditPage{}, fmt.Errorf("%s:%w", op, models.ErrInvalidCursor)
+		}
+		args = append(args, beforeID)
+		conds = append(conds, fmt.Sprintf("ID < $%d", len(args)))
+	}
+
+	// Запрашиваем на одну строку больше, чтобы понять есть ли следующая страница
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+	SELECT
+		ID, TenantID, Chain, ActorID, TargetID, Action, Outcome, Details, IP, UserAgent, RequestID, Created_At, PrevHash, Hash
+	FROM
+		AuditLog
+	WHERE
+		%s
+	ORDER BY
+		ID DESC
+	LIMIT
+		$%d
+	`, strings.Join(conds, " AND "), len(args))
+
+	rows, err := repo.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.AuditPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	page := models.AuditPage{Entries: []models.AuditEntry{}}
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return models.AuditPage{}, fmt.Errorf("%s:%w", op, err)
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return models.AuditPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if len(page.Entries) > filter.Limit {
+		page.Entries = page.Entries[:filter.Limit]
+		page.NextCursor = strconv.FormatInt(page.Entries[len(page.Entries)-1].ID, 10)
+	}
+	return page, nil
+}
+
+// Streams tenant entries chain by chain, each in chain order
+func (repo *AuditDal) WalkAudit(ctx context.Context, tenantID string, fn func(models.AuditEntry) error) error {
+	const op = "AuditDal.WalkAudit"
+
+	ctx, cancel := repo.Timeouts.stream(ctx)
+	defer cancel()
+
+	rows, err := repo.Db.QueryContext(ctx, `
+	SELECT
+		ID, TenantID, Chain, ActorID, TargetID, Action, Outcome, Details, IP, UserAgent, RequestID, Created_At, PrevHash, Hash
+	FROM
+		AuditLog
+	WHERE
+		TenantID = $1
+	ORDER BY
+		Chain, ID
+	`, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if err := fn(entry); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+func scanAuditEntry(rows *sql.Rows) (models.AuditEntry, error) {
+	var entry models.AuditEntry
+	err := rows.Scan(&entry.ID, &entry.TenantID, &entry.Chain, &entry.ActorID, &entry.TargetID, &entry.Action, &entry.Outcome, &entry.Details,
+		&entry.IP, &entry.UserAgent, &entry.RequestID, &entry.Created_At, &entry.PrevHash, &entry.Hash)
+	entry.Created_At = entry.Created_At.UTC()
+	return entry, err
+}
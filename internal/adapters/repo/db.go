@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// Limits of database operations applied on top of the caller's context. Zero means no limit
+type Timeouts struct {
+	Query  time.Duration // Single query or transaction
+	Stream time.Duration // Streaming reads like exports, fn of the caller runs under this limit
+}
+
+func (t Timeouts) query(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Query)
+}
+
+func (t Timeouts) stream(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, t.Stream)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,97 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type KeyDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewKeyDal(Db *sql.DB, Timeouts Timeouts) *KeyDal {
+	return &KeyDal{Db: Db, Timeouts: Timeouts}
+}
+
+// Returns not yet retired signing keys, newest first
+func (repo *KeyDal) ListKeys(ctx context.Context) ([]models.SigningKey, error) {
+	const op = "KeyDal.ListKeys"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, Secret, Created_At, Retires_At
+	FROM
+		SigningKeys
+	WHERE
+		Retires_At IS NULL OR Retires_At > Now()
+	ORDER BY
+		Created_At DESC, ID DESC`
+
+	rows, err := repo.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		if err := rows.Scan(&key.ID, &key.Secret, &key.Created_At, &key.Retires_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return keys, nil
+}
+
+// Saves new current key and retires the previous ones after grace period. The first rotation
+// retires the configured secret the same way
+func (repo *KeyDal) RotateKey(ctx context.Context, key *models.SigningKey, grace time.Duration) error {
+	const op = "KeyDal.RotateKey"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка не дает двум первым ротациям записать секрет дважды
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE SigningKeys IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO SigningKeys (ID, Secret, Retires_At)
+	SELECT $1, ''::bytea, Now() + make_interval(secs => $2)
+	WHERE NOT EXISTS (SELECT 1 FROM SigningKeys)`, models.SecretKeyID, grace.Seconds()); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE SigningKeys SET Retires_At = Now() + make_interval(secs => $1) WHERE Retires_At IS NULL`,
+		grace.Seconds()); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.QueryRowContext(ctx, `INSERT INTO SigningKeys (ID, Secret) VALUES ($1, $2) RETURNING Created_At`, key.ID, key.Secret).
+		Scan(&key.Created_At); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
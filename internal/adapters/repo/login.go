@@ -0,0 +1,114 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+type LoginDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewLoginDal(Db *sql.DB, Timeouts Timeouts) *LoginDal {
+	return &LoginDal{Db: Db, Timeouts: Timeouts}
+}
+
+func (repo *LoginDal) AddLogin(ctx context.Context, login *models.LoginRecord) error {
+	const op = "LoginDal.AddLogin"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO LoginHistory (TenantID, UserID, IP, UserAgent, Fingerprint, Success, Reason)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING ID, Created_At`
+
+	if err := repo.Db.QueryRowContext(ctx, query, login.TenantID, login.UserID, login.IP, login.UserAgent, login.Fingerprint,
+		login.Success, login.Reason).Scan(&login.ID, &login.Created_At); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Reports whether user has successfully logged in from the device before
+// and whether user has no successful logins at all
+func (repo *LoginDal) KnownDevice(ctx context.Context, tenantID string, userID int, fingerprint string) (bool, bool, error) {
+	const op = "LoginDal.KnownDevice"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		COALESCE(bool_or(Fingerprint = $3), false),
+		COUNT(*) = 0
+	FROM
+		LoginHistory
+	WHERE
+		TenantID = $1 AND UserID = $2 AND Success`
+
+	var known, firstLogin bool
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID, userID, fingerprint).Scan(&known, &firstLogin); err != nil {
+		return false, false, fmt.Errorf("%s:%w", op, err)
+	}
+	return known, firstLogin, nil
+}
+
+// Returns page of user logins, newest first
+func (repo *LoginDal) ListLogins(ctx context.Context, tenantID string, userID int, cursor string, limit int) (models.LoginPage, error) {
+	const op = "LoginDal.ListLogins"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	var beforeID int64
+	if cursor != "" {
+		var err error
+		beforeID, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return models.LoginPage{}, fmt.Errorf("%s:%w", op, models.ErrInvalidCursor)
+		}
+	}
+
+	// Запрашиваем на одну строку больше, чтобы понять есть ли следующая страница
+	rows, err := repo.Db.QueryContext(ctx, `
+	SELECT
+		ID, TenantID, UserID, IP, UserAgent, Fingerprint, Success, Reason, Created_At
+	FROM
+		LoginHistory
+	WHERE
+		TenantID = $1 AND UserID = $2 AND ($3 = 0 OR ID < $3)
+	ORDER BY
+		ID DESC
+	LIMIT
+		$4
+	`, tenantID, userID, beforeID, limit+1)
+	if err != nil {
+		return models.LoginPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	page := models.LoginPage{Logins: []models.LoginRecord{}}
+	for rows.Next() {
+		var login models.LoginRecord
+		if err := rows.Scan(&login.ID, &login.TenantID, &login.UserID, &login.IP, &login.UserAgent,
+			&login.Fingerprint, &login.Success, &login.Reason, &login.Created_At); err != nil {
+			return models.LoginPage{}, fmt.Errorf("%s:%w", op, err)
+		}
+		page.Logins = append(page.Logins, login)
+	}
+	if err := rows.Err(); err != nil {
+		return models.LoginPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if len(page.Logins) > limit {
+		page.Logins = page.Logins[:limit]
+		page.NextCursor = strconv.FormatInt(page.Logins[len(page.Logins)-1].ID, 10)
+	}
+	return page, nil
+}
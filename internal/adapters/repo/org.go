@@ -0,0 +1,449 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrOrgNotExist        = errors.New("organization does not exist")
+	ErrMemberNotExist     = errors.New("organization member does not exist")
+	ErrInvitationNotExist = errors.New("invitation does not exist")
+)
+
+type OrgDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewOrgDal(Db *sql.DB, Timeouts Timeouts) *OrgDal {
+	return &OrgDal{Db: Db, Timeouts: Timeouts}
+}
+
+// Saves organization with its owner membership and sets org ID
+func (repo *OrgDal) CreateOrg(ctx context.Context, org *models.Organization) error {
+	const op = "OrgDal.CreateOrg"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `
+	INSERT INTO Organizations (TenantID, Name, OwnerID)
+	VALUES ($1, $2, $3)
+	RETURNING ID, Created_At
+	`, org.TenantID, org.Name, org.OwnerID).Scan(&org.ID, &org.Created_At); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+	INSERT INTO Memberships (OrgID, UserID, Role)
+	VALUES ($1, $2, $3)
+	`, org.ID, org.OwnerID, models.OrgOwnerRole); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+func (repo *OrgDal) GetOrg(ctx context.Context, tenantID string, orgID int) (models.Organization, error) {
+	const op = "OrgDal.GetOrg"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, TenantID, Name, OwnerID, Created_At
+	FROM
+		Organizations
+	WHERE
+		TenantID=$1 AND ID=$2
+	`
+
+	var org models.Organization
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID, orgID).
+		Scan(&org.ID, &org.TenantID, &org.Name, &org.OwnerID, &org.Created_At); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Organization{}, fmt.Errorf("%s:%w", op, ErrOrgNotExist)
+		}
+		return models.Organization{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return org, nil
+}
+
+func (repo *OrgDal) GetMembership(ctx context.Context, orgID, userID int) (models.Membership, error) {
+	const op = "OrgDal.GetMembership"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		m.OrgID, m.UserID, u.Name, u.Email, m.Role, m.Joined_At
+	FROM
+		Memberships m
+		JOIN Users u ON u.ID = m.UserID
+	WHERE
+		m.OrgID=$1 AND m.UserID=$2
+	`
+
+	var member models.Membership
+	if err := repo.Db.QueryRowContext(ctx, query, orgID, userID).
+		Scan(&member.OrgID, &member.UserID, &member.Name, &member.Email, &member.Role, &member.Joined_At); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Membership{}, fmt.Errorf("%s:%w", op, ErrMemberNotExist)
+		}
+		return models.Membership{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return member, nil
+}
+
+func (repo *OrgDal) ListMembers(ctx context.Context, orgID int) ([]models.Membership, error) {
+	const op = "OrgDal.ListMembers"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		m.OrgID, m.UserID, u.Name, u.Email, m.Role, m.Joined_At
+	FROM
+		Memberships m
+		JOIN Users u ON u.ID = m.UserID
+	WHERE
+		m.OrgID=$1
+	ORDER BY
+		m.Joined_At, m.UserID
+	`
+
+	rows, err := repo.Db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	members := []models.Membership{}
+	for rows.Next() {
+		var member models.Membership
+		if err := rows.Scan(&member.OrgID, &member.UserID, &member.Name, &member.Email, &member.Role, &member.Joined_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return members, nil
+}
+
+// Lists memberships of the user in all organizations
+func (repo *OrgDal) ListUserMemberships(ctx context.Context, userID int) ([]models.Membership, error) {
+	const op = "OrgDal.ListUserMemberships"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		m.OrgID, o.Name, m.UserID, u.Name, u.Email, m.Role, m.Joined_At
+	FROM
+		Memberships m
+		JOIN Organizations o ON o.ID = m.OrgID
+		JOIN Users u ON u.ID = m.UserID
+	WHERE
+		m.UserID=$1
+	ORDER BY
+		m.Joined_At, m.OrgID
+	`
+
+	rows, err := repo.Db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	members := []models.Membership{}
+	for rows.Next() {
+		var member models.Membership
+		if err := rows.Scan(&member.OrgID, &member.OrgName, &member.UserID, &member.Name, &member.Email, &member.Role, &member.Joined_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return members, nil
+}
+
+func (repo *OrgDal) AddMember(ctx context.Context, orgID, userID int, role string) error {
+	const op = "OrgDal.AddMember"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO Memberships (OrgID, UserID, Role)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (OrgID, UserID) DO NOTHING
+	`
+
+	res, err := repo.Db.ExecContext(ctx, query, orgID, userID, role)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, models.ErrAlreadyMember)
+	}
+	return nil
+}
+
+func (repo *OrgDal) UpdateMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	const op = "OrgDal.UpdateMemberRole"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE Memberships
+	SET Role=$1
+	WHERE OrgID=$2 AND UserID=$3
+	`
+
+	res, err := repo.Db.ExecContext(ctx, query, role, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrMemberNotExist)
+	}
+	return nil
+}
+
+// Saves invitation and sets its ID
+func (repo *OrgDal) SaveInvitation(ctx context.Context, inv *models.Invitation) error {
+	const op = "OrgDal.SaveInvitation"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO Invitations (OrgID, Email, Role, InvitedBy, Expires_At)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING ID, Created_At
+	`
+
+	if err := repo.Db.QueryRowContext(ctx, query, inv.OrgID, inv.Email, inv.Role, inv.InvitedBy, inv.Expires_At).
+		Scan(&inv.ID, &inv.Created_At); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+func (repo *OrgDal) GetInvitation(ctx context.Context, invitationID int) (models.Invitation, error) {
+	const op = "OrgDal.GetInvitation"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, OrgID, Email, Role, Coalesce(InvitedBy, 0), Created_At, Expires_At, Accepted_At, Revoked_At
+	FROM
+		Invitations
+	WHERE
+		ID=$1
+	`
+
+	inv, err := scanInvitation(repo.Db.QueryRowContext(ctx, query, invitationID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Invitation{}, fmt.Errorf("%s:%w", op, ErrInvitationNotExist)
+		}
+		return models.Invitation{}, fmt.Errorf("%s:%w", op, err)
+	}
+	return inv, nil
+}
+
+func (repo *OrgDal) ListInvitations(ctx context.Context, orgID int) ([]models.Invitation, error) {
+	const op = "OrgDal.ListInvitations"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, OrgID, Email, Role, Coalesce(InvitedBy, 0), Created_At, Expires_At, Accepted_At, Revoked_At
+	FROM
+		Invitations
+	WHERE
+		OrgID=$1
+	ORDER BY
+		Created_At DESC
+	`
+
+	rows, err := repo.Db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		invitations = append(invitations, inv)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return invitations, nil
+}
+
+// Marks invitation as accepted and adds the user to the organization in one transaction, fails if
+// invitation was already accepted, revoked or expired. User without ID is saved with his events first
+func (repo *OrgDal) AcceptInvitation(ctx context.Context, inv models.Invitation, user *models.User, events ...models.UserEvent) (err error) {
+	const op = "OrgDal.AcceptInvitation"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Новый аккаунт создается только вместе с членством, при откате его ID не существует
+	if user.ID == 0 {
+		defer func() {
+			if err != nil {
+				user.ID = 0
+			}
+		}()
+		if err := saveUser(ctx, tx, user); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if err := insertUserEvents(ctx, tx, user.TenantID, user.ID, events); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+	UPDATE Invitations
+	SET Accepted_At = Now()
+	WHERE ID=$1 AND Accepted_At IS NULL AND Revoked_At IS NULL AND Expires_At > Now()
+	`, inv.ID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, models.ErrInvitationInvalid)
+	}
+
+	res, err = tx.ExecContext(ctx, `
+	INSERT INTO Memberships (OrgID, UserID, Role)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (OrgID, UserID) DO NOTHING
+	`, inv.OrgID, user.ID, inv.Role)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err = res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, models.ErrAlreadyMember)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+func (repo *OrgDal) RevokeInvitation(ctx context.Context, orgID, invitationID int) error {
+	const op = "OrgDal.RevokeInvitation"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE Invitations
+	SET Revoked_At = Now()
+	WHERE OrgID=$1 AND ID=$2 AND Accepted_At IS NULL AND Revoked_At IS NULL
+	`
+
+	res, err := repo.Db.ExecContext(ctx, query, orgID, invitationID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrInvitationNotExist)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvitation(row rowScanner) (models.Invitation, error) {
+	var (
+		inv               models.Invitation
+		accepted, revoked sql.NullTime
+	)
+	if err := row.Scan(&inv.ID, &inv.OrgID, &inv.Email, &inv.Role, &inv.InvitedBy, &inv.Created_At, &inv.Expires_At, &accepted, &revoked); err != nil {
+		return models.Invitation{}, err
+	}
+
+	if accepted.Valid {
+		inv.Accepted_At = &accepted.Time
+	}
+	if revoked.Valid {
+		inv.Revoked_At = &revoked.Time
+	}
+	return inv, nil
+}
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Inserts user events into the outbox. Must be called in the transaction of the user write,
+// payload is taken from the user row as it is after the write
+func insertUserEvents(ctx context.Context, db execer, tenantID string, userID int, events []models.UserEvent) error {
+	query := `
+	INSERT INTO Outbox (TenantID, Type, Payload)
+	SELECT
+		TenantID, $3,
+		jsonb_build_object('user', jsonb_build_object(
+			'id', ID, 'tenant_id', TenantID, 'name', Name, 'email', Email,
+			'role', Role, 'status', Status, 'is_admin', IsAdmin
+		)) || $4::jsonb
+	FROM
+		Users
+	WHERE
+		TenantID = $1 AND ID = $2`
+
+	for _, event := range events {
+		extra := []byte("{}")
+		if len(event.Extra) > 0 {
+			var err error
+			if extra, err = json.Marshal(event.Extra); err != nil {
+				return err
+			}
+		}
+		if _, err := db.ExecContext(ctx, query, tenantID, userID, event.Type, string(extra)); err != nil {
+			return fmt.Errorf("event %s: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+type EventDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewEventDal(Db *sql.DB, Timeouts Timeouts) *EventDal {
+	return &EventDal{Db: Db, Timeouts: Timeouts}
+}
+
+// Returns tenant events after the event with the ID in commit order. Empty types means all types.
+// IDs are taken before commit, so a transaction still running may commit an event below the returned
+// ones. Only events of transactions older than the oldest running one are returned, ordered by writer
+// transaction and ID, so events committed later always follow the cursor
+func (repo *EventDal) ListEvents(ctx context.Context, tenantID string, afterID int64, types []string, limit int) ([]models.Event, error) {
+	const op = "EventDal.ListEvents"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	// Если событие курсора удалено, продолжаем по ID
+	query := `
+	WITH cursor AS (
+		SELECT Xid FROM Outbox WHERE TenantID = $1 AND ID = $2
+	)
+	SELECT
+		ID, TenantID, Type, Payload, Created_At
+	FROM
+		Outbox
+	WHERE
+		TenantID = $1
+		AND CASE WHEN EXISTS (SELECT 1 FROM cursor) THEN (Xid, ID) > ((SELECT Xid FROM cursor), $2) ELSE ID > $2 END
+		AND (cardinality($3::TEXT[]) = 0 OR Type = ANY($3))
+		AND Xid < pg_snapshot_xmin(pg_current_snapshot())
+	ORDER BY
+		Xid, ID
+	LIMIT $4`
+
+	rows, err := repo.Db.QueryContext(ctx, query, tenantID, afterID, pq.Array(types), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var event models.Event
+		if err := rows.Scan(&event.ID, &event.TenantID, &event.Type, &event.Data, &event.Created_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return events, nil
+}
+
+// Returns the highest purged event ID of the tenant, 0 if nothing was purged
+func (repo *EventDal) PurgedThrough(ctx context.Context, tenantID string) (int64, error) {
+	const op = "EventDal.PurgedThrough"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `SELECT PurgedThrough FROM OutboxWatermarks WHERE TenantID = $1`
+
+	var purged int64
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID).Scan(&purged); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	return purged, nil
+}
@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTenantNotExist = errors.New("tenant does not exist")
+)
+
+type TenantDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewTenantDal(Db *sql.DB, Timeouts Timeouts) *TenantDal {
+	return &TenantDal{Db: Db, Timeouts: Timeouts}
+}
+
+func (repo *TenantDal) GetTenant(ctx context.Context, tenantID string) (models.Tenant, error) {
+	const op = "TenantDal.GetTenant"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, Name, Coalesce(AccessTTL, 0), Coalesce(RefreshTTL, 0), PasswordPolicy, Created_At, Coalesce(Updated_At, Created_At)
+	FROM
+		Tenants
+	WHERE
+		ID=$1
+	`
+
+	var (
+		tenant                models.Tenant
+		accessTTL, refreshTTL int64
+		policy                []byte
+	)
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID).
+		Scan(&tenant.ID, &tenant.Name, &accessTTL, &refreshTTL, &policy, &tenant.Created_At, &tenant.Updated_At); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Tenant{}, fmt.Errorf("%s:%w", op, ErrTenantNotExist)
+		}
+		return models.Tenant{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// TTL хранятся в секундах
+	tenant.AccessTTL = time.Duration(accessTTL) * time.Second
+	tenant.RefreshTTL = time.Duration(refreshTTL) * time.Second
+
+	if policy != nil {
+		tenant.Password = &models.PasswordPolicy{}
+		if err := json.Unmarshal(policy, tenant.Password); err != nil {
+			return models.Tenant{}, fmt.Errorf("%s: failed to decode password policy: %w", op, err)
+		}
+	}
+
+	return tenant, nil
+}
+
+// Updates tenant settings, zero TTLs and nil policy reset overrides to global values
+func (repo *TenantDal) UpdateTenant(ctx context.Context, tenant models.Tenant) error {
+	const op = "TenantDal.UpdateTenant"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE Tenants
+	SET AccessTTL = $1, RefreshTTL = $2, PasswordPolicy = $3, Updated_At = Now()
+	WHERE ID = $4
+	`
+
+	// NULL в PasswordPolicy означает глобальную политику
+	var policy any
+	if tenant.Password != nil {
+		encoded, err := json.Marshal(tenant.Password)
+		if err != nil {
+			return fmt.Errorf("%s: failed to encode password policy: %w", op, err)
+		}
+		policy = string(encoded)
+	}
+
+	res, err := repo.Db.ExecContext(ctx, query, nullSeconds(tenant.AccessTTL), nullSeconds(tenant.RefreshTTL), policy, tenant.ID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrTenantNotExist)
+	}
+
+	return nil
+}
+
+func nullSeconds(d time.Duration) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(d / time.Second), Valid: d > 0}
+}
+
+// Creates tenant if it doesn't exist yet
+func (repo *TenantDal) CreateTenant(ctx context.Context, tenantID, name string) error {
+	const op = "TenantDal.CreateTenant"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `INSERT INTO Tenants (ID, Name) VALUES ($1, $2) ON CONFLICT (ID) DO NOTHING`
+
+	if _, err := repo.Db.ExecContext(ctx, query, tenantID, name); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
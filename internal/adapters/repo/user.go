@@ -2,9 +2,16 @@ package repo
 
 import (
 	"auth/internal/domain/models"
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 var (
@@ -12,30 +19,37 @@ var (
 )
 
 type UserDal struct {
-	Db *sql.DB
+	Db       *sql.DB
+	Timeouts Timeouts
 }
 
-func NewUserDal(Db *sql.DB) *UserDal {
-	return &UserDal{Db: Db}
+func NewUserDal(Db *sql.DB, Timeouts Timeouts) *UserDal {
+	return &UserDal{Db: Db, Timeouts: Timeouts}
 }
 
-func (repo *UserDal) GetUser(email string) (models.User, error) {
+func (repo *UserDal) GetUser(ctx context.Context, tenantID, email string) (_ models.User, err error) {
 	const op = "UserDal.GetUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
 	query := `
 	SELECT 
-		ID, Name, Email, PassHash, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role 
+		ID, TenantID, Name, Email, PassHash, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role, Status, MustChangePassword, AvatarURL, Locale, Timezone, Metadata
 	FROM   
 		Users
 	WHERE
-		Email=$1
+		TenantID=$1 AND Email=$2
 	LIMIT 
 		1
 	`
 
 	var user models.User
 	var passHash string
-	if err := repo.Db.QueryRow(query, email).
-		Scan(&user.ID, &user.Name, &user.Email, &passHash, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role); err != nil {
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID, email).
+		Scan(&user.ID, &user.TenantID, &user.Name, &user.Email, &passHash, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role, &user.Status, &user.MustChangePassword, &user.AvatarURL, &user.Locale, &user.Timezone, &user.Metadata); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s:%w", op, ErrUserNotExist)
 		}
@@ -46,23 +60,29 @@ func (repo *UserDal) GetUser(email string) (models.User, error) {
 	return user, nil
 }
 
-func (repo *UserDal) GetUserByID(userID int) (models.User, error) {
-	const op = "UserDal.GetUser"
+func (repo *UserDal) GetUserByID(ctx context.Context, tenantID string, userID int) (_ models.User, err error) {
+	const op = "UserDal.GetUserByID"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
 	query := `
 	SELECT 
-		ID, Name, Email, PassHash, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role 
+		ID, TenantID, Name, Email, PassHash, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role, Status, MustChangePassword, AvatarURL, Locale, Timezone, Metadata
 	FROM   
 		Users
 	WHERE
-		ID=$1
+		TenantID=$1 AND ID=$2
 	LIMIT 
 		1
 	`
 
 	var user models.User
 	var passHash string
-	if err := repo.Db.QueryRow(query, userID).
-		Scan(&user.ID, &user.Name, &user.Email, &passHash, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role); err != nil {
+	if err := repo.Db.QueryRowContext(ctx, query, tenantID, userID).
+		Scan(&user.ID, &user.TenantID, &user.Name, &user.Email, &passHash, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role, &user.Status, &user.MustChangePassword, &user.AvatarURL, &user.Locale, &user.Timezone, &user.Metadata); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s:%w", op, ErrUserNotExist)
 		}
@@ -73,29 +93,234 @@ func (repo *UserDal) GetUserByID(userID int) (models.User, error) {
 	return user, nil
 }
 
-// Saves user and sets his ID
-func (repo *UserDal) SaveUser(user *models.User) error {
+// Saves user with his events and sets his ID
+func (repo *UserDal) SaveUser(ctx context.Context, user *models.User, events ...models.UserEvent) (err error) {
 	const op = "UserDal.SaveUser"
-	query := `
-	INSERT INTO Users (Name, Email, PassHash, IsAdmin, Role)
-	VALUES ($1, $2, $3, $4, $5)
-	RETURNING ID
-	`
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
 
 	// QueryRow для получения ID
-	if err := repo.Db.QueryRow(query, user.Name, user.Email, user.GetPassword(), user.IsAdmin, user.Role).
-		Scan(&user.ID); err != nil {
+	if err := saveUser(ctx, tx, user); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	if err := insertUserEvents(ctx, tx, user.TenantID, user.ID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("%s:%w", op, err)
 	}
 	return nil
 }
-func (repo *UserDal) DeleteUser(userID int) error {
+
+// Saves all users with events of each in one transaction, nothing is saved if any row fails
+func (repo *UserDal) SaveUsers(ctx context.Context, users []*models.User, events ...models.UserEvent) (err error) {
+	const op = "UserDal.SaveUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, user := range users {
+		if err := saveUser(ctx, tx, user); err != nil {
+			return fmt.Errorf("%s: %s: %w", op, user.Email, err)
+		}
+		if err := insertUserEvents(ctx, tx, user.TenantID, user.ID, events); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Sets new password hash. mustChange marks it temporary, it must be changed at next login
+func (repo *UserDal) UpdatePassword(ctx context.Context, tenantID string, userID int, passHash string, mustChange bool) (err error) {
+	const op = "UserDal.UpdatePassword"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `UPDATE Users
+	SET PassHash = $1, MustChangePassword = $4, Updated_At = Now()
+	WHERE ID = $2 AND TenantID = $3
+	`
+
+	res, err := repo.Db.ExecContext(ctx, query, passHash, userID, tenantID, mustChange)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+	}
+
+	return nil
+}
+
+// Grants or revokes admin rights with the role. Revoking fails with ErrLastAdmin
+// if no other active admin is left in the tenant
+func (repo *UserDal) SetAdmin(ctx context.Context, tenantID string, userID int, isAdmin bool, role string, events ...models.UserEvent) (err error) {
+	const op = "UserDal.SetAdmin"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	if !isAdmin {
+		// Блокируем остальных админов, чтобы параллельные понижения не оставили тенант без админа
+		var others int
+		if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT ID FROM Users
+			WHERE TenantID = $1 AND ID <> $2 AND IsAdmin AND Status = 'active'
+			FOR UPDATE
+		) admins`, tenantID, userID).Scan(&others); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if others == 0 {
+			return fmt.Errorf("%s:%w", op, models.ErrLastAdmin)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `UPDATE Users SET IsAdmin = $1, Role = $2, Updated_At = Now() WHERE TenantID = $3 AND ID = $4`,
+		isAdmin, role, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+	}
+
+	if err := insertUserEvents(ctx, tx, tenantID, userID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func saveUser(ctx context.Context, db queryRower, user *models.User) error {
+	query := `
+	INSERT INTO Users (TenantID, Name, Email, PassHash, IsAdmin, Role, MustChangePassword)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING ID, Status
+	`
+
+	err := db.QueryRowContext(ctx, query, user.TenantID, user.Name, user.Email, user.GetPassword(), user.IsAdmin, user.Role, user.MustChangePassword).
+		Scan(&user.ID, &user.Status)
+
+	// Email уникален в рамках тенанта
+	if isUniqueViolation(err) {
+		return models.ErrNotUniqueEmail
+	}
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// Soft deletes user. The row is kept until retention window passes and PurgeUsers deletes it
+func (repo *UserDal) DeleteUser(ctx context.Context, tenantID string, userID int, events ...models.UserEvent) (err error) {
 	const op = "UserDal.DeleteUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	if err := repo.UpdateStatus(ctx, tenantID, userID, []string{models.StatusActive, models.StatusDisabled}, models.StatusPendingDeletion, events...); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Moves user to status "to" only if current status is one of "from" and saves the events.
+// Leaving active status fails with ErrLastAdmin for the last active admin of the tenant
+func (repo *UserDal) UpdateStatus(ctx context.Context, tenantID string, userID int, from []string, to string, events ...models.UserEvent) (err error) {
+	const op = "UserDal.UpdateStatus"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	if to != models.StatusActive {
+		// Блокируем активных админов, чтобы отключение не оставило тенант без админа, как и при понижении
+		var others, target int
+		if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE ID <> $2), COUNT(*) FILTER (WHERE ID = $2) FROM (
+			SELECT ID FROM Users
+			WHERE TenantID = $1 AND IsAdmin AND Status = 'active'
+			FOR UPDATE
+		) admins`, tenantID, userID).Scan(&others, &target); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if target > 0 && others == 0 {
+			return fmt.Errorf("%s:%w", op, models.ErrLastAdmin)
+		}
+	}
+
+	// Отметка удаления нужна только ожидающим удаления, восстановление её сбрасывает
+	var deletedAt *time.Time
+	if to == models.StatusPendingDeletion {
+		now := time.Now()
+		deletedAt = &now
+	}
+
 	query := `
-		DELETE FROM Users
-		WHERE ID = $1`
+		UPDATE Users
+		SET Status = $1, Deleted_At = $2, Updated_At = Now()
+		WHERE TenantID = $3 AND ID = $4 AND Status = ANY($5)`
 
-	res, err := repo.Db.Exec(query, userID)
+	res, err := tx.ExecContext(ctx, query, to, deletedAt, tenantID, userID, pq.Array(from))
 	if err != nil {
 		return fmt.Errorf("%s:%w", op, err)
 	}
@@ -106,21 +331,237 @@ func (repo *UserDal) DeleteUser(userID int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+		// Отличаем отсутствующего пользователя от недопустимого перехода
+		var status string
+		err := tx.QueryRowContext(ctx, `SELECT Status FROM Users WHERE TenantID = $1 AND ID = $2`, tenantID, userID).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		return fmt.Errorf("%s:%w", op, models.ErrStatusTransition)
+	}
+
+	if err := insertUserEvents(ctx, tx, tenantID, userID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
 	return nil
 }
 
-func (repo *UserDal) UpdateUser(name string, role string, userID int) error {
+// Purges users pending deletion since before deletedBefore: removes their memberships, invitations and login history,
+// saves user.purged event of each with anonymized data and deletes the rows. Owners of organizations are kept anonymized
+// with their owner memberships so organizations stay manageable, and are deleted once they own none. Audit entries
+// reference users only by ID, so the audit chain stays verifiable. Returns count of purged users
+func (repo *UserDal) PurgeUsers(ctx context.Context, deletedBefore time.Time, limit int) (_ int, err error) {
+	const op = "UserDal.PurgeUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	WITH expired AS (
+		SELECT ID, TenantID, Email
+		FROM Users
+		WHERE Status = 'pending_deletion' AND Deleted_At < $1
+		ORDER BY Deleted_At
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	), memberships AS (
+		DELETE FROM Memberships m USING expired e
+		WHERE m.UserID = e.ID AND m.Role <> 'owner'
+	), invitations AS (
+		DELETE FROM Invitations i USING expired e, Organizations o
+		WHERE i.OrgID = o.ID AND o.TenantID = e.TenantID AND i.Email = e.Email
+	), logins AS (
+		DELETE FROM LoginHistory l USING expired e
+		WHERE l.UserID = e.ID
+	)
+	UPDATE Users u
+	SET Status = 'deleted', Name = 'Deleted user', Email = 'deleted-' || u.ID || '@deleted.invalid',
+		PassHash = '', MustChangePassword = false, AvatarURL = '', Locale = '', Timezone = '', Metadata = '{}',
+		Updated_At = Now()
+	FROM expired e
+	WHERE u.ID = e.ID
+	RETURNING u.TenantID, u.ID`
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, deletedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	type purgedUser struct {
+		tenantID string
+		ID       int
+	}
+	var purged []purgedUser
+	for rows.Next() {
+		var user purgedUser
+		if err := rows.Scan(&user.tenantID, &user.ID); err != nil {
+			return 0, fmt.Errorf("%s:%w", op, err)
+		}
+		purged = append(purged, user)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Данные уже обезличены, в событии остаются только идентификаторы
+	for _, user := range purged {
+		if err := insertUserEvents(ctx, tx, user.tenantID, user.ID, []models.UserEvent{{Type: models.EventUserPurged}}); err != nil {
+			return 0, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	// Удаляем обезличенные строки, кроме владельцев организаций
+	if _, err := tx.ExecContext(ctx, `
+	DELETE FROM Users
+	WHERE ID IN (
+		SELECT u.ID FROM Users u
+		WHERE u.Status = 'deleted' AND NOT EXISTS (SELECT 1 FROM Organizations o WHERE o.OwnerID = u.ID)
+		LIMIT $1
+	)`, limit); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	return len(purged), nil
+}
+
+func (repo *UserDal) UpdateUser(ctx context.Context, tenantID string, name string, role string, userID int, events ...models.UserEvent) (err error) {
 	const op = "UserDal.UpdateUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
 	query := `UPDATE Users
 	SET Name=$1 , Role = $2 , Updated_at = Now()
-	WHERE ID=$3
+	WHERE ID=$3 AND TenantID=$4
 	`
 
-	res, err := repo.Db.Exec(query, name, role, userID)
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, name, role, userID, tenantID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+	}
+
+	if err := insertUserEvents(ctx, tx, tenantID, userID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Updates profile fields set in update with events of the user, nil fields keep current values
+func (repo *UserDal) UpdateProfile(ctx context.Context, tenantID string, userID int, update models.ProfileUpdate, events ...models.UserEvent) (err error) {
+	const op = "UserDal.UpdateProfile"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE Users
+	SET Name = Coalesce($1, Name), AvatarURL = Coalesce($2, AvatarURL), Locale = Coalesce($3, Locale),
+		Timezone = Coalesce($4, Timezone), Metadata = Coalesce($5::jsonb, Metadata), Updated_At = Now()
+	WHERE TenantID = $6 AND ID = $7`
+
+	var metadata *string
+	if update.Metadata != nil {
+		raw := string(update.Metadata)
+		metadata = &raw
+	}
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, update.Name, update.AvatarURL, update.Locale, update.Timezone, metadata, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
+	}
+
+	if err := insertUserEvents(ctx, tx, tenantID, userID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Changes email only if the current one is still oldEmail, so a confirmation link works once. Events are saved with the change
+func (repo *UserDal) UpdateEmail(ctx context.Context, tenantID string, userID int, oldEmail, newEmail string, events ...models.UserEvent) (err error) {
+	const op = "UserDal.UpdateEmail"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE Users
+	SET Email = $1, Updated_At = Now()
+	WHERE TenantID = $2 AND ID = $3 AND Email = $4 AND Status = 'active'`
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, newEmail, tenantID, userID, oldEmail)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s:%w", op, models.ErrNotUniqueEmail)
+		}
 		return fmt.Errorf("%s:%w", op, err)
 	}
 
@@ -133,5 +574,223 @@ func (repo *UserDal) UpdateUser(name string, role string, userID int) error {
 		return fmt.Errorf("%s:%w", op, ErrUserNotExist)
 	}
 
+	if err := insertUserEvents(ctx, tx, tenantID, userID, events); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Sort columns of users listing, ID is used as a tie-breaker
+var userSortColumns = map[string]struct {
+	column string
+	cast   string
+}{
+	models.SortByCreatedAt: {"Created_At", "timestamptz"},
+	models.SortByName:      {"Name", "text"},
+	models.SortByEmail:     {"Email", "text"},
+}
+
+// Keyset pagination cursor: sort value and ID of the last row on the page with the order it was built for
+type userCursor struct {
+	Value  string `json:"v"`
+	ID     int    `json:"id"`
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+}
+
+// Lists tenant users page by page. Rows are ordered by sort column and ID,
+// so the cursor stays stable while users are inserted or deleted.
+func (repo *UserDal) ListUsers(ctx context.Context, filter models.UserFilter) (_ models.UserPage, err error) {
+	const op = "UserDal.ListUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	sortBy := filter.SortBy
+	if _, ok := userSortColumns[sortBy]; !ok {
+		sortBy = models.SortByCreatedAt
+	}
+	sort := userSortColumns[sortBy]
+
+	var cursor userCursor
+	if filter.Cursor != "" {
+		if cursor, err = decodeUserCursor(filter.Cursor, sortBy, filter.Desc); err != nil {
+			return models.UserPage{}, fmt.Errorf("%s:%w", op, err)
+		}
+	}
+
+	conds, args := userFilterConds(filter)
+
+	var page models.UserPage
+	countQuery := "SELECT COUNT(*) FROM Users WHERE " + strings.Join(conds, " AND ")
+	if err := repo.Db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return models.UserPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	direction, cmp := "ASC", ">"
+	if filter.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		args = append(args, cursor.Value, cursor.ID)
+		conds = append(conds, fmt.Sprintf("(%s, ID) %s ($%d::%s, $%d)", sort.column, cmp, len(args)-1, sort.cast, len(args)))
+	}
+
+	// Запрашиваем на одну строку больше, чтобы понять есть ли следующая страница
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+	SELECT
+		ID, TenantID, Name, Email, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role, Status, MustChangePassword, AvatarURL, Locale, Timezone, Metadata
+	FROM
+		Users
+	WHERE
+		%s
+	ORDER BY
+		%s %s, ID %s
+	LIMIT
+		$%d
+	`, strings.Join(conds, " AND "), sort.column, direction, direction, len(args))
+
+	rows, err := repo.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.UserPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.TenantID, &user.Name, &user.Email, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role, &user.Status, &user.MustChangePassword, &user.AvatarURL, &user.Locale, &user.Timezone, &user.Metadata); err != nil {
+			return models.UserPage{}, fmt.Errorf("%s:%w", op, err)
+		}
+		page.Users = append(page.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return models.UserPage{}, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if len(page.Users) > filter.Limit {
+		page.Users = page.Users[:filter.Limit]
+		page.NextCursor = encodeUserCursor(page.Users[len(page.Users)-1], sortBy, filter.Desc)
+	}
+
+	return page, nil
+}
+
+// Streams all users matching filter ordered by ID. Rows are read one by one,
+// so export of the whole tenant does not load it into memory.
+func (repo *UserDal) ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) (err error) {
+	const op = "UserDal.ExportUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	ctx, cancel := repo.Timeouts.stream(ctx)
+	defer cancel()
+
+	conds, args := userFilterConds(filter)
+	query := fmt.Sprintf(`
+	SELECT
+		ID, TenantID, Name, Email, IsAdmin, Created_At, Coalesce(Updated_At,Created_At), Role, Status, MustChangePassword, AvatarURL, Locale, Timezone, Metadata
+	FROM
+		Users
+	WHERE
+		%s
+	ORDER BY
+		ID
+	`, strings.Join(conds, " AND "))
+
+	rows, err := repo.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.TenantID, &user.Name, &user.Email, &user.IsAdmin, &user.Created_At, &user.Updated_At, &user.Role, &user.Status, &user.MustChangePassword, &user.AvatarURL, &user.Locale, &user.Timezone, &user.Metadata); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if err := fn(user); err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
 	return nil
 }
+
+// Builds WHERE conditions of users filter, cursor and sorting are not applied
+func userFilterConds(filter models.UserFilter) ([]string, []any) {
+	conds := []string{"TenantID = $1"}
+	args := []any{filter.TenantID}
+	addCond := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Role != "" {
+		addCond("Role = $%d", filter.Role)
+	}
+	if filter.Status != "" {
+		addCond("Status = $%d", filter.Status)
+	}
+	if filter.Query != "" {
+		// Поиск по подстроке использует trigram индексы
+		addCond("(lower(Email) LIKE $%[1]d OR lower(Name) LIKE $%[1]d)", "%"+escapeLike(strings.ToLower(filter.Query))+"%")
+	}
+	if !filter.CreatedFrom.IsZero() {
+		addCond("Created_At >= $%d", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		addCond("Created_At < $%d", filter.CreatedTo)
+	}
+	return conds, args
+}
+
+func encodeUserCursor(last models.User, sortBy string, desc bool) string {
+	cursor := userCursor{ID: last.ID, SortBy: sortBy, Desc: desc}
+	switch sortBy {
+	case models.SortByName:
+		cursor.Value = last.Name
+	case models.SortByEmail:
+		cursor.Value = last.Email
+	default:
+		cursor.Value = last.Created_At.Format(time.RFC3339Nano)
+	}
+
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// Decodes cursor of the page sorted the same way, cursor of another order can't continue the listing
+func decodeUserCursor(raw, sortBy string, desc bool) (userCursor, error) {
+	var cursor userCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return userCursor{}, models.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(decoded, &cursor); err != nil || cursor.ID == 0 {
+		return userCursor{}, models.ErrInvalidCursor
+	}
+	if cursor.SortBy != sortBy || cursor.Desc != desc {
+		return userCursor{}, models.ErrInvalidCursor
+	}
+	// Значение сравнивается с колонкой сортировки, время должно разбираться
+	if sortBy == models.SortByCreatedAt {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return userCursor{}, models.ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,358 @@
+package repo
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrWebhookNotExist  = errors.New("webhook does not exist")
+	ErrDeliveryNotExist = errors.New("delivery does not exist")
+)
+
+type WebhookDal struct {
+	Db       *sql.DB
+	Timeouts Timeouts
+}
+
+func NewWebhookDal(Db *sql.DB, Timeouts Timeouts) *WebhookDal {
+	return &WebhookDal{Db: Db, Timeouts: Timeouts}
+}
+
+// Saves webhook and sets its ID
+func (repo *WebhookDal) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	const op = "WebhookDal.CreateWebhook"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	INSERT INTO Webhooks (TenantID, URL, Secret, Events)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ID, Created_At`
+
+	if err := repo.Db.QueryRowContext(ctx, query, webhook.TenantID, webhook.URL, webhook.Secret, pq.Array(webhook.Events)).
+		Scan(&webhook.ID, &webhook.Created_At); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Returns tenant webhooks without secrets
+func (repo *WebhookDal) ListWebhooks(ctx context.Context, tenantID string) ([]models.Webhook, error) {
+	const op = "WebhookDal.ListWebhooks"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		ID, TenantID, URL, Events, Created_At
+	FROM
+		Webhooks
+	WHERE
+		TenantID = $1
+	ORDER BY
+		ID`
+
+	rows, err := repo.Db.QueryContext(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var webhook models.Webhook
+		if err := rows.Scan(&webhook.ID, &webhook.TenantID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Created_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return webhooks, nil
+}
+
+// Deletes webhook with all its deliveries
+func (repo *WebhookDal) DeleteWebhook(ctx context.Context, tenantID string, webhookID int) error {
+	const op = "WebhookDal.DeleteWebhook"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	res, err := repo.Db.ExecContext(ctx, `DELETE FROM Webhooks WHERE TenantID = $1 AND ID = $2`, tenantID, webhookID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s:%w", op, ErrWebhookNotExist)
+	}
+	return nil
+}
+
+// Returns deliveries to tenant webhooks with the status (any when empty), newest first
+func (repo *WebhookDal) ListDeliveries(ctx context.Context, tenantID, status string, limit int) ([]models.WebhookDelivery, error) {
+	const op = "WebhookDal.ListDeliveries"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	SELECT
+		d.ID, d.WebhookID, d.EventID, o.Type, d.Status, d.Attempts, d.LastError, d.NextAttemptAt, d.Created_At, d.Updated_At
+	FROM
+		WebhookDeliveries d
+		JOIN Webhooks w ON w.ID = d.WebhookID
+		JOIN Outbox o ON o.ID = d.EventID
+	WHERE
+		w.TenantID = $1 AND ($2 = '' OR d.Status = $2)
+	ORDER BY
+		d.ID DESC
+	LIMIT
+		$3`
+
+	rows, err := repo.Db.QueryContext(ctx, query, tenantID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.LastError,
+			&d.NextAttemptAt, &d.Created_At, &d.Updated_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return deliveries, nil
+}
+
+// Returns dead delivery back to the queue with reset attempts
+func (repo *WebhookDal) RetryDelivery(ctx context.Context, tenantID string, deliveryID int64) error {
+	const op = "WebhookDal.RetryDelivery"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE WebhookDeliveries d
+	SET Status = 'pending', Attempts = 0, NextAttemptAt = Now(), Updated_At = Now()
+	FROM Webhooks w
+	WHERE d.ID = $2 AND w.ID = d.WebhookID AND w.TenantID = $1 AND d.Status = 'dead'`
+
+	res, err := repo.Db.ExecContext(ctx, query, tenantID, deliveryID)
+	if err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		// Отличаем отсутствующую доставку от недоставленной еще
+		var exists bool
+		err := repo.Db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM WebhookDeliveries d JOIN Webhooks w ON w.ID = d.WebhookID
+			WHERE d.ID = $2 AND w.TenantID = $1
+		)`, tenantID, deliveryID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("%s:%w", op, err)
+		}
+		if !exists {
+			return fmt.Errorf("%s:%w", op, ErrDeliveryNotExist)
+		}
+		return fmt.Errorf("%s:%w", op, models.ErrStatusTransition)
+	}
+	return nil
+}
+
+// Creates deliveries of not dispatched outbox events for subscribed webhooks of their tenants.
+// Returns count of dispatched events
+func (repo *WebhookDal) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	const op = "WebhookDal.FanOutEvents"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	WITH events AS (
+		SELECT ID, TenantID, Type
+		FROM Outbox
+		WHERE Dispatched_At IS NULL
+		ORDER BY ID
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	), deliveries AS (
+		INSERT INTO WebhookDeliveries (WebhookID, EventID)
+		SELECT w.ID, e.ID
+		FROM events e
+		JOIN Webhooks w ON w.TenantID = e.TenantID AND (cardinality(w.Events) = 0 OR e.Type = ANY(w.Events))
+		ON CONFLICT (WebhookID, EventID) DO NOTHING
+	)
+	UPDATE Outbox o
+	SET Dispatched_At = Now()
+	FROM events e
+	WHERE o.ID = e.ID`
+
+	res, err := repo.Db.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	return int(rowsAffected), nil
+}
+
+// Takes due deliveries with their webhook and event. Next attempt is moved by lease,
+// so other dispatchers don't take the delivery while it is being sent
+func (repo *WebhookDal) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	const op = "WebhookDal.ClaimDeliveries"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE WebhookDeliveries d
+	SET NextAttemptAt = Now() + make_interval(secs => $2), Updated_At = Now()
+	FROM (
+		SELECT ID
+		FROM WebhookDeliveries
+		WHERE Status = 'pending' AND NextAttemptAt <= Now()
+		ORDER BY NextAttemptAt
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	) due, Webhooks w, Outbox o
+	WHERE d.ID = due.ID AND w.ID = d.WebhookID AND o.ID = d.EventID
+	RETURNING
+		d.ID, d.WebhookID, d.EventID, o.Type, d.Status, d.Attempts, d.LastError, d.NextAttemptAt, d.Created_At, d.Updated_At,
+		w.URL, w.Secret, o.TenantID, o.Payload, o.Created_At`
+
+	rows, err := repo.Db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.LastError,
+			&d.NextAttemptAt, &d.Created_At, &d.Updated_At,
+			&d.URL, &d.Secret, &d.Event.TenantID, &d.Event.Data, &d.Event.Created_At); err != nil {
+			return nil, fmt.Errorf("%s:%w", op, err)
+		}
+		d.Event.ID, d.Event.Type = d.EventID, d.EventType
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%w", op, err)
+	}
+	return deliveries, nil
+}
+
+func (repo *WebhookDal) MarkDelivered(ctx context.Context, deliveryID int64) error {
+	const op = "WebhookDal.MarkDelivered"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE WebhookDeliveries
+	SET Status = 'delivered', Attempts = Attempts + 1, LastError = '', Updated_At = Now()
+	WHERE ID = $1`
+
+	if _, err := repo.Db.ExecContext(ctx, query, deliveryID); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Counts failed attempt and schedules the next one, dead delivery is not retried anymore
+func (repo *WebhookDal) MarkFailed(ctx context.Context, deliveryID int64, lastErr string, nextAttemptAt time.Time, dead bool) error {
+	const op = "WebhookDal.MarkFailed"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	query := `
+	UPDATE WebhookDeliveries
+	SET Status = CASE WHEN $4 THEN 'dead' ELSE 'pending' END, Attempts = Attempts + 1,
+		LastError = $2, NextAttemptAt = $3, Updated_At = Now()
+	WHERE ID = $1`
+
+	if _, err := repo.Db.ExecContext(ctx, query, deliveryID, lastErr, nextAttemptAt, dead); err != nil {
+		return fmt.Errorf("%s:%w", op, err)
+	}
+	return nil
+}
+
+// Deletes dead letters last attempted before the time, then events dispatched before it which have
+// all deliveries delivered, and moves tenant watermarks to the highest deleted ID. Events of dead letters
+// are kept with them, so administrators can requeue them during retention. Returns count of deleted events
+func (repo *WebhookDal) PurgeEvents(ctx context.Context, dispatchedBefore time.Time) (int, error) {
+	const op = "WebhookDal.PurgeEvents"
+
+	ctx, cancel := repo.Timeouts.query(ctx)
+	defer cancel()
+
+	tx, err := repo.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM WebhookDeliveries WHERE Status = 'dead' AND Updated_At < $1`, dispatchedBefore); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	// Каскадно удаляются только доставленные события
+	query := `
+	WITH purged AS (
+		DELETE FROM Outbox o
+		WHERE o.Dispatched_At < $1 AND NOT EXISTS (
+			SELECT 1 FROM WebhookDeliveries d WHERE d.EventID = o.ID AND d.Status <> 'delivered'
+		)
+		RETURNING o.TenantID, o.ID
+	), watermarks AS (
+		INSERT INTO OutboxWatermarks (TenantID, PurgedThrough)
+		SELECT TenantID, MAX(ID) FROM purged GROUP BY TenantID
+		ON CONFLICT (TenantID) DO UPDATE
+		SET PurgedThrough = GREATEST(OutboxWatermarks.PurgedThrough, EXCLUDED.PurgedThrough)
+	)
+	SELECT COUNT(*) FROM purged`
+
+	var count int
+	if err := tx.QueryRowContext(ctx, query, dispatchedBefore).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s:%w", op, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,229 @@
+package grpcserver
+
+import (
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/adapters/transport/grpc/routers"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Metadata key of the access token, "authorization: Bearer <token>"
+const authorizationKey = "authorization"
+
+type access int
+
+const (
+	accessRequired access = iota // Call is rejected without valid token, default for methods missing in the table
+	accessOptional               // Anonymous calls are passed, a given token must be valid
+	accessPublic                 // Token is not checked
+)
+
+type methodAuth struct {
+	access access
+	// Deprecated field of the request message with the token, read when metadata has no authorization
+	legacyField protoreflect.Name
+}
+
+// Auth requirements of every method. Roles are checked by services
+var methodAuths = map[string]methodAuth{
+	authv1.AuthService_Login_FullMethodName:          {access: accessPublic},
+	authv1.AuthService_Register_FullMethodName:       {access: accessPublic},
+	authv1.AuthService_Refresh_FullMethodName:        {access: accessPublic},
+	authv1.AuthService_ChangePassword_FullMethodName: {access: accessPublic},
+	authv1.AuthService_WhoAmI_FullMethodName:         {access: accessRequired, legacyField: "token"},
+
+	authv1.AdminService_GetUser_FullMethodName:         {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_ListUsers_FullMethodName:       {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_CreateUser_FullMethodName:      {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_ExportUsers_FullMethodName:     {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_WatchUserEvents_FullMethodName: {access: accessRequired, legacyField: "token"},
+	authv1.AdminService_UpdateUser_FullMethodName:      {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_DeleteUser_FullMethodName:      {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_DisableUser_FullMethodName:     {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_EnableUser_FullMethodName:      {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_RestoreUser_FullMethodName:     {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_GetTenant_FullMethodName:       {access: accessRequired, legacyField: "admin_token"},
+	authv1.AdminService_UpdateTenant_FullMethodName:    {access: accessRequired, legacyField: "admin_token"},
+
+	authv1.OrgService_CreateOrganization_FullMethodName: {access: accessRequired, legacyField: "token"},
+	authv1.OrgService_InviteMember_FullMethodName:       {access: accessRequired, legacyField: "token"},
+	authv1.OrgService_AcceptInvitation_FullMethodName:   {access: accessOptional, legacyField: "access_token"},
+	authv1.OrgService_RevokeInvitation_FullMethodName:   {access: accessRequired, legacyField: "token"},
+	authv1.OrgService_ListInvitations_FullMethodName:    {access: accessRequired, legacyField: "token"},
+	authv1.OrgService_ListMembers_FullMethodName:        {access: accessRequired, legacyField: "token"},
+	authv1.OrgService_UpdateMemberRole_FullMethodName:   {access: accessRequired, legacyField: "token"},
+
+	authv1.ProfileService_GetProfile_FullMethodName:    {access: accessRequired, legacyField: "token"},
+	authv1.ProfileService_UpdateProfile_FullMethodName: {access: accessRequired, legacyField: "token"},
+	// Токен в запросе - это ссылка подтверждения из письма, а не токен доступа
+	authv1.ProfileService_ConfirmEmailChange_FullMethodName: {access: accessPublic},
+	authv1.ProfileService_ListLogins_FullMethodName:         {access: accessRequired, legacyField: "token"},
+
+	authv1.WebhookService_CreateWebhook_FullMethodName:  {access: accessRequired, legacyField: "admin_token"},
+	authv1.WebhookService_ListWebhooks_FullMethodName:   {access: accessRequired, legacyField: "admin_token"},
+	authv1.WebhookService_DeleteWebhook_FullMethodName:  {access: accessRequired, legacyField: "admin_token"},
+	authv1.WebhookService_ListDeliveries_FullMethodName: {access: accessRequired, legacyField: "admin_token"},
+	authv1.WebhookService_RetryDelivery_FullMethodName:  {access: accessRequired, legacyField: "admin_token"},
+
+	authv1.AuditService_ListAuditLog_FullMethodName:   {access: accessRequired, legacyField: "admin_token"},
+	authv1.AuditService_VerifyAuditLog_FullMethodName: {access: accessRequired, legacyField: "admin_token"},
+
+	healthv1.Health_Check_FullMethodName: {access: accessPublic},
+	healthv1.Health_List_FullMethodName:  {access: accessPublic},
+	healthv1.Health_Watch_FullMethodName: {access: accessPublic},
+}
+
+// Authenticates calls by the access token from authorization metadata
+type authenticator struct {
+	tokenServ *service.TokenService
+	log       *slog.Logger
+}
+
+func authUnaryInterceptor(tokenServ *service.TokenService, log *slog.Logger) grpc.UnaryServerInterceptor {
+	a := authenticator{tokenServ: tokenServ, log: log}
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStreamInterceptor(tokenServ *service.TokenService, log *slog.Logger) grpc.StreamServerInterceptor {
+	a := authenticator{tokenServ: tokenServ, log: log}
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		token, err := metadataToken(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		// Устаревшее поле с токеном доступно только после чтения сообщения
+		if token == "" && methodAuths[info.FullMethod].legacyField != "" {
+			return handler(srv, &authStream{requestStream: requestStream{ServerStream: stream, ctx: stream.Context()}, auth: a, method: info.FullMethod})
+		}
+
+		ctx, err := a.authenticate(stream.Context(), info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &requestStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// Stream authenticated by the first request message
+type authStream struct {
+	requestStream
+	auth   authenticator
+	method string
+	done   bool
+}
+
+func (s *authStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.done {
+		return nil
+	}
+	s.done = true
+
+	ctx, err := s.auth.authenticate(s.ctx, s.method, m)
+	if err != nil {
+		return err
+	}
+	s.ctx = ctx
+	return nil
+}
+
+// Checks token of the call against requirements of the method. Returned context carries the token and its claims,
+// so services don't validate it again
+func (a authenticator) authenticate(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	rule := methodAuths[method]
+	if rule.access == accessPublic {
+		return ctx, nil
+	}
+
+	token, err := metadataToken(ctx)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to read authorization metadata", "method", method, "error", err)
+		return ctx, err
+	}
+	if token == "" && rule.legacyField != "" {
+		token = messageToken(req, rule.legacyField)
+		if token != "" {
+			a.log.WarnContext(ctx, "Token in request message is deprecated, pass it in authorization metadata",
+				"method", method, "field", string(rule.legacyField))
+		}
+	}
+
+	if token == "" {
+		if rule.access == accessOptional {
+			return ctx, nil
+		}
+		a.log.ErrorContext(ctx, "Access token not found", "method", method)
+		return ctx, status.Error(codes.Unauthenticated, "access token not found")
+	}
+
+	ctx, claims, err := a.tokenServ.Authenticate(ctx, token)
+	if err != nil {
+		a.log.ErrorContext(ctx, "Failed to authenticate call", "method", method, "error", err)
+		return ctx, status.Errorf(utils.GetGRPCStatus(err), "failed to authenticate: %v", err)
+	}
+
+	a.log.DebugContext(ctx, "Call authenticated", "tenant", claims.TenantID, "ID", claims.ID)
+	return routers.WithAccessToken(ctx, token), nil
+}
+
+// Returns bearer token from authorization metadata, empty when there is none
+func metadataToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", status.Error(codes.Unauthenticated, "authorization metadata must be Bearer token")
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// Reads token from the deprecated string field of the request message
+func messageToken(req interface{}, field protoreflect.Name) string {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return ""
+	}
+	m := msg.ProtoReflect()
+	fd := m.Descriptor().Fields().ByName(field)
+	if fd == nil || fd.Kind() != protoreflect.StringKind {
+		return ""
+	}
+	return m.Get(fd).String()
+}
@@ -0,0 +1,56 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcHandledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Completed gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+	grpcHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Latency of gRPC calls by method, streams are measured until they end.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func metricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeCall(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func metricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		observeCall(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeCall(method string, start time.Time, err error) {
+	grpcHandledTotal.WithLabelValues(method, status.Code(err).String()).Inc()
+	grpcHandlingSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -2,17 +2,26 @@ package grpcserver
 
 import (
 	"auth/config"
+	"auth/internal/service"
 	"context"
 	"fmt"
 	"log/slog"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 )
 
-func GetOptions(cfg config.GrpcServer, log *slog.Logger) []grpc.ServerOption {
-	return []grpc.ServerOption{
-		grpc.UnaryInterceptor(unaryInterceptor(log)), // Добавляем interceptor
+func GetOptions(cfg config.GrpcServer, tokenServ *service.TokenService, log *slog.Logger) ([]grpc.ServerOption, error) {
+	const op = "grpcserver.GetOptions"
+
+	opts := []grpc.ServerOption{
+		// Id запроса нужен всем следующим перехватчикам, метрики снимаются и с вызовов, завершенных ошибкой
+		grpc.ChainUnaryInterceptor(requestUnaryInterceptor(log), metricsUnaryInterceptor(), clientCertUnaryInterceptor(cfg.TLS), authUnaryInterceptor(tokenServ, log), unaryInterceptor(log)),
+		grpc.ChainStreamInterceptor(requestStreamInterceptor(log), metricsStreamInterceptor(), clientCertStreamInterceptor(cfg.TLS), authStreamInterceptor(tokenServ, log)),
+		// Спаны вызовов с контекстом трассировки из метаданных traceparent, кроме проверок здоровья
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle:     cfg.KeepaliveIdle,
 			MaxConnectionAge:      cfg.KeepaliveAge,
@@ -23,8 +32,18 @@ func GetOptions(cfg config.GrpcServer, log *slog.Logger) []grpc.ServerOption {
 		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
 		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
 	}
+
+	creds, err := serverCredentials(cfg.TLS, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	return opts, nil
 }
 
+// Logs requests and responses. Messages are passed to the logger as is, its handler removes passwords, tokens and emails
 func unaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -32,22 +51,22 @@ func unaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		log.Info("gRPC request",
+		log.InfoContext(ctx, "gRPC request",
 			"method", info.FullMethod,
-			"request", fmt.Sprintf("%+v", req),
+			"request", req,
 		)
 
 		resp, err := handler(ctx, req)
 
 		if err != nil {
-			log.Error("gRPC error",
+			log.ErrorContext(ctx, "gRPC error",
 				"method", info.FullMethod,
 				"error", err.Error(),
 			)
 		} else {
-			log.Info("gRPC response",
+			log.InfoContext(ctx, "gRPC response",
 				"method", info.FullMethod,
-				"response", fmt.Sprintf("%+v", resp),
+				"response", resp,
 			)
 		}
 
@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"auth/pkg/logger"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata key of the request id, same as X-Request-ID of the HTTP API
+const requestIDKey = "x-request-id"
+
+// Gives the call an id from x-request-id metadata or a new one, returns it in the header metadata
+// and writes one access log line after the call
+func requestUnaryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		ctx, id := withRequestID(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func requestStreamInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		ctx, id := withRequestID(stream.Context())
+		stream.SetHeader(metadata.Pairs(requestIDKey, id))
+
+		err := handler(srv, &requestStream{ServerStream: stream, ctx: ctx})
+		logCall(ctx, log, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// Stream with the context of the request
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+func withRequestID(ctx context.Context) (context.Context, string) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = logger.RequestIDOrNew(id)
+	return logger.WithRequestID(ctx, id), id
+}
+
+func logCall(ctx context.Context, log *slog.Logger, method string, start time.Time, err error) {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	// Проверки здоровья приходят каждые несколько секунд и не нужны в журнале
+	level := slog.LevelInfo
+	if strings.HasPrefix(method, "/"+healthv1.Health_ServiceDesc.ServiceName+"/") {
+		level = slog.LevelDebug
+	}
+	log.Log(ctx, level, "gRPC call",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("ip", ip),
+		slog.String("principal", logger.Principal(ctx)),
+	)
+}
@@ -8,7 +8,9 @@ import (
 	"auth/pkg/utils"
 	"context"
 	"log/slog"
+	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -17,89 +19,320 @@ import (
 type AdminHandler struct {
 	authServ  *service.AuthService
 	adminServ *service.AdminService
+	eventServ *service.EventService
 	log       *slog.Logger
 
 	authv1.UnimplementedAdminServiceServer
 }
 
-func NewAdminHandler(authServ *service.AuthService, adminServ *service.AdminService, log *slog.Logger) *AdminHandler {
+func NewAdminHandler(authServ *service.AuthService, adminServ *service.AdminService, eventServ *service.EventService, log *slog.Logger) *AdminHandler {
 	return &AdminHandler{
 		authServ:  authServ,
 		adminServ: adminServ,
+		eventServ: eventServ,
 		log:       log,
 	}
 }
 
 func (h *AdminHandler) GetUser(ctx context.Context, req *authv1.GetUserRequest) (*authv1.GetUserResponse, error) {
-	adminToken := req.GetAdminToken()
+	adminToken := accessToken(ctx)
 	userID := req.GetUserId()
 
 	if userID == 0 {
 		return nil, status.Error(codes.InvalidArgument, "user ID is empty")
 	}
 
-	user, err := h.adminServ.GetUser(int(userID), adminToken)
+	user, err := h.adminServ.GetUser(ctx, int(userID), adminToken)
 	if err != nil {
-		h.log.Error("Failed to get user", "error", err)
+		h.log.ErrorContext(ctx, "Failed to get user", "error", err)
 		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to get user data: %v", err)
 	}
 
-	h.log.Info("User data fetch finished")
+	h.log.InfoContext(ctx, "User data fetch finished")
 	return &authv1.GetUserResponse{
-		User: &authv1.User{
-			Id:        userID,
-			Name:      user.Name,
-			Email:     user.Email,
-			IsAdmin:   user.IsAdmin,
-			CreatedAt: timestamppb.New(user.Created_At),
-			UpdatedAt: timestamppb.New(user.Updated_At),
-			Role:      user.Role,
-		},
+		User: toUser(user),
 	}, nil
 }
 
+func (h *AdminHandler) CreateUser(ctx context.Context, req *authv1.CreateUserRequest) (*authv1.CreateUserResponse, error) {
+	role := req.GetRole()
+	if role == "" {
+		role = models.UserRole
+	}
+
+	// Валидируем запрос, пустой пароль будет сгенерирован
+	if err := validate.NewUser(req.GetName(), req.GetEmail(), req.GetPassword(), role); err != nil {
+		h.log.ErrorContext(ctx, "Credentials are invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, tempPassword, err := h.adminServ.CreateUser(ctx, models.User{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Role:  role,
+	}, req.GetPassword(), accessToken(ctx), requestMeta(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to create user", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to create user: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "User created", "ID", user.ID)
+	return &authv1.CreateUserResponse{
+		User:         toUser(user),
+		TempPassword: tempPassword,
+	}, nil
+}
+
+func (h *AdminHandler) ListUsers(ctx context.Context, req *authv1.ListUsersRequest) (*authv1.ListUsersResponse, error) {
+	filter := models.UserFilter{
+		Role:   req.GetRole(),
+		Query:  req.GetQuery(),
+		Status: req.GetStatus(),
+		SortBy: req.GetSortBy(),
+		Desc:   req.GetDesc(),
+		Cursor: req.GetCursor(),
+		Limit:  int(req.GetLimit()),
+	}
+	if req.GetCreatedFrom() != nil {
+		filter.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.GetCreatedTo() != nil {
+		filter.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+
+	// Валидируем запрос
+	if err := validate.UserFilter(filter); err != nil {
+		h.log.ErrorContext(ctx, "Filter is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "filter is invalid: %v", err)
+	}
+
+	page, err := h.adminServ.ListUsers(ctx, filter, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list users", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list users: %v", err)
+	}
+
+	resp := &authv1.ListUsersResponse{
+		NextCursor: page.NextCursor,
+		Total:      int64(page.Total),
+	}
+	for _, user := range page.Users {
+		resp.Users = append(resp.Users, toUser(user))
+	}
+
+	h.log.InfoContext(ctx, "Users list fetch finished", "count", len(page.Users), "total", page.Total)
+	return resp, nil
+}
+
 func (h *AdminHandler) UpdateUser(ctx context.Context, req *authv1.UpdateRequest) (*authv1.UpdateResponse, error) {
-	adminToken := req.GetAdminToken()
+	adminToken := accessToken(ctx)
 	userID := int(req.GetUserId())
 	role := req.GetRole()
 	name := req.GetName()
 
 	// Валидируем запрос
 	if err := validate.UserReq(userID, name, role); err != nil {
-		h.log.Error("Update request is invalid", "error", err)
+		h.log.ErrorContext(ctx, "Update request is invalid", "error", err)
 		return nil, status.Errorf(codes.InvalidArgument, "update request is invalid: %v", err)
 	}
 
-	if err := h.adminServ.UpdateUser(models.User{
+	if err := h.adminServ.UpdateUser(ctx, models.User{
 		ID:   userID,
 		Name: name,
 		Role: role,
-	}, adminToken); err != nil {
-		h.log.Error("Failed to update user", "error", err)
+	}, adminToken, requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to update user", "error", err)
 		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to update user data: %v", err)
 	}
 
-	h.log.Info("User updated succesfully", "ID", userID)
+	h.log.InfoContext(ctx, "User updated succesfully", "ID", userID)
 	return &authv1.UpdateResponse{
 		Message: "User updated succesfully",
 	}, nil
 }
 
+func (h *AdminHandler) ExportUsers(req *authv1.ExportUsersRequest, stream grpc.ServerStreamingServer[authv1.User]) error {
+	filter := models.UserFilter{
+		Role:   req.GetRole(),
+		Query:  req.GetQuery(),
+		Status: req.GetStatus(),
+	}
+	if req.GetCreatedFrom() != nil {
+		filter.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.GetCreatedTo() != nil {
+		filter.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+
+	// Валидируем запрос
+	if err := validate.UserFilter(filter); err != nil {
+		h.log.ErrorContext(stream.Context(), "Filter is invalid", "error", err)
+		return status.Errorf(codes.InvalidArgument, "filter is invalid: %v", err)
+	}
+
+	var count int
+	if err := h.adminServ.ExportUsers(stream.Context(), filter, accessToken(stream.Context()), func(user models.User) error {
+		count++
+		return stream.Send(toUser(user))
+	}); err != nil {
+		h.log.ErrorContext(stream.Context(), "Failed to export users", "error", err, "exported", count)
+		return status.Errorf(utils.GetGRPCStatus(err), "failed to export users: %v", err)
+	}
+
+	h.log.InfoContext(stream.Context(), "Users export finished", "exported", count)
+	return nil
+}
+
+func (h *AdminHandler) WatchUserEvents(req *authv1.WatchUserEventsRequest, stream grpc.ServerStreamingServer[authv1.UserEvent]) error {
+	// Валидируем запрос
+	if req.GetCursor() < 0 {
+		return status.Error(codes.InvalidArgument, "cursor must not be negative")
+	}
+	if err := validate.EventTypes(req.GetEventTypes()); err != nil {
+		h.log.ErrorContext(stream.Context(), "Event types are invalid", "error", err)
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var count int
+	if err := h.eventServ.WatchUserEvents(stream.Context(), req.GetCursor(), req.GetEventTypes(), accessToken(stream.Context()), func(event models.Event) error {
+		count++
+		return stream.Send(&authv1.UserEvent{
+			Id:        event.ID,
+			TenantId:  event.TenantID,
+			Type:      event.Type,
+			Data:      string(event.Data),
+			CreatedAt: timestamppb.New(event.Created_At),
+		})
+	}); err != nil {
+		h.log.ErrorContext(stream.Context(), "Failed to watch user events", "error", err, "sent", count)
+		return status.Errorf(utils.GetGRPCStatus(err), "failed to watch user events: %v", err)
+	}
+
+	h.log.InfoContext(stream.Context(), "User events watch finished", "sent", count)
+	return nil
+}
+
 func (h *AdminHandler) DeleteUser(ctx context.Context, req *authv1.DeleteRequest) (*authv1.DeleteResponse, error) {
-	adminToken := req.GetAdminToken()
+	adminToken := accessToken(ctx)
 	userID := req.GetUserId()
 
 	if userID == 0 {
 		return nil, status.Error(codes.InvalidArgument, "user ID is empty")
 	}
 
-	if err := h.adminServ.DeleteUser(int(userID), adminToken); err != nil {
-		h.log.Error("Failed to delete user", "error", err)
+	if err := h.adminServ.DeleteUser(ctx, int(userID), adminToken, requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to delete user", "error", err)
 		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to delete user data: %v", err)
 	}
 
-	h.log.Info("User deleted succesfully", "ID", userID)
+	h.log.InfoContext(ctx, "User deleted succesfully", "ID", userID)
 	return &authv1.DeleteResponse{
 		Message: "User deleted succesfully",
 	}, nil
 }
+
+func (h *AdminHandler) DisableUser(ctx context.Context, req *authv1.UserStatusRequest) (*authv1.UserStatusResponse, error) {
+	return h.updateStatus(ctx, req, h.adminServ.DisableUser, "User disabled succesfully")
+}
+
+func (h *AdminHandler) EnableUser(ctx context.Context, req *authv1.UserStatusRequest) (*authv1.UserStatusResponse, error) {
+	return h.updateStatus(ctx, req, h.adminServ.EnableUser, "User enabled succesfully")
+}
+
+func (h *AdminHandler) RestoreUser(ctx context.Context, req *authv1.UserStatusRequest) (*authv1.UserStatusResponse, error) {
+	return h.updateStatus(ctx, req, h.adminServ.RestoreUser, "User restored succesfully")
+}
+
+func (h *AdminHandler) updateStatus(ctx context.Context, req *authv1.UserStatusRequest, update func(ctx context.Context, userID int, access string, meta models.RequestMeta) error, message string) (*authv1.UserStatusResponse, error) {
+	userID := req.GetUserId()
+	if userID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID is empty")
+	}
+
+	if err := update(ctx, int(userID), accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to update user status", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to update user status: %v", err)
+	}
+
+	h.log.InfoContext(ctx, message, "ID", userID)
+	return &authv1.UserStatusResponse{
+		Message: message,
+	}, nil
+}
+
+func (h *AdminHandler) GetTenant(ctx context.Context, req *authv1.GetTenantRequest) (*authv1.GetTenantResponse, error) {
+	tenant, err := h.adminServ.GetTenant(ctx, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to get tenant data: %v", err)
+	}
+
+	resp := &authv1.Tenant{
+		Id:                tenant.ID,
+		Name:              tenant.Name,
+		AccessTtlSeconds:  int64(tenant.AccessTTL / time.Second),
+		RefreshTtlSeconds: int64(tenant.RefreshTTL / time.Second),
+	}
+	if tenant.Password != nil {
+		resp.PasswordPolicy = &authv1.PasswordPolicy{
+			MinLength:     int32(tenant.Password.MinLength),
+			RequireDigit:  tenant.Password.RequireDigit,
+			RequireUpper:  tenant.Password.RequireUpper,
+			RequireSymbol: tenant.Password.RequireSymbol,
+		}
+	}
+
+	h.log.InfoContext(ctx, "Tenant data fetch finished", "tenant", tenant.ID)
+	return &authv1.GetTenantResponse{Tenant: resp}, nil
+}
+
+func (h *AdminHandler) UpdateTenant(ctx context.Context, req *authv1.UpdateTenantRequest) (*authv1.UpdateTenantResponse, error) {
+	tenant := models.Tenant{
+		AccessTTL:  time.Duration(req.GetAccessTtlSeconds()) * time.Second,
+		RefreshTTL: time.Duration(req.GetRefreshTtlSeconds()) * time.Second,
+	}
+	if policy := req.GetPasswordPolicy(); policy != nil {
+		tenant.Password = &models.PasswordPolicy{
+			MinLength:     int(policy.GetMinLength()),
+			RequireDigit:  policy.GetRequireDigit(),
+			RequireUpper:  policy.GetRequireUpper(),
+			RequireSymbol: policy.GetRequireSymbol(),
+		}
+	}
+
+	// Валидируем запрос
+	if err := validate.TenantSettings(tenant.AccessTTL, tenant.RefreshTTL, tenant.Password); err != nil {
+		h.log.ErrorContext(ctx, "Tenant settings are invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "tenant settings are invalid: %v", err)
+	}
+
+	if err := h.adminServ.UpdateTenant(ctx, tenant, accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to update tenant", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to update tenant settings: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Tenant settings updated succesfully")
+	return &authv1.UpdateTenantResponse{
+		Message: "Tenant settings updated succesfully",
+	}, nil
+}
+
+func toUser(user models.User) *authv1.User {
+	return &authv1.User{
+		Id:        int64(user.ID),
+		TenantId:  user.TenantID,
+		Name:      user.Name,
+		Email:     user.Email,
+		IsAdmin:   user.IsAdmin,
+		CreatedAt: timestamppb.New(user.Created_At),
+		UpdatedAt: timestamppb.New(user.Updated_At),
+		Role:      user.Role,
+		Status:    user.Status,
+
+		MustChangePassword: user.MustChangePassword,
+		AvatarUrl:          user.AvatarURL,
+		Locale:             user.Locale,
+		Timezone:           user.Timezone,
+		MetadataJson:       string(user.Metadata),
+	}
+}
@@ -0,0 +1,99 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AuditHandler struct {
+	auditServ *service.AuditService
+	log       *slog.Logger
+
+	authv1.UnimplementedAuditServiceServer
+}
+
+func NewAuditHandler(auditServ *service.AuditService, log *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditServ: auditServ,
+		log:       log,
+	}
+}
+
+func (h *AuditHandler) ListAuditLog(ctx context.Context, req *authv1.ListAuditLogRequest) (*authv1.ListAuditLogResponse, error) {
+	filter := models.AuditFilter{
+		UserID: int(req.GetUserId()),
+		Action: req.GetAction(),
+		Cursor: req.GetCursor(),
+		Limit:  int(req.GetLimit()),
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+
+	// Валидируем запрос
+	if err := validate.AuditFilter(filter); err != nil {
+		h.log.ErrorContext(ctx, "Filter is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "filter is invalid: %v", err)
+	}
+
+	page, err := h.auditServ.ListAudit(ctx, filter, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list audit log", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list audit log: %v", err)
+	}
+
+	resp := &authv1.ListAuditLogResponse{
+		NextCursor: page.NextCursor,
+	}
+	for _, entry := range page.Entries {
+		resp.Entries = append(resp.Entries, toAuditEntry(entry))
+	}
+
+	h.log.InfoContext(ctx, "Audit log fetch finished", "count", len(page.Entries))
+	return resp, nil
+}
+
+func (h *AuditHandler) VerifyAuditLog(ctx context.Context, req *authv1.VerifyAuditLogRequest) (*authv1.VerifyAuditLogResponse, error) {
+	result, err := h.auditServ.VerifyAudit(ctx, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to verify audit log", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to verify audit log: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Audit log verification finished", "checked", result.Checked, "valid", result.Valid)
+	return &authv1.VerifyAuditLogResponse{
+		Checked:  int64(result.Checked),
+		Valid:    result.Valid,
+		BrokenId: result.BrokenID,
+	}, nil
+}
+
+func toAuditEntry(entry models.AuditEntry) *authv1.AuditEntry {
+	return &authv1.AuditEntry{
+		Id:        entry.ID,
+		TenantId:  entry.TenantID,
+		ActorId:   int64(entry.ActorID),
+		TargetId:  int64(entry.TargetID),
+		Action:    entry.Action,
+		Outcome:   entry.Outcome,
+		Details:   entry.Details,
+		Ip:        entry.IP,
+		UserAgent: entry.UserAgent,
+		RequestId: entry.RequestID,
+		CreatedAt: timestamppb.New(entry.Created_At),
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	}
+}
@@ -33,20 +33,26 @@ func NewAuthHandler(authServ *service.AuthService, tokenServ *service.TokenServi
 func (h *AuthHandler) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
 	email := req.GetEmail()
 	password := req.GetPassword()
+	tenantID := req.GetTenantId()
+
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(ctx, "Tenant id is invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	// Валидация реквизитов
 	if err := validate.Credentials("valid Name", email, password, models.UserRole); err != nil {
-		h.log.Error("Credentials are invalid", "email", email, "password", password)
+		h.log.ErrorContext(ctx, "Credentials are invalid", "email", email, "error", err)
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	tokens, err := h.authServ.Login(email, password)
+	tokens, err := h.authServ.Login(ctx, tenantID, email, password, requestMeta(ctx))
 	if err != nil {
-		h.log.Error("Failed to auth user", "error", err)
+		h.log.ErrorContext(ctx, "Failed to auth user", "error", err)
 		return nil, status.Error(utils.GetGRPCStatus(err), err.Error())
 	}
 
-	h.log.Info("User login finished")
+	h.log.InfoContext(ctx, "User login finished")
 	return &authv1.LoginResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
@@ -56,30 +62,61 @@ func (h *AuthHandler) Login(ctx context.Context, req *authv1.LoginRequest) (*aut
 func (h *AuthHandler) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*authv1.RefreshResponse, error) {
 	refresh := req.GetRefreshToken()
 
-	tokens, err := h.tokenServ.Refresh(refresh)
+	tokens, err := h.authServ.Refresh(ctx, refresh, requestMeta(ctx))
 	if err != nil {
-		h.log.Error("Failed to refresh token", "error", err)
+		h.log.ErrorContext(ctx, "Failed to refresh token", "error", err)
 		return nil, status.Error(utils.GetGRPCStatus(err), err.Error())
 	}
 
-	h.log.Info("Token has been refreshed")
+	h.log.InfoContext(ctx, "Token has been refreshed")
 	return &authv1.RefreshResponse{
 		NewAccessToken:  tokens.AccessToken,
 		NewRefreshToken: tokens.RefreshToken,
 	}, nil
 }
 
+func (h *AuthHandler) ChangePassword(ctx context.Context, req *authv1.ChangePasswordRequest) (*authv1.ChangePasswordResponse, error) {
+	tenantID := req.GetTenantId()
+
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(ctx, "Tenant id is invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// Валидация реквизитов
+	if err := validate.Credentials("valid Name", req.GetEmail(), req.GetNewPassword(), models.UserRole); err != nil {
+		h.log.ErrorContext(ctx, "Credentials are invalid")
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := h.authServ.ChangePassword(ctx, tenantID, req.GetEmail(), req.GetOldPassword(), req.GetNewPassword()); err != nil {
+		h.log.ErrorContext(ctx, "Failed to change password", "error", err)
+		return nil, status.Error(utils.GetGRPCStatus(err), err.Error())
+	}
+
+	h.log.InfoContext(ctx, "Password changed")
+	return &authv1.ChangePasswordResponse{
+		Message: "Password changed succesfully",
+	}, nil
+}
+
 func (h *AuthHandler) Register(ctx context.Context, req *authv1.RegisterRequest) (*authv1.RegisterResponse, error) {
 	name, email, role, password := req.GetName(), req.GetEmail(), req.GetRole(), req.GetPassword()
+	tenantID := req.GetTenantId()
+
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(ctx, "Tenant id is invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	if err := validate.Credentials(name, email, password, role); err != nil {
-		h.log.Error("Credentials are invalid")
+		h.log.ErrorContext(ctx, "Credentials are invalid")
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	userID, err := h.authServ.Register(name, email, password, role)
+	userID, err := h.authServ.Register(ctx, tenantID, name, email, password, role, requestMeta(ctx))
 	if err != nil {
-		h.log.Error("Failed to register user", "error", err)
+		h.log.ErrorContext(ctx, "Failed to register user", "error", err)
 		return nil, status.Error(utils.GetGRPCStatus(err), err.Error())
 	}
 
@@ -89,20 +126,21 @@ func (h *AuthHandler) Register(ctx context.Context, req *authv1.RegisterRequest)
 }
 
 func (h *AuthHandler) WhoAmI(ctx context.Context, req *authv1.WhoAmIRequest) (*authv1.WhoAmIResponse, error) {
-	token := req.GetToken()
+	token := accessToken(ctx)
 
 	// Вызов основной логики
-	existUser, err := h.authServ.RoleCheck(token)
+	existUser, err := h.authServ.RoleCheck(ctx, token)
 	if err != nil {
-		h.log.Error("Failed to check user role", "error", err)
+		h.log.ErrorContext(ctx, "Failed to check user role", "error", err)
 		return nil, status.Error(utils.GetGRPCStatus(err), err.Error())
 	}
 
 	// Возвращаем ответ
-	h.log.Info("User role check finished", "ID", existUser.ID, "is_admin", existUser.IsAdmin)
+	h.log.InfoContext(ctx, "User role check finished", "ID", existUser.ID, "is_admin", existUser.IsAdmin)
 	return &authv1.WhoAmIResponse{
 		User: &authv1.User{
 			Id:        int64(existUser.ID),
+			TenantId:  existUser.TenantID,
 			Name:      existUser.Name,
 			Email:     existUser.Email,
 			IsAdmin:   existUser.IsAdmin,
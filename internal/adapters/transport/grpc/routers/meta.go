@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+	"context"
+	"net"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Returns client info of the call for the audit log and login history
+func requestMeta(ctx context.Context) models.RequestMeta {
+	meta := models.RequestMeta{RequestID: logger.RequestID(ctx)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		meta.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(meta.IP); err == nil {
+			meta.IP = host
+		}
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			meta.UserAgent = values[0]
+		}
+		if values := md.Get("x-device-id"); len(values) > 0 {
+			meta.DeviceID = values[0]
+		}
+	}
+	return meta
+}
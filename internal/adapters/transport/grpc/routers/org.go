@@ -0,0 +1,191 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type OrgHandler struct {
+	orgServ *service.OrgService
+	log     *slog.Logger
+
+	authv1.UnimplementedOrgServiceServer
+}
+
+func NewOrgHandler(orgServ *service.OrgService, log *slog.Logger) *OrgHandler {
+	return &OrgHandler{
+		orgServ: orgServ,
+		log:     log,
+	}
+}
+
+func (h *OrgHandler) CreateOrganization(ctx context.Context, req *authv1.CreateOrganizationRequest) (*authv1.CreateOrganizationResponse, error) {
+	if err := validate.OrgName(req.GetName()); err != nil {
+		h.log.ErrorContext(ctx, "Organization name is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "organization name is invalid: %v", err)
+	}
+
+	org, err := h.orgServ.CreateOrg(ctx, req.GetName(), accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to create organization", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to create organization: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Organization created", "ID", org.ID)
+	return &authv1.CreateOrganizationResponse{
+		Organization: &authv1.Organization{
+			Id:        int64(org.ID),
+			TenantId:  org.TenantID,
+			Name:      org.Name,
+			OwnerId:   int64(org.OwnerID),
+			CreatedAt: timestamppb.New(org.Created_At),
+		},
+	}, nil
+}
+
+func (h *OrgHandler) InviteMember(ctx context.Context, req *authv1.InviteMemberRequest) (*authv1.InviteMemberResponse, error) {
+	// Валидируем запрос
+	if err := validate.Invitation(req.GetEmail(), req.GetRole()); err != nil {
+		h.log.ErrorContext(ctx, "Invitation is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invitation is invalid: %v", err)
+	}
+
+	inv, err := h.orgServ.Invite(ctx, int(req.GetOrgId()), req.GetEmail(), req.GetRole(), accessToken(ctx), requestMeta(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to invite member", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to invite member: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Invitation sent", "ID", inv.ID)
+	return &authv1.InviteMemberResponse{
+		Invitation: toInvitation(inv),
+	}, nil
+}
+
+func (h *OrgHandler) AcceptInvitation(ctx context.Context, req *authv1.AcceptInvitationRequest) (*authv1.AcceptInvitationResponse, error) {
+	if req.GetInvitationToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "invitation token is empty")
+	}
+
+	if accessToken(ctx) == "" && req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required without access token")
+	}
+
+	member, err := h.orgServ.AcceptInvitation(ctx, req.GetInvitationToken(), accessToken(ctx), req.GetName(), req.GetPassword(), requestMeta(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to accept invitation", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to accept invitation: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Invitation accepted", "org", member.OrgID, "user", member.UserID)
+	return &authv1.AcceptInvitationResponse{
+		Member: toMember(member),
+	}, nil
+}
+
+func (h *OrgHandler) RevokeInvitation(ctx context.Context, req *authv1.RevokeInvitationRequest) (*authv1.RevokeInvitationResponse, error) {
+	if req.GetInvitationId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invitation ID is empty")
+	}
+
+	if err := h.orgServ.RevokeInvitation(ctx, int(req.GetOrgId()), int(req.GetInvitationId()), accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to revoke invitation", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to revoke invitation: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Invitation revoked", "ID", req.GetInvitationId())
+	return &authv1.RevokeInvitationResponse{
+		Message: "Invitation revoked succesfully",
+	}, nil
+}
+
+func (h *OrgHandler) ListInvitations(ctx context.Context, req *authv1.ListInvitationsRequest) (*authv1.ListInvitationsResponse, error) {
+	invitations, err := h.orgServ.ListInvitations(ctx, int(req.GetOrgId()), accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list invitations", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list invitations: %v", err)
+	}
+
+	resp := &authv1.ListInvitationsResponse{}
+	for _, inv := range invitations {
+		resp.Invitations = append(resp.Invitations, toInvitation(inv))
+	}
+	return resp, nil
+}
+
+func (h *OrgHandler) ListMembers(ctx context.Context, req *authv1.ListMembersRequest) (*authv1.ListMembersResponse, error) {
+	members, err := h.orgServ.ListMembers(ctx, int(req.GetOrgId()), accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list members", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list members: %v", err)
+	}
+
+	resp := &authv1.ListMembersResponse{}
+	for _, member := range members {
+		resp.Members = append(resp.Members, toMember(member))
+	}
+	return resp, nil
+}
+
+func (h *OrgHandler) UpdateMemberRole(ctx context.Context, req *authv1.UpdateMemberRoleRequest) (*authv1.UpdateMemberRoleResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user ID is empty")
+	}
+
+	if err := validate.OrgRole(req.GetRole()); err != nil {
+		h.log.ErrorContext(ctx, "Member role is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "member role is invalid: %v", err)
+	}
+
+	if err := h.orgServ.UpdateMemberRole(ctx, int(req.GetOrgId()), int(req.GetUserId()), req.GetRole(), accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to update member role", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to update member role: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Member role updated", "org", req.GetOrgId(), "user", req.GetUserId())
+	return &authv1.UpdateMemberRoleResponse{
+		Message: "Member role updated succesfully",
+	}, nil
+}
+
+func toMember(member models.Membership) *authv1.Member {
+	return &authv1.Member{
+		OrgId:    int64(member.OrgID),
+		UserId:   int64(member.UserID),
+		Name:     member.Name,
+		Email:    member.Email,
+		Role:     member.Role,
+		JoinedAt: timestamppb.New(member.Joined_At),
+	}
+}
+
+func toInvitation(inv models.Invitation) *authv1.Invitation {
+	return &authv1.Invitation{
+		Id:         int64(inv.ID),
+		OrgId:      int64(inv.OrgID),
+		Email:      inv.Email,
+		Role:       inv.Role,
+		InvitedBy:  int64(inv.InvitedBy),
+		CreatedAt:  timestamppb.New(inv.Created_At),
+		ExpiresAt:  timestamppb.New(inv.Expires_At),
+		AcceptedAt: optionalTimestamp(inv.Accepted_At),
+		RevokedAt:  optionalTimestamp(inv.Revoked_At),
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
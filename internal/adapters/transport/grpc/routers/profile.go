@@ -0,0 +1,132 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ProfileHandler struct {
+	profileServ *service.ProfileService
+	loginServ   *service.LoginService
+	log         *slog.Logger
+
+	authv1.UnimplementedProfileServiceServer
+}
+
+func NewProfileHandler(profileServ *service.ProfileService, loginServ *service.LoginService, log *slog.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		profileServ: profileServ,
+		loginServ:   loginServ,
+		log:         log,
+	}
+}
+
+func (h *ProfileHandler) GetProfile(ctx context.Context, req *authv1.GetProfileRequest) (*authv1.ProfileResponse, error) {
+	user, err := h.profileServ.GetProfile(ctx, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to get profile", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to get profile: %v", err)
+	}
+
+	return &authv1.ProfileResponse{
+		User: toUser(user),
+	}, nil
+}
+
+func (h *ProfileHandler) UpdateProfile(ctx context.Context, req *authv1.UpdateProfileRequest) (*authv1.ProfileResponse, error) {
+	// Собираем изменения по маске полей
+	var update models.ProfileUpdate
+	for _, field := range req.GetUpdateMask() {
+		switch field {
+		case "name":
+			update.Name = &req.Name
+		case "avatar_url":
+			update.AvatarURL = &req.AvatarUrl
+		case "locale":
+			update.Locale = &req.Locale
+		case "timezone":
+			update.Timezone = &req.Timezone
+		case "metadata_json":
+			update.Metadata = json.RawMessage(req.GetMetadataJson())
+		case "email":
+			update.Email = &req.Email
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown field in update mask: %s", field)
+		}
+	}
+
+	// Валидируем запрос
+	if err := validate.Profile(update); err != nil {
+		h.log.ErrorContext(ctx, "Profile update is invalid", "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "profile update is invalid: %v", err)
+	}
+
+	user, pendingEmail, err := h.profileServ.UpdateProfile(ctx, update, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to update profile", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to update profile: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Profile updated succesfully", "ID", user.ID)
+	return &authv1.ProfileResponse{
+		User:         toUser(user),
+		PendingEmail: pendingEmail,
+	}, nil
+}
+
+func (h *ProfileHandler) ConfirmEmailChange(ctx context.Context, req *authv1.ConfirmEmailChangeRequest) (*authv1.ProfileResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "confirmation token is empty")
+	}
+
+	user, err := h.profileServ.ConfirmEmail(ctx, req.GetToken())
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to confirm email", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to confirm email: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Email changed succesfully", "ID", user.ID)
+	return &authv1.ProfileResponse{
+		User: toUser(user),
+	}, nil
+}
+
+func (h *ProfileHandler) ListLogins(ctx context.Context, req *authv1.ListLoginsRequest) (*authv1.ListLoginsResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	page, err := h.loginServ.ListLogins(ctx, req.GetCursor(), int(req.GetLimit()), accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list logins", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list logins: %v", err)
+	}
+
+	resp := &authv1.ListLoginsResponse{
+		NextCursor: page.NextCursor,
+	}
+	for _, login := range page.Logins {
+		resp.Logins = append(resp.Logins, &authv1.LoginRecord{
+			Id:        login.ID,
+			Ip:        login.IP,
+			UserAgent: login.UserAgent,
+			Device:    login.Fingerprint,
+			Success:   login.Success,
+			Reason:    login.Reason,
+			CreatedAt: timestamppb.New(login.Created_At),
+		})
+	}
+
+	h.log.InfoContext(ctx, "Login history fetch finished", "count", len(page.Logins))
+	return resp, nil
+}
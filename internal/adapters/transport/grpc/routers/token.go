@@ -0,0 +1,17 @@
+package routers
+
+import "context"
+
+// Access token of the call that passed the auth interceptor
+type accessTokenKey struct{}
+
+// Returns context of the call authenticated by the token
+func WithAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, token)
+}
+
+// Returns access token authenticated by the interceptor, empty for anonymous calls
+func accessToken(ctx context.Context) string {
+	token, _ := ctx.Value(accessTokenKey{}).(string)
+	return token
+}
@@ -0,0 +1,144 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type WebhookHandler struct {
+	webhookServ *service.WebhookService
+	log         *slog.Logger
+
+	authv1.UnimplementedWebhookServiceServer
+}
+
+func NewWebhookHandler(webhookServ *service.WebhookService, log *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookServ: webhookServ,
+		log:         log,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(ctx context.Context, req *authv1.CreateWebhookRequest) (*authv1.CreateWebhookResponse, error) {
+	// Валидируем запрос
+	if err := validate.Webhook(req.GetUrl(), req.GetEvents()); err != nil {
+		h.log.ErrorContext(ctx, "Webhook is invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	webhook, err := h.webhookServ.CreateWebhook(ctx, models.Webhook{
+		URL:    req.GetUrl(),
+		Events: req.GetEvents(),
+	}, accessToken(ctx), requestMeta(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to create webhook", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to create webhook: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Webhook created", "ID", webhook.ID)
+	return &authv1.CreateWebhookResponse{
+		Webhook: toWebhook(webhook),
+	}, nil
+}
+
+func (h *WebhookHandler) ListWebhooks(ctx context.Context, req *authv1.ListWebhooksRequest) (*authv1.ListWebhooksResponse, error) {
+	webhooks, err := h.webhookServ.ListWebhooks(ctx, accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list webhooks", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list webhooks: %v", err)
+	}
+
+	resp := &authv1.ListWebhooksResponse{}
+	for _, webhook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, toWebhook(webhook))
+	}
+	return resp, nil
+}
+
+func (h *WebhookHandler) DeleteWebhook(ctx context.Context, req *authv1.DeleteWebhookRequest) (*authv1.DeleteWebhookResponse, error) {
+	webhookID := req.GetWebhookId()
+	if webhookID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "webhook ID is empty")
+	}
+
+	if err := h.webhookServ.DeleteWebhook(ctx, int(webhookID), accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to delete webhook", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to delete webhook: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Webhook deleted succesfully", "ID", webhookID)
+	return &authv1.DeleteWebhookResponse{
+		Message: "Webhook deleted succesfully",
+	}, nil
+}
+
+func (h *WebhookHandler) ListDeliveries(ctx context.Context, req *authv1.ListDeliveriesRequest) (*authv1.ListDeliveriesResponse, error) {
+	// Валидируем запрос
+	if err := validate.DeliveryStatus(req.GetStatus()); err != nil {
+		h.log.ErrorContext(ctx, "Delivery status is invalid", "error", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	deliveries, err := h.webhookServ.ListDeliveries(ctx, req.GetStatus(), int(req.GetLimit()), accessToken(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx, "Failed to list deliveries", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to list deliveries: %v", err)
+	}
+
+	resp := &authv1.ListDeliveriesResponse{}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, &authv1.WebhookDelivery{
+			Id:            d.ID,
+			WebhookId:     int64(d.WebhookID),
+			EventId:       d.EventID,
+			EventType:     d.EventType,
+			Status:        d.Status,
+			Attempts:      int32(d.Attempts),
+			LastError:     d.LastError,
+			NextAttemptAt: timestamppb.New(d.NextAttemptAt),
+			CreatedAt:     timestamppb.New(d.Created_At),
+			UpdatedAt:     timestamppb.New(d.Updated_At),
+		})
+	}
+	return resp, nil
+}
+
+func (h *WebhookHandler) RetryDelivery(ctx context.Context, req *authv1.RetryDeliveryRequest) (*authv1.RetryDeliveryResponse, error) {
+	deliveryID := req.GetDeliveryId()
+	if deliveryID == 0 {
+		return nil, status.Error(codes.InvalidArgument, "delivery ID is empty")
+	}
+
+	if err := h.webhookServ.RetryDelivery(ctx, deliveryID, accessToken(ctx), requestMeta(ctx)); err != nil {
+		h.log.ErrorContext(ctx, "Failed to retry delivery", "error", err)
+		return nil, status.Errorf(utils.GetGRPCStatus(err), "failed to retry delivery: %v", err)
+	}
+
+	h.log.InfoContext(ctx, "Delivery requeued succesfully", "ID", deliveryID)
+	return &authv1.RetryDeliveryResponse{
+		Message: "Delivery requeued succesfully",
+	}, nil
+}
+
+func toWebhook(webhook models.Webhook) *authv1.Webhook {
+	return &authv1.Webhook{
+		Id:        int64(webhook.ID),
+		TenantId:  webhook.TenantID,
+		Url:       webhook.URL,
+		Events:    webhook.Events,
+		Secret:    webhook.Secret,
+		CreatedAt: timestamppb.New(webhook.Created_At),
+	}
+}
@@ -5,33 +5,82 @@ import (
 	authv1 "auth/internal/adapters/transport/grpc/gen"
 	"auth/internal/adapters/transport/grpc/routers"
 	"auth/internal/service"
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthv1 "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type API struct {
-	server *grpc.Server
-	cfg    config.GrpcServer
+	server     *grpc.Server
+	health     *health.Server
+	healthServ *service.HealthService
+	stopHealth context.CancelFunc
+	cfg        config.GrpcServer
 
 	log *slog.Logger
 }
 
-func New(cfg config.GrpcServer, authServ *service.AuthService, adminServ *service.AdminService, tokenServ *service.TokenService, log *slog.Logger) *API {
-	grpcServer := grpc.NewServer(GetOptions(cfg, log)...)
+func New(cfg config.GrpcServer, authServ *service.AuthService, adminServ *service.AdminService, orgServ *service.OrgService, profileServ *service.ProfileService, loginServ *service.LoginService, auditServ *service.AuditService, webhookServ *service.WebhookService, eventServ *service.EventService, healthServ *service.HealthService, tokenServ *service.TokenService, log *slog.Logger) (*API, error) {
+	const op = "grpcserver.New"
 
-	adminHandler := routers.NewAdminHandler(authServ, adminServ, log)
+	opts, err := GetOptions(cfg, tokenServ, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	grpcServer := grpc.NewServer(opts...)
+
+	adminHandler := routers.NewAdminHandler(authServ, adminServ, eventServ, log)
 	authHandler := routers.NewAuthHandler(authServ, tokenServ, log)
+	orgHandler := routers.NewOrgHandler(orgServ, log)
+	profileHandler := routers.NewProfileHandler(profileServ, loginServ, log)
+	auditHandler := routers.NewAuditHandler(auditServ, log)
+	webhookHandler := routers.NewWebhookHandler(webhookServ, log)
 
 	authv1.RegisterAdminServiceServer(grpcServer, adminHandler)
 	authv1.RegisterAuthServiceServer(grpcServer, authHandler)
+	authv1.RegisterOrgServiceServer(grpcServer, orgHandler)
+	authv1.RegisterProfileServiceServer(grpcServer, profileHandler)
+	authv1.RegisterAuditServiceServer(grpcServer, auditHandler)
+	authv1.RegisterWebhookServiceServer(grpcServer, webhookHandler)
+
+	// Стандартный сервис проверки здоровья, статус до первой проверки NOT_SERVING
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthv1.HealthCheckResponse_NOT_SERVING)
+	healthv1.RegisterHealthServer(grpcServer, healthServer)
 
-	return &API{
-		server: grpcServer,
-		cfg:    cfg,
-		log:    log,
+	api := &API{
+		server:     grpcServer,
+		health:     healthServer,
+		healthServ: healthServ,
+		cfg:        cfg,
+		log:        log,
+	}
+
+	// Статусы обновляются по проверкам готовности и сразу при ее смене
+	ctx, cancel := context.WithCancel(context.Background())
+	api.stopHealth = cancel
+	go healthServ.Watch(ctx, cfg.HealthInterval, api.setServing)
+
+	return api, nil
+}
+
+// Sets grpc.health.v1 status of every registered service and of the server (empty name)
+func (a *API) setServing(err error) {
+	status := healthv1.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthv1.HealthCheckResponse_NOT_SERVING
+	}
+
+	a.health.SetServingStatus("", status)
+	for name := range a.server.GetServiceInfo() {
+		if name != healthv1.Health_ServiceDesc.ServiceName {
+			a.health.SetServingStatus(name, status)
+		}
 	}
 }
 
@@ -44,11 +93,28 @@ func (a *API) StartServer() error {
 		return err
 	}
 
-	a.log.Info("gRPC server is running", "port", a.cfg.Port)
+	a.log.Info("gRPC server is running", "port", a.cfg.Port, "tls", a.cfg.TLS.CertFile != "")
 	return a.server.Serve(listener)
 }
 
-func (a *API) Close() {
-	a.log.Info("Shutting down gRPC server...")
-	a.server.GracefulStop()
+// Stops accepting connections and waits for running calls. When ctx is done
+// remaining calls and streams are closed forcibly
+func (a *API) Shutdown(ctx context.Context) {
+	a.log.InfoContext(ctx, "Shutting down gRPC server...")
+
+	// Клиенты, следящие за здоровьем, переключаются на другие инстансы
+	a.stopHealth()
+	a.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		a.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.log.WarnContext(ctx, "Graceful stop timed out, closing open calls")
+		a.server.Stop()
+	}
 }
@@ -0,0 +1,104 @@
+package grpcserver
+
+import (
+	"auth/config"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/pkg/tlsconfig"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Services only internal callers with a client certificate can use when client CAs are configured
+var internalServices = []string{authv1.AdminService_ServiceDesc.ServiceName}
+
+// Returns TLS credentials of the server, nil when no certificate is configured
+func serverCredentials(cfg config.GrpcTLS, log *slog.Logger) (credentials.TransportCredentials, error) {
+	// Без CA сертификаты клиентов не запрашиваются, требование молча бы не выполнялось
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("required client certificates need client CAs")
+	}
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		if cfg.ClientCAFile != "" {
+			return nil, errors.New("client certificates require server certificate and key")
+		}
+		return nil, nil
+	}
+
+	clientAuth := tls.VerifyClientCertIfGiven
+	if cfg.RequireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	tlsConfig, err := tlsconfig.New(tlsconfig.Options{
+		CertFile:     cfg.CertFile,
+		KeyFile:      cfg.KeyFile,
+		MinVersion:   cfg.MinVersion,
+		CipherSuites: cfg.CipherSuites,
+		ClientCAFile: cfg.ClientCAFile,
+		ClientAuth:   clientAuth,
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// Rejects calls of internal services without verified client certificate. Disabled without client CAs
+func clientCertUnaryInterceptor(cfg config.GrpcTLS) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := checkClientCert(ctx, cfg, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func clientCertStreamInterceptor(cfg config.GrpcTLS) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		stream grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := checkClientCert(stream.Context(), cfg, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func checkClientCert(ctx context.Context, cfg config.GrpcTLS, method string) error {
+	if cfg.ClientCAFile == "" || !isInternal(method) {
+		return nil
+	}
+
+	// Цепочку проверяет TLS рукопожатие, здесь достаточно убедиться, что сертификат был предъявлен
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "client certificate is required")
+}
+
+func isInternal(method string) bool {
+	for _, service := range internalServices {
+		if strings.HasPrefix(method, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
+}
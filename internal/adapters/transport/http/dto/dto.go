@@ -1,9 +1,33 @@
 package dto
 
+import (
+	"auth/internal/domain/models"
+	"encoding/json"
+)
+
 // Data transfer objects
 type LoginReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email         string `json:"email"`
+	Password      string `json:"password"`
+	TokenDelivery string `json:"token_delivery"` // cookie | json, overrides X-Token-Delivery header
+}
+
+type RefreshReq struct {
+	RefreshToken  string `json:"refresh_token"`  // Empty - refresh_token cookie is used
+	TokenDelivery string `json:"token_delivery"` // cookie | json, overrides X-Token-Delivery header
+}
+
+// Token pair in the body, same as gRPC LoginResponse and RefreshResponse
+type TokenResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // Seconds until the access token expires
+	TokenType    string `json:"token_type"`
+}
+
+// CSRF token of the cookie session, sent in X-CSRF-Token header of unsafe requests
+type CSRFResp struct {
+	CSRFToken string `json:"csrf_token"`
 }
 
 type RegisterReq struct {
@@ -19,3 +43,90 @@ type UpdateUserReq struct {
 	Role  string `json:"role"`
 	Email string `json:"email"`
 }
+
+type TenantSettingsReq struct {
+	AccessTTL      string                 `json:"access_ttl"`  // Go duration, e.g. "15m". Empty resets to global value
+	RefreshTTL     string                 `json:"refresh_ttl"` // Go duration, e.g. "168h". Empty resets to global value
+	PasswordPolicy *models.PasswordPolicy `json:"password_policy"`
+}
+
+type TenantResp struct {
+	ID             string                 `json:"ID"`
+	Name           string                 `json:"name"`
+	AccessTTL      string                 `json:"access_ttl,omitempty"`
+	RefreshTTL     string                 `json:"refresh_ttl,omitempty"`
+	PasswordPolicy *models.PasswordPolicy `json:"password_policy,omitempty"`
+}
+
+type CreateOrgReq struct {
+	Name string `json:"name"`
+}
+
+type InviteReq struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // admin | member
+}
+
+// Name and password are used when invitee is not logged in:
+// password of the existing account or credentials for the new one
+type AcceptInvitationReq struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type UpdateMemberReq struct {
+	Role string `json:"role"` // admin | member
+}
+
+// Empty password generates temporary one which must be changed at first login
+type CreateUserReq struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+type CreateUserResp struct {
+	User         models.User `json:"user"`
+	TempPassword string      `json:"temp_password,omitempty"`
+}
+
+// Line of NDJSON users import
+type ImportUserReq struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+type ChangePasswordReq struct {
+	Email       string `json:"email"`
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+// Absent fields are left unchanged. New email is applied after confirmation
+type UpdateProfileReq struct {
+	Name      *string         `json:"name"`
+	AvatarURL *string         `json:"avatar_url"`
+	Locale    *string         `json:"locale"`
+	Timezone  *string         `json:"timezone"`
+	Metadata  json.RawMessage `json:"metadata"`
+	Email     *string         `json:"email"`
+}
+
+type ProfileResp struct {
+	User         models.User `json:"user"`
+	PendingEmail string      `json:"pending_email,omitempty"` // Confirmation link was sent to this address
+}
+
+type ConfirmEmailReq struct {
+	Token string `json:"token"`
+}
+
+// Empty events subscribe to all event types
+type CreateWebhookReq struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"auth/internal/adapters/transport/http/routers"
+	"auth/pkg/logger"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Gives the request an id from X-Request-ID or a new one and echoes it in the response.
+// After the handler the request is counted, its span is named by the route and one access log line is written
+func requestMiddleware(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := logger.RequestIDOrNew(r.Header.Get(routers.RequestIDHeader))
+		w.Header().Set(routers.RequestIDHeader, id)
+		r = r.WithContext(logger.WithRequestID(r.Context(), id))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// Мультиплексор записывает шаблон маршрута в переданный ему запрос
+		route := routeOf(r)
+		latency := time.Since(start)
+		observeRequest(r.Method, route, rec.status, latency)
+		nameSpan(r, route)
+
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		// Пробы и сбор метрик приходят каждые несколько секунд и не нужны в журнале
+		level := slog.LevelInfo
+		if untracedPaths[r.URL.Path] {
+			level = slog.LevelDebug
+		}
+		log.Log(r.Context(), level, "HTTP request",
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("latency", latency),
+			slog.Int64("bytes", rec.bytes),
+			slog.String("ip", ip),
+			slog.String("user_agent", r.UserAgent()),
+			slog.String("principal", logger.Principal(r.Context())),
+		)
+	})
+}
+
+// Remembers status code and size of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Body written without WriteHeader is sent with 200
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Exports stream rows and flush them as they go
+func (s *statusRecorder) Flush() {
+	s.wroteHeader = true
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
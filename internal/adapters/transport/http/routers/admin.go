@@ -6,14 +6,20 @@ import (
 	"auth/internal/domain/models"
 	"auth/internal/service"
 	"auth/pkg/utils"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+// Max size of bulk import body
+const maxImportBytes = 10 << 20
+
 type AdminHandler struct {
 	authServ  *service.AuthService
 	adminServ *service.AdminService
@@ -29,28 +35,23 @@ func NewAdminHandler(authServ *service.AuthService, adminServ *service.AdminServ
 }
 
 func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
-	adminToken, err := r.Cookie(models.Access)
-	if err != nil {
-		h.log.Error("Failed to get cookie", "error", err)
-		utils.SendError(w, errors.New("cookie not found"), http.StatusUnauthorized)
-		return
-	}
+	adminToken := authToken(r)
 
 	userID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		h.log.Error("Failed to convert user id", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to convert user id", "error", err)
 		utils.SendError(w, errors.New("user id is invalid"), http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.adminServ.GetUser(userID, adminToken.Value)
+	user, err := h.adminServ.GetUser(r.Context(), userID, adminToken)
 	if err != nil {
-		h.log.Error("Failed to get user", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to get user", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
-	h.log.Info("User data fetch finished")
+	h.log.InfoContext(r.Context(), "User data fetch finished")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err = json.NewEncoder(w).Encode(struct {
@@ -58,69 +59,404 @@ func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	}{
 		User: user,
 	}); err != nil {
-		h.log.Error("Failed to send user data", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to send user data", "error", err)
 		utils.SendError(w, errors.New("user data send error"), http.StatusInternalServerError)
 		return
 	}
 }
 
 func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	adminToken, err := r.Cookie(models.Access)
-	if err != nil {
-		h.log.Error("Failed to get cookie", "error", err)
-		utils.SendError(w, errors.New("cookie not found"), http.StatusUnauthorized)
-		return
-	}
+	adminToken := authToken(r)
 
 	userID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		h.log.Error("Failed to convert user id", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to convert user id", "error", err)
 		utils.SendError(w, errors.New("user id is invalid"), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.adminServ.DeleteUser(userID, adminToken.Value); err != nil {
-		h.log.Error("Failed to delete user", "error", err)
+	if err := h.adminServ.DeleteUser(r.Context(), userID, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to delete user", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
-	h.log.Info("User deleted succesfully", "ID", userID)
+	h.log.InfoContext(r.Context(), "User deleted succesfully", "ID", userID)
 	utils.SendMessage(w, http.StatusNoContent, "User deleted succesfully")
 }
 
-func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
-	adminToken, err := r.Cookie(models.Access)
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.updateStatus(w, r, h.adminServ.DisableUser, "User disabled succesfully")
+}
+
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.updateStatus(w, r, h.adminServ.EnableUser, "User enabled succesfully")
+}
+
+func (h *AdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	h.updateStatus(w, r, h.adminServ.RestoreUser, "User restored succesfully")
+}
+
+func (h *AdminHandler) updateStatus(w http.ResponseWriter, r *http.Request, update func(ctx context.Context, userID int, access string, meta models.RequestMeta) error, message string) {
+	adminToken := authToken(r)
+
+	userID, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
-		h.log.Error("Failed to get cookie", "error", err)
-		utils.SendError(w, errors.New("cookie not found"), http.StatusUnauthorized)
+		h.log.ErrorContext(r.Context(), "Failed to convert user id", "error", err)
+		utils.SendError(w, errors.New("user id is invalid"), http.StatusBadRequest)
 		return
 	}
 
+	if err := update(r.Context(), userID, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to update user status", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), message, "ID", userID)
+	utils.SendMessage(w, http.StatusOK, message)
+}
+
+func (h *AdminHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
 	var userReq dto.UpdateUserReq
 	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
-		h.log.Error("Failed to decode json", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
 		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
 		return
 	}
 
 	// Валидируем запрос
 	if err := validate.UserReq(userReq.ID, userReq.Name, userReq.Role); err != nil {
-		h.log.Error("Update request is invalid", "error", err)
+		h.log.ErrorContext(r.Context(), "Update request is invalid", "error", err)
 		utils.SendError(w, fmt.Errorf("update request is invalid: %w", err), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.adminServ.UpdateUser(models.User{
+	if err := h.adminServ.UpdateUser(r.Context(), models.User{
 		ID:   userReq.ID,
 		Name: userReq.Name,
 		Role: userReq.Role,
-	}, adminToken.Value); err != nil {
-		h.log.Error("Failed to update user", "error", err)
+	}, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to update user", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
-	h.log.Info("User updated succesfully", "ID", userReq.ID)
+	h.log.InfoContext(r.Context(), "User updated succesfully", "ID", userReq.ID)
 	utils.SendMessage(w, http.StatusOK, "User updated succesfully")
 }
+
+func (h *AdminHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	var userReq dto.CreateUserReq
+	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	// Валидируем запрос, пустой пароль будет сгенерирован
+	row := importRow(0, dto.ImportUserReq(userReq))
+	if row.Err != nil {
+		h.log.ErrorContext(r.Context(), "Credentials are invalid", "error", row.Err)
+		utils.SendError(w, row.Err, http.StatusBadRequest)
+		return
+	}
+
+	user, tempPassword, err := h.adminServ.CreateUser(r.Context(), models.User{
+		Name:  row.Name,
+		Email: row.Email,
+		Role:  row.Role,
+	}, row.Password, adminToken, RequestMeta(r))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to create user", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User created", "ID", user.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.CreateUserResp{
+		User:         user,
+		TempPassword: tempPassword,
+	})
+}
+
+// Imports users from CSV (text/csv) or NDJSON (application/x-ndjson) body.
+// mode=atomic (default) saves all rows or none, mode=partial saves valid rows.
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	var atomic bool
+	switch r.URL.Query().Get("mode") {
+	case "", "atomic":
+		atomic = true
+	case "partial":
+	default:
+		utils.SendError(w, errors.New("mode must be atomic or partial"), http.StatusBadRequest)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	var rows []models.ImportRow
+	var err error
+	switch mediaType(r) {
+	case "text/csv":
+		rows, err = parseImportCSV(body)
+	case "application/x-ndjson", "application/jsonl":
+		rows, err = parseImportNDJSON(body)
+	default:
+		utils.SendError(w, errors.New("content type must be text/csv or application/x-ndjson"), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to parse import", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if len(rows) == 0 {
+		utils.SendError(w, errors.New("import is empty"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.adminServ.ImportUsers(r.Context(), rows, atomic, adminToken, RequestMeta(r))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to import users", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	// Атомарный импорт с ошибками ничего не сохранил
+	code := http.StatusOK
+	if atomic && report.Failed > 0 {
+		code = http.StatusUnprocessableEntity
+	}
+
+	h.log.InfoContext(r.Context(), "Users import finished", "created", report.Created, "failed", report.Failed)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Lists users of the administrator's tenant, filters are passed in query params
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	filter, err := userFilter(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to parse filter", "error", err)
+		utils.SendError(w, fmt.Errorf("filter is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// Валидируем запрос
+	if err := validate.UserFilter(filter); err != nil {
+		h.log.ErrorContext(r.Context(), "Filter is invalid", "error", err)
+		utils.SendError(w, fmt.Errorf("filter is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.adminServ.ListUsers(r.Context(), filter, adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list users", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Users list fetch finished", "count", len(page.Users), "total", page.Total)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// Streams users of the administrator's tenant as CSV or NDJSON (format query param).
+// Listing filters are applied, password hashes are never exported.
+func (h *AdminHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	filter, err := userFilter(r)
+	if err == nil {
+		err = validate.UserFilter(filter)
+	}
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Filter is invalid", "error", err)
+		utils.SendError(w, fmt.Errorf("filter is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = models.ExportNDJSON
+	}
+
+	var writer userWriter
+	switch format {
+	case models.ExportCSV:
+		writer = newCSVUserWriter(w)
+	case models.ExportNDJSON:
+		writer = newNDJSONUserWriter(w)
+	default:
+		utils.SendError(w, errors.New("format must be csv or ndjson"), http.StatusBadRequest)
+		return
+	}
+
+	// Заголовки отправляются вместе с первой строкой, до нее еще можно вернуть ошибку
+	var count int
+	flusher, _ := w.(http.Flusher)
+	err = h.adminServ.ExportUsers(r.Context(), filter, adminToken, func(user models.User) error {
+		if count == 0 {
+			writer.Header(w)
+		}
+		if err := writer.Write(user); err != nil {
+			return err
+		}
+		if count++; count%100 == 0 && flusher != nil {
+			writer.Flush()
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to export users", "error", err, "exported", count)
+		if count == 0 {
+			utils.SendError(w, err, utils.GetHTTpStatus(err))
+		}
+		return
+	}
+
+	if count == 0 {
+		writer.Header(w)
+	}
+	writer.Flush()
+	h.log.InfoContext(r.Context(), "Users export finished", "exported", count)
+}
+
+func (h *AdminHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	tenant, err := h.adminServ.GetTenant(r.Context(), adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to get tenant", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	resp := dto.TenantResp{
+		ID:             tenant.ID,
+		Name:           tenant.Name,
+		PasswordPolicy: tenant.Password,
+	}
+	if tenant.AccessTTL > 0 {
+		resp.AccessTTL = tenant.AccessTTL.String()
+	}
+	if tenant.RefreshTTL > 0 {
+		resp.RefreshTTL = tenant.RefreshTTL.String()
+	}
+
+	h.log.InfoContext(r.Context(), "Tenant data fetch finished", "tenant", tenant.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *AdminHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	var settingsReq dto.TenantSettingsReq
+	if err := json.NewDecoder(r.Body).Decode(&settingsReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	var tenant models.Tenant
+	var err error
+	for _, ttl := range []struct {
+		raw  string
+		dest *time.Duration
+	}{
+		{settingsReq.AccessTTL, &tenant.AccessTTL},
+		{settingsReq.RefreshTTL, &tenant.RefreshTTL},
+	} {
+		if ttl.raw == "" {
+			continue
+		}
+		if *ttl.dest, err = time.ParseDuration(ttl.raw); err != nil {
+			h.log.ErrorContext(r.Context(), "Failed to parse TTL", "error", err)
+			utils.SendError(w, fmt.Errorf("invalid TTL %q: %w", ttl.raw, err), http.StatusBadRequest)
+			return
+		}
+	}
+	tenant.Password = settingsReq.PasswordPolicy
+
+	// Валидируем запрос
+	if err := validate.TenantSettings(tenant.AccessTTL, tenant.RefreshTTL, tenant.Password); err != nil {
+		h.log.ErrorContext(r.Context(), "Tenant settings are invalid", "error", err)
+		utils.SendError(w, fmt.Errorf("tenant settings are invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.adminServ.UpdateTenant(r.Context(), tenant, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to update tenant", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Tenant settings updated succesfully")
+	utils.SendMessage(w, http.StatusOK, "Tenant settings updated succesfully")
+}
+
+func userFilter(r *http.Request) (models.UserFilter, error) {
+	query := r.URL.Query()
+	filter := models.UserFilter{
+		Role:   query.Get("role"),
+		Query:  query.Get("q"),
+		Status: query.Get("status"),
+		SortBy: query.Get("sort"),
+		Cursor: query.Get("cursor"),
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return models.UserFilter{}, errors.New("order must be asc or desc")
+	}
+
+	var err error
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			return models.UserFilter{}, errors.New("limit must be a number")
+		}
+	}
+
+	for _, date := range []struct {
+		param string
+		dest  *time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+	} {
+		raw := query.Get(date.param)
+		if raw == "" {
+			continue
+		}
+		if *date.dest, err = time.Parse(time.RFC3339, raw); err != nil {
+			return models.UserFilter{}, fmt.Errorf("%s must be RFC3339 time", date.param)
+		}
+	}
+
+	return filter, nil
+}
+
+func mediaType(r *http.Request) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType
+}
@@ -0,0 +1,113 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	auditServ *service.AuditService
+	log       *slog.Logger
+}
+
+func NewAuditHandler(auditServ *service.AuditService, log *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditServ: auditServ,
+		log:       log,
+	}
+}
+
+// Returns audit log of the administrator's tenant, newest first
+func (h *AuditHandler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	filter, err := auditFilter(r)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to parse filter", "error", err)
+		utils.SendError(w, fmt.Errorf("filter is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	// Валидируем запрос
+	if err := validate.AuditFilter(filter); err != nil {
+		h.log.ErrorContext(r.Context(), "Filter is invalid", "error", err)
+		utils.SendError(w, fmt.Errorf("filter is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.auditServ.ListAudit(r.Context(), filter, adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list audit log", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Audit log fetch finished", "count", len(page.Entries))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// Checks hash chain of the administrator's tenant audit log
+func (h *AuditHandler) VerifyAudit(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	result, err := h.auditServ.VerifyAudit(r.Context(), adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to verify audit log", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Audit log verification finished", "checked", result.Checked, "valid", result.Valid)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func auditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Action: query.Get("action"),
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	if raw := query.Get("user_id"); raw != "" {
+		if filter.UserID, err = strconv.Atoi(raw); err != nil {
+			return models.AuditFilter{}, errors.New("user_id must be a number")
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil {
+			return models.AuditFilter{}, errors.New("limit must be a number")
+		}
+	}
+
+	for _, date := range []struct {
+		param string
+		dest  *time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		raw := query.Get(date.param)
+		if raw == "" {
+			continue
+		}
+		if *date.dest, err = time.Parse(time.RFC3339, raw); err != nil {
+			return models.AuditFilter{}, fmt.Errorf("%s must be RFC3339 time", date.param)
+		}
+	}
+
+	return filter, nil
+}
@@ -4,21 +4,41 @@ import (
 	validate "auth/internal/adapters/transport"
 	"auth/internal/adapters/transport/http/dto"
 	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
 	"auth/internal/service"
+	"auth/pkg/logger"
 	"auth/pkg/utils"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
+)
+
+// Header with tenant id for routes without {tenant} path segment
+const (
+	TenantHeader        = "X-Tenant-ID"
+	RequestIDHeader     = utils.RequestIDHeader
+	DeviceIDHeader      = "X-Device-ID"
+	TokenDeliveryHeader = "X-Token-Delivery"
+)
+
+// How /login and /refresh return tokens: cookies for browsers, JSON body for native clients and services
+const (
+	DeliveryCookie = "cookie"
+	DeliveryJSON   = "json"
 )
 
 type AuthHandler struct {
 	authServ  *service.AuthService
-	tokenServ *service.TokenService
+	tokenServ ports.TokenService
 	log       *slog.Logger
 }
 
-func NewAuthHandler(authServ *service.AuthService, tokenServ *service.TokenService, log *slog.Logger) *AuthHandler {
+func NewAuthHandler(authServ *service.AuthService, tokenServ ports.TokenService, log *slog.Logger) *AuthHandler {
 	return &AuthHandler{
 		authServ:  authServ,
 		tokenServ: tokenServ,
@@ -31,26 +51,44 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var user dto.LoginReq
 
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		h.log.Error("Failed to decode json", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
 		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
 		return
 	}
 
+	tenantID := TenantID(r)
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(r.Context(), "Tenant id is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := tokenDelivery(r, user.TokenDelivery, DeliveryCookie)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Token delivery is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
 	// Валидация реквизитов
 	if err := validate.Credentials("valid Name", user.Email, user.Password, models.UserRole); err != nil {
-		h.log.Error("Credentials are invalid")
+		h.log.ErrorContext(r.Context(), "Credentials are invalid")
 		utils.SendError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	tokens, err := h.authServ.Login(user.Email, user.Password)
+	tokens, err := h.authServ.Login(r.Context(), tenantID, user.Email, user.Password, RequestMeta(r))
 	if err != nil {
-		h.log.Error("Failed to auth user", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to auth user", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
-	h.log.Info("User login finished")
+	h.log.InfoContext(r.Context(), "User login finished")
+	if delivery == DeliveryJSON {
+		sendTokens(w, tokens)
+		return
+	}
 	SetTokenCookies(w, tokens, r.TLS != nil)
 	utils.SendMessage(w, http.StatusOK, "User login success")
 }
@@ -58,28 +96,35 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var user dto.RegisterReq
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		h.log.Error("Failed to decode json", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
 		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
 		return
 	}
 
+	tenantID := TenantID(r)
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(r.Context(), "Tenant id is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
 	// Валидация реквизитов
 	if err := validate.Credentials(user.Name, user.Email, user.Password, user.Role); err != nil {
-		h.log.Error("Credentials are invalid")
+		h.log.ErrorContext(r.Context(), "Credentials are invalid")
 		utils.SendError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	userID, err := h.authServ.Register(user.Name, user.Email, user.Password, user.Role)
+	userID, err := h.authServ.Register(r.Context(), tenantID, user.Name, user.Email, user.Password, user.Role, RequestMeta(r))
 	if err != nil {
-		h.log.Error("Failed to register user", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to register user", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
 	ClearTokenCookies(w)
 
-	h.log.Info("User registered", "ID", userID)
+	h.log.InfoContext(r.Context(), "User registered", "ID", userID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -90,51 +135,185 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *AuthHandler) CheckRole(w http.ResponseWriter, r *http.Request) {
-	// Достаем access token
-	tokenCookie, err := r.Cookie(models.Access)
-	if err != nil {
-		h.log.Error("Failed to get cookie", "error", err)
-		utils.SendError(w, errors.New("cookie not found"), http.StatusUnauthorized)
+// Changes password by the old one, required after login with temporary password
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var passwordReq dto.ChangePasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&passwordReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	tenantID := TenantID(r)
+	if err := validate.Tenant(tenantID); err != nil {
+		h.log.ErrorContext(r.Context(), "Tenant id is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
 		return
 	}
 
+	// Валидация реквизитов
+	if err := validate.Credentials("valid Name", passwordReq.Email, passwordReq.NewPassword, models.UserRole); err != nil {
+		h.log.ErrorContext(r.Context(), "Credentials are invalid")
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authServ.ChangePassword(r.Context(), tenantID, passwordReq.Email, passwordReq.OldPassword, passwordReq.NewPassword); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to change password", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Password changed")
+	utils.SendMessage(w, http.StatusOK, "Password changed succesfully")
+}
+
+func (h *AuthHandler) CheckRole(w http.ResponseWriter, r *http.Request) {
+	// Достаем access token
+	accessToken := authToken(r)
+
 	// Вызов основной логики
-	existUser, err := h.authServ.RoleCheck(tokenCookie.Value)
+	existUser, err := h.authServ.RoleCheck(r.Context(), accessToken)
 	if err != nil {
-		h.log.Error("Failed to check user role", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to check user role", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
 	// Возвращаем ответ
-	h.log.Info("User role check finished", "ID", existUser.ID, "is_admin", existUser.IsAdmin)
+	h.log.InfoContext(r.Context(), "User role check finished", "ID", existUser.ID, "is_admin", existUser.IsAdmin)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(&existUser)
 }
 
+// Refreshes tokens by refresh token from the body or the cookie. Body is optional for cookie clients
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	tokenCookie, err := r.Cookie(models.Refresh)
+	var refreshReq dto.RefreshReq
+	if err := json.NewDecoder(r.Body).Decode(&refreshReq); err != nil && !errors.Is(err, io.EOF) {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	// Клиент, передавший токен в теле, по умолчанию получает новую пару тоже в теле
+	refreshToken, defaultDelivery := refreshReq.RefreshToken, DeliveryJSON
+	if refreshToken == "" {
+		tokenCookie, err := r.Cookie(models.Refresh)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "Failed to get refresh token", "error", err)
+			utils.SendError(w, errors.New("refresh token not found"), http.StatusUnauthorized)
+			return
+		}
+		refreshToken, defaultDelivery = tokenCookie.Value, DeliveryCookie
+
+		// Refresh по cookie изменяет сессию, поэтому подтверждается CSRF токеном как остальные небезопасные запросы
+		claims, err := h.tokenServ.Claims(refreshToken)
+		if err != nil {
+			h.log.ErrorContext(r.Context(), "Refresh token is invalid", "error", err)
+			utils.SendError(w, models.ErrInvalidToken, http.StatusUnauthorized)
+			return
+		}
+		if err := checkCSRF(r, h.tokenServ, claims); err != nil {
+			h.log.ErrorContext(r.Context(), "CSRF check failed", "error", err)
+			utils.SendError(w, err, http.StatusForbidden)
+			return
+		}
+	}
+
+	delivery, err := tokenDelivery(r, refreshReq.TokenDelivery, defaultDelivery)
 	if err != nil {
-		h.log.Error("Failed to get cookie", "error", err)
-		utils.SendError(w, errors.New("cookie not found"), http.StatusUnauthorized)
+		h.log.ErrorContext(r.Context(), "Token delivery is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	tokens, err := h.tokenServ.Refresh(tokenCookie.Value)
+	tokens, err := h.authServ.Refresh(r.Context(), refreshToken, RequestMeta(r))
 	if err != nil {
-		h.log.Error("Failed to refresh token", "error", err)
+		h.log.ErrorContext(r.Context(), "Failed to refresh token", "error", err)
 		utils.SendError(w, err, utils.GetHTTpStatus(err))
 		return
 	}
 
-	h.log.Info("Token has been refreshed")
+	h.log.InfoContext(r.Context(), "Token has been refreshed")
+	if delivery == DeliveryJSON {
+		sendTokens(w, tokens)
+		return
+	}
 	SetTokenCookies(w, tokens, r.TLS != nil)
 	w.WriteHeader(http.StatusOK)
 }
 
+// Returns CSRF token of the cookie session. Pages of other origins can't read the cookie and take the token here
+func (h *AuthHandler) CSRFToken(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(models.CSRF)
+	if err != nil || cookie.Value == "" {
+		h.log.ErrorContext(r.Context(), "CSRF token not found")
+		utils.SendError(w, errors.New("CSRF token not found, log in with cookie delivery"), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(CSRFHeader, cookie.Value)
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.CSRFResp{CSRFToken: cookie.Value})
+}
+
+// Returns delivery mode from the body field, then from X-Token-Delivery header
+func tokenDelivery(r *http.Request, field, defaultDelivery string) (string, error) {
+	delivery := field
+	if delivery == "" {
+		delivery = r.Header.Get(TokenDeliveryHeader)
+	}
+	switch delivery {
+	case "":
+		return defaultDelivery, nil
+	case DeliveryCookie, DeliveryJSON:
+		return delivery, nil
+	default:
+		return "", fmt.Errorf("token delivery must be %s or %s", DeliveryCookie, DeliveryJSON)
+	}
+}
+
+// Sends token pair in the body. Responses with tokens must not be cached
+func sendTokens(w http.ResponseWriter, tokens models.TokenPair) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.TokenResp{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(time.Until(tokens.AccessExpiresAt).Round(time.Second) / time.Second),
+		TokenType:    "Bearer",
+	})
+}
+
+// Returns tenant id from /tenants/{tenant}/... path or X-Tenant-ID header
+func TenantID(r *http.Request) string {
+	if tenantID := r.PathValue("tenant"); tenantID != "" {
+		return tenantID
+	}
+	return r.Header.Get(TenantHeader)
+}
+
+// Returns client info of the request for the audit log and login history.
+// IP is taken from the connection, forwarded headers are not trusted
+func RequestMeta(r *http.Request) models.RequestMeta {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return models.RequestMeta{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		RequestID: logger.RequestID(r.Context()),
+		DeviceID:  r.Header.Get(DeviceIDHeader),
+	}
+}
+
 func SetTokenCookies(w http.ResponseWriter, tokens models.TokenPair, hasTLS bool) {
 	ClearTokenCookies(w)
 	http.SetCookie(w, &http.Cookie{
@@ -155,6 +334,17 @@ func SetTokenCookies(w http.ResponseWriter, tokens models.TokenPair, hasTLS bool
 		SameSite: http.SameSiteStrictMode,
 		Path:     "/refresh",
 	})
+	// Клиенты других источников не читают cookie, токен дублируется в заголовке ответа
+	http.SetCookie(w, &http.Cookie{
+		Name:     models.CSRF,
+		Value:    tokens.CSRFToken,
+		Expires:  tokens.RefreshExpiresAt.UTC(),
+		HttpOnly: true,
+		Secure:   hasTLS,
+		SameSite: http.SameSiteStrictMode,
+		Path:     "/",
+	})
+	w.Header().Set(CSRFHeader, tokens.CSRFToken)
 }
 
 func ClearTokenCookies(w http.ResponseWriter) {
@@ -168,4 +358,9 @@ func ClearTokenCookies(w http.ResponseWriter) {
 		Value:  "",
 		MaxAge: -1,
 	})
+	http.SetCookie(w, &http.Cookie{
+		Name:   models.CSRF,
+		Value:  "",
+		MaxAge: -1,
+	})
 }
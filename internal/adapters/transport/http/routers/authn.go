@@ -0,0 +1,131 @@
+package routers
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Where access token is looked up first when request has both
+const (
+	PrecedenceHeader = "header"
+	PrecedenceCookie = "cookie"
+)
+
+var errTokenNotFound = errors.New("access token not found")
+
+// Access token of the request that passed authentication
+type accessTokenKey struct{}
+
+// Authenticates requests by "Authorization: Bearer" header or access_token cookie.
+// Unsafe requests authenticated by the cookie must also pass the CSRF check
+type Authenticator struct {
+	tokenServ   *service.TokenService
+	cookieFirst bool
+	log         *slog.Logger
+}
+
+func NewAuthenticator(tokenServ *service.TokenService, precedence string, log *slog.Logger) (*Authenticator, error) {
+	if precedence != PrecedenceHeader && precedence != PrecedenceCookie {
+		return nil, fmt.Errorf("unknown auth precedence %q", precedence)
+	}
+	return &Authenticator{
+		tokenServ:   tokenServ,
+		cookieFirst: precedence == PrecedenceCookie,
+		log:         log,
+	}, nil
+}
+
+// Rejects requests without valid access token
+func (a *Authenticator) Required(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, fromCookie := a.token(r)
+		if token == "" {
+			a.log.ErrorContext(r.Context(), "Access token not found")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="auth"`)
+			utils.SendError(w, errTokenNotFound, http.StatusUnauthorized)
+			return
+		}
+		a.authenticate(w, r, token, fromCookie, next)
+	}
+}
+
+// Authenticates request if it has access token, anonymous requests are passed as is
+func (a *Authenticator) Optional(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, fromCookie := a.token(r)
+		if token == "" {
+			next(w, r)
+			return
+		}
+		a.authenticate(w, r, token, fromCookie, next)
+	}
+}
+
+func (a *Authenticator) authenticate(w http.ResponseWriter, r *http.Request, token string, fromCookie bool, next http.HandlerFunc) {
+	ctx, claims, err := a.tokenServ.Authenticate(r.Context(), token)
+	if err != nil {
+		a.log.ErrorContext(r.Context(), "Failed to authenticate request", "error", err)
+		status := utils.GetHTTpStatus(err)
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="auth", error="invalid_token"`)
+		}
+		utils.SendError(w, err, status)
+		return
+	}
+
+	// Браузер прикладывает cookie и к запросам, отправленным чужими страницами
+	if fromCookie && !safeMethod(r.Method) {
+		if err := checkCSRF(r, a.tokenServ, claims); err != nil {
+			a.log.ErrorContext(ctx, "CSRF check failed", "error", err)
+			utils.SendError(w, err, http.StatusForbidden)
+			return
+		}
+	}
+
+	a.log.DebugContext(ctx, "Request authenticated", "tenant", claims.TenantID, "ID", claims.ID)
+	next(w, r.WithContext(context.WithValue(ctx, accessTokenKey{}, token)))
+}
+
+// Returns access token from the header or the cookie in configured order and whether it is the cookie
+func (a *Authenticator) token(r *http.Request) (string, bool) {
+	if a.cookieFirst {
+		if token := cookieToken(r); token != "" {
+			return token, true
+		}
+		return bearerToken(r), false
+	}
+	if token := bearerToken(r); token != "" {
+		return token, false
+	}
+	token := cookieToken(r)
+	return token, token != ""
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+func cookieToken(r *http.Request) string {
+	cookie, err := r.Cookie(models.Access)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// Returns access token authenticated by Authenticator, empty for anonymous requests
+func authToken(r *http.Request) string {
+	token, _ := r.Context().Value(accessTokenKey{}).(string)
+	return token
+}
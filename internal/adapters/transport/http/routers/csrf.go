@@ -0,0 +1,37 @@
+package routers
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// Header repeating csrf_token cookie in unsafe requests of cookie sessions
+const CSRFHeader = "X-CSRF-Token"
+
+var errCSRF = errors.New("CSRF token is missing or invalid")
+
+// Methods that don't change state and are not checked for CSRF
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// Checks double-submitted CSRF token: header must repeat the csrf_token cookie, and the token must be
+// signed for the session of the token claims
+func checkCSRF(r *http.Request, tokenServ ports.TokenService, claims models.CustomClaims) error {
+	token := r.Header.Get(CSRFHeader)
+	cookie, err := r.Cookie(models.CSRF)
+	if token == "" || err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		return errCSRF
+	}
+	if err := tokenServ.CheckCSRF(token, claims); err != nil {
+		return errCSRF
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package routers
+
+import (
+	"auth/internal/domain/models"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Writes exported users in the response format
+type userWriter interface {
+	Header(w http.ResponseWriter)
+	Write(user models.User) error
+	Flush()
+}
+
+var csvExportColumns = []string{"ID", "tenant_id", "name", "email", "role", "status", "is_admin", "must_change_password", "created_at", "updated_at"}
+
+type csvUserWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVUserWriter(w http.ResponseWriter) *csvUserWriter {
+	return &csvUserWriter{writer: csv.NewWriter(w)}
+}
+
+func (c *csvUserWriter) Header(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+	w.WriteHeader(http.StatusOK)
+	_ = c.writer.Write(csvExportColumns)
+}
+
+func (c *csvUserWriter) Write(user models.User) error {
+	return c.writer.Write([]string{
+		strconv.Itoa(user.ID),
+		user.TenantID,
+		user.Name,
+		user.Email,
+		user.Role,
+		user.Status,
+		strconv.FormatBool(user.IsAdmin),
+		strconv.FormatBool(user.MustChangePassword),
+		user.Created_At.UTC().Format(time.RFC3339),
+		user.Updated_At.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *csvUserWriter) Flush() {
+	c.writer.Flush()
+}
+
+type ndjsonUserWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONUserWriter(w http.ResponseWriter) *ndjsonUserWriter {
+	return &ndjsonUserWriter{encoder: json.NewEncoder(w)}
+}
+
+func (n *ndjsonUserWriter) Header(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="users.ndjson"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Encoder writes one object per line
+func (n *ndjsonUserWriter) Write(user models.User) error {
+	return n.encoder.Encode(user)
+}
+
+func (n *ndjsonUserWriter) Flush() {}
@@ -0,0 +1,40 @@
+package routers
+
+import (
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type HealthHandler struct {
+	healthServ *service.HealthService
+	log        *slog.Logger
+}
+
+func NewHealthHandler(healthServ *service.HealthService, log *slog.Logger) *HealthHandler {
+	return &HealthHandler{
+		healthServ: healthServ,
+		log:        log,
+	}
+}
+
+// Liveness probe: the process serves requests. Dependencies are not checked,
+// so their outage makes the instance not ready instead of restarting it
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	utils.SendMessage(w, http.StatusOK, "ok")
+}
+
+// Readiness probe: 503 while the instance is starting, shutting down or its dependency check fails
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report, err := h.healthServ.Ready(r.Context())
+
+	code := http.StatusOK
+	if err != nil {
+		code = utils.GetHTTpStatus(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,118 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/adapters/transport/http/dto"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var csvColumns = []string{"name", "email", "password", "role"}
+
+// Parses CSV with header row. Columns name and email are required, password and role are optional
+func parseImportCSV(r io.Reader) ([]models.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range csvColumns[:2] {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header must contain %q column", required)
+		}
+	}
+
+	var rows []models.ImportRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		row := models.ImportRow{Line: line}
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			row.Err = parseErr.Err
+		case err != nil:
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		default:
+			field := func(name string) string {
+				if i, ok := columns[name]; ok && i < len(record) {
+					return strings.TrimSpace(record[i])
+				}
+				return ""
+			}
+			row = importRow(line, dto.ImportUserReq{
+				Name:     field("name"),
+				Email:    field("email"),
+				Password: field("password"),
+				Role:     field("role"),
+			})
+		}
+
+		if rows = append(rows, row); len(rows) > service.MaxImportRows {
+			return nil, fmt.Errorf("import is limited to %d rows", service.MaxImportRows)
+		}
+	}
+	return rows, nil
+}
+
+// Parses newline delimited JSON, one user object per line
+func parseImportNDJSON(r io.Reader) ([]models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+
+	var rows []models.ImportRow
+	for line := 1; scanner.Scan(); line++ {
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		var userReq dto.ImportUserReq
+		row := models.ImportRow{Line: line}
+		if err := json.Unmarshal([]byte(raw), &userReq); err != nil {
+			row.Err = errors.New("invalid JSON data")
+		} else {
+			row = importRow(line, userReq)
+		}
+
+		if rows = append(rows, row); len(rows) > service.MaxImportRows {
+			return nil, fmt.Errorf("import is limited to %d rows", service.MaxImportRows)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return rows, nil
+}
+
+func importRow(line int, userReq dto.ImportUserReq) models.ImportRow {
+	row := models.ImportRow{
+		Line:     line,
+		Name:     userReq.Name,
+		Email:    userReq.Email,
+		Password: userReq.Password,
+		Role:     userReq.Role,
+	}
+	if row.Role == "" {
+		row.Role = models.UserRole
+	}
+	row.Err = validate.NewUser(row.Name, row.Email, row.Password, row.Role)
+	return row
+}
@@ -0,0 +1,156 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/adapters/transport/http/dto"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Handles requests of the user about own account
+type MeHandler struct {
+	exportServ  *service.ExportService
+	profileServ *service.ProfileService
+	loginServ   *service.LoginService
+	log         *slog.Logger
+}
+
+func NewMeHandler(exportServ *service.ExportService, profileServ *service.ProfileService, loginServ *service.LoginService, log *slog.Logger) *MeHandler {
+	return &MeHandler{
+		exportServ:  exportServ,
+		profileServ: profileServ,
+		loginServ:   loginServ,
+		log:         log,
+	}
+}
+
+func (h *MeHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	user, err := h.profileServ.GetProfile(r.Context(), accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to get profile", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.sendProfile(w, dto.ProfileResp{User: user})
+}
+
+// Partially updates own profile, email change is confirmed by the link sent to the new address
+func (h *MeHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	var profileReq dto.UpdateProfileReq
+	if err := json.NewDecoder(r.Body).Decode(&profileReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	update := models.ProfileUpdate(profileReq)
+	if err := validate.Profile(update); err != nil {
+		h.log.ErrorContext(r.Context(), "Profile update is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	user, pendingEmail, err := h.profileServ.UpdateProfile(r.Context(), update, accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to update profile", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Profile updated succesfully", "ID", user.ID)
+	h.sendProfile(w, dto.ProfileResp{User: user, PendingEmail: pendingEmail})
+}
+
+// Applies email change from the confirmation link
+func (h *MeHandler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var confirmReq dto.ConfirmEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&confirmReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	if confirmReq.Token == "" {
+		h.log.ErrorContext(r.Context(), "Confirmation token is empty")
+		utils.SendError(w, models.ErrInvalidToken, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.profileServ.ConfirmEmail(r.Context(), confirmReq.Token)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to confirm email", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Email changed succesfully", "ID", user.ID)
+	h.sendProfile(w, dto.ProfileResp{User: user})
+}
+
+func (h *MeHandler) sendProfile(w http.ResponseWriter, profile dto.ProfileResp) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(profile); err != nil {
+		h.log.Error("Failed to send profile", "error", err)
+	}
+}
+
+// Returns own login history, newest first
+func (h *MeHandler) ListLogins(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			h.log.ErrorContext(r.Context(), "Limit is invalid", "limit", raw)
+			utils.SendError(w, errors.New("limit must be a non-negative number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.loginServ.ListLogins(r.Context(), r.URL.Query().Get("cursor"), limit, accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list logins", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Login history fetch finished", "count", len(page.Logins))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+// Returns JSON archive of the user's personal data
+func (h *MeHandler) Export(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	export, err := h.exportServ.ExportMe(r.Context(), accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to export user data", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "User data export finished", "ID", export.Profile.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, export.Profile.ID))
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(export)
+}
@@ -0,0 +1,252 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/adapters/transport/http/dto"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type OrgHandler struct {
+	orgServ *service.OrgService
+	log     *slog.Logger
+}
+
+func NewOrgHandler(orgServ *service.OrgService, log *slog.Logger) *OrgHandler {
+	return &OrgHandler{
+		orgServ: orgServ,
+		log:     log,
+	}
+}
+
+func (h *OrgHandler) CreateOrg(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	var orgReq dto.CreateOrgReq
+	if err := json.NewDecoder(r.Body).Decode(&orgReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	if err := validate.OrgName(orgReq.Name); err != nil {
+		h.log.ErrorContext(r.Context(), "Organization name is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	org, err := h.orgServ.CreateOrg(r.Context(), orgReq.Name, accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to create organization", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Organization created", "ID", org.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(org)
+}
+
+func (h *OrgHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	orgID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert organization id", "error", err)
+		utils.SendError(w, errors.New("organization id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	var inviteReq dto.InviteReq
+	if err := json.NewDecoder(r.Body).Decode(&inviteReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	// Валидируем запрос
+	if err := validate.Invitation(inviteReq.Email, inviteReq.Role); err != nil {
+		h.log.ErrorContext(r.Context(), "Invitation is invalid", "error", err)
+		utils.SendError(w, fmt.Errorf("invitation is invalid: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	inv, err := h.orgServ.Invite(r.Context(), orgID, inviteReq.Email, inviteReq.Role, accessToken, RequestMeta(r))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to invite member", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Invitation sent", "ID", inv.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(inv)
+}
+
+// Accepts invitation, access cookie is optional
+func (h *OrgHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var acceptReq dto.AcceptInvitationReq
+	if err := json.NewDecoder(r.Body).Decode(&acceptReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	if acceptReq.Token == "" {
+		h.log.ErrorContext(r.Context(), "Invitation token is empty")
+		utils.SendError(w, models.ErrInvitationInvalid, http.StatusBadRequest)
+		return
+	}
+
+	// Вошедший пользователь присоединяется сам, новый регистрируется с паролем
+	access := authToken(r)
+	if access == "" && acceptReq.Password == "" {
+		h.log.ErrorContext(r.Context(), "Password is required without access token")
+		utils.SendError(w, models.ErrEmptyPassword, http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.orgServ.AcceptInvitation(r.Context(), acceptReq.Token, access, acceptReq.Name, acceptReq.Password, RequestMeta(r))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to accept invitation", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Invitation accepted", "org", member.OrgID, "user", member.UserID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(member)
+}
+
+func (h *OrgHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	orgID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert organization id", "error", err)
+		utils.SendError(w, errors.New("organization id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	invitationID, err := pathID(r, "invitationID")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert invitation id", "error", err)
+		utils.SendError(w, errors.New("invitation id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgServ.RevokeInvitation(r.Context(), orgID, invitationID, accessToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to revoke invitation", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Invitation revoked", "ID", invitationID)
+	utils.SendMessage(w, http.StatusOK, "Invitation revoked succesfully")
+}
+
+func (h *OrgHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	orgID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert organization id", "error", err)
+		utils.SendError(w, errors.New("organization id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	invitations, err := h.orgServ.ListInvitations(r.Context(), orgID, accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list invitations", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(struct {
+		Invitations []models.Invitation `json:"invitations"`
+	}{
+		Invitations: invitations,
+	})
+}
+
+func (h *OrgHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	orgID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert organization id", "error", err)
+		utils.SendError(w, errors.New("organization id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	members, err := h.orgServ.ListMembers(r.Context(), orgID, accessToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list members", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(struct {
+		Members []models.Membership `json:"members"`
+	}{
+		Members: members,
+	})
+}
+
+func (h *OrgHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	accessToken := authToken(r)
+
+	orgID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert organization id", "error", err)
+		utils.SendError(w, errors.New("organization id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	userID, err := pathID(r, "userID")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert user id", "error", err)
+		utils.SendError(w, errors.New("user id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	var memberReq dto.UpdateMemberReq
+	if err := json.NewDecoder(r.Body).Decode(&memberReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	if err := validate.OrgRole(memberReq.Role); err != nil {
+		h.log.ErrorContext(r.Context(), "Member role is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orgServ.UpdateMemberRole(r.Context(), orgID, userID, memberReq.Role, accessToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to update member role", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Member role updated", "org", orgID, "user", userID)
+	utils.SendMessage(w, http.StatusOK, "Member role updated succesfully")
+}
+
+func pathID(r *http.Request, name string) (int, error) {
+	return strconv.Atoi(r.PathValue(name))
+}
@@ -0,0 +1,149 @@
+package routers
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/adapters/transport/http/dto"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/pkg/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	webhookServ *service.WebhookService
+	log         *slog.Logger
+}
+
+func NewWebhookHandler(webhookServ *service.WebhookService, log *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookServ: webhookServ,
+		log:         log,
+	}
+}
+
+// Registers webhook, response contains signing secret which is not shown again
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	var webhookReq dto.CreateWebhookReq
+	if err := json.NewDecoder(r.Body).Decode(&webhookReq); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to decode json", "error", err)
+		utils.SendError(w, errors.New("invalid JSON data"), http.StatusBadRequest)
+		return
+	}
+
+	// Валидируем запрос
+	if err := validate.Webhook(webhookReq.URL, webhookReq.Events); err != nil {
+		h.log.ErrorContext(r.Context(), "Webhook is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.webhookServ.CreateWebhook(r.Context(), models.Webhook{
+		URL:    webhookReq.URL,
+		Events: webhookReq.Events,
+	}, adminToken, RequestMeta(r))
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to create webhook", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Webhook created", "ID", webhook.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(webhook)
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	webhooks, err := h.webhookServ.ListWebhooks(r.Context(), adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list webhooks", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(webhooks)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	webhookID, err := pathID(r, "id")
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert webhook id", "error", err)
+		utils.SendError(w, errors.New("webhook id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookServ.DeleteWebhook(r.Context(), webhookID, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to delete webhook", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Webhook deleted", "ID", webhookID)
+	utils.SendMessage(w, http.StatusOK, "Webhook deleted succesfully")
+}
+
+// Returns deliveries filtered by status query param, status=dead gives the dead-letter view
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	status := r.URL.Query().Get("status")
+	if err := validate.DeliveryStatus(status); err != nil {
+		h.log.ErrorContext(r.Context(), "Delivery status is invalid", "error", err)
+		utils.SendError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			h.log.ErrorContext(r.Context(), "Limit is invalid", "limit", raw)
+			utils.SendError(w, errors.New("limit must be a non-negative number"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := h.webhookServ.ListDeliveries(r.Context(), status, limit, adminToken)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to list deliveries", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(deliveries)
+}
+
+// Requeues dead delivery
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	adminToken := authToken(r)
+
+	deliveryID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to convert delivery id", "error", err)
+		utils.SendError(w, errors.New("delivery id is invalid"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookServ.RetryDelivery(r.Context(), deliveryID, adminToken, RequestMeta(r)); err != nil {
+		h.log.ErrorContext(r.Context(), "Failed to retry delivery", "error", err)
+		utils.SendError(w, err, utils.GetHTTpStatus(err))
+		return
+	}
+
+	h.log.InfoContext(r.Context(), "Delivery requeued", "ID", deliveryID)
+	utils.SendMessage(w, http.StatusOK, "Delivery requeued succesfully")
+}
@@ -4,11 +4,15 @@ import (
 	"auth/config"
 	"auth/internal/adapters/transport/http/routers"
 	"auth/internal/service"
+	"auth/pkg/cors"
+	"auth/pkg/tlsconfig"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -19,33 +23,115 @@ type API struct {
 	log *slog.Logger
 }
 
-func New(cfg config.HttpServer, authServ *service.AuthService, adminServ *service.AdminService, tokenServ *service.TokenService, log *slog.Logger) *API {
+func New(cfg config.HttpServer, authServ *service.AuthService, adminServ *service.AdminService, orgServ *service.OrgService, exportServ *service.ExportService, profileServ *service.ProfileService, loginServ *service.LoginService, auditServ *service.AuditService, webhookServ *service.WebhookService, healthServ *service.HealthService, tokenServ *service.TokenService, log *slog.Logger) (*API, error) {
+	const op = "httpserver.New"
+
 	mux := http.NewServeMux()
 	SetSwagger(mux)
 
 	authH := routers.NewAuthHandler(authServ, tokenServ, log)
 	adminH := routers.NewAdminHandler(authServ, adminServ, log)
+	orgH := routers.NewOrgHandler(orgServ, log)
+	meH := routers.NewMeHandler(exportServ, profileServ, loginServ, log)
+	auditH := routers.NewAuditHandler(auditServ, log)
+	webhookH := routers.NewWebhookHandler(webhookServ, log)
+	healthH := routers.NewHealthHandler(healthServ, log)
+
+	// Protected routes take access token from "Authorization: Bearer" header or access_token cookie
+	authn, err := routers.NewAuthenticator(tokenServ, cfg.AuthPrecedence, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Probes of orchestrators and load balancers
+	mux.HandleFunc("GET /healthz", healthH.Live)
+	mux.HandleFunc("GET /readyz", healthH.Ready)
+	mux.Handle("GET /metrics", promhttp.Handler())
 
+	// Tenant is taken from X-Tenant-ID header or from the path
 	mux.HandleFunc("POST /login", authH.Login)
 	mux.HandleFunc("POST /register", authH.Register)
+	mux.HandleFunc("POST /tenants/{tenant}/login", authH.Login)
+	mux.HandleFunc("POST /tenants/{tenant}/register", authH.Register)
+	mux.HandleFunc("POST /password/change", authH.ChangePassword)
+	mux.HandleFunc("POST /tenants/{tenant}/password/change", authH.ChangePassword)
 	mux.HandleFunc("POST /refresh", authH.RefreshToken)
-	mux.HandleFunc("GET /role", authH.CheckRole)
+	mux.HandleFunc("GET /csrf", authH.CSRFToken)
+	mux.HandleFunc("GET /role", authn.Required(authH.CheckRole))
+	mux.HandleFunc("GET /me", authn.Required(meH.GetProfile))
+	mux.HandleFunc("PATCH /me", authn.Required(meH.UpdateProfile))
+	mux.HandleFunc("POST /me/email/confirm", meH.ConfirmEmail)
+	mux.HandleFunc("GET /me/logins", authn.Required(meH.ListLogins))
+	mux.HandleFunc("GET /me/export", authn.Required(meH.Export))
 
 	// Admin rights
-	mux.HandleFunc("PUT /user", adminH.UpdateUser)
-	mux.HandleFunc("GET /user/{id}", adminH.GetUser)
-	mux.HandleFunc("DELETE /user/{id}", adminH.DeleteUser)
+	mux.HandleFunc("GET /users", authn.Required(adminH.ListUsers))
+	mux.HandleFunc("POST /users", authn.Required(adminH.CreateUser))
+	mux.HandleFunc("POST /users/import", authn.Required(adminH.ImportUsers))
+	mux.HandleFunc("GET /users/export", authn.Required(adminH.ExportUsers))
+	mux.HandleFunc("PUT /user", authn.Required(adminH.UpdateUser))
+	mux.HandleFunc("GET /user/{id}", authn.Required(adminH.GetUser))
+	mux.HandleFunc("DELETE /user/{id}", authn.Required(adminH.DeleteUser))
+	mux.HandleFunc("POST /user/{id}/disable", authn.Required(adminH.DisableUser))
+	mux.HandleFunc("POST /user/{id}/enable", authn.Required(adminH.EnableUser))
+	mux.HandleFunc("POST /user/{id}/restore", authn.Required(adminH.RestoreUser))
+	mux.HandleFunc("GET /tenant", authn.Required(adminH.GetTenant))
+	mux.HandleFunc("PUT /tenant", authn.Required(adminH.UpdateTenant))
+	mux.HandleFunc("GET /audit", authn.Required(auditH.ListAudit))
+	mux.HandleFunc("GET /audit/verify", authn.Required(auditH.VerifyAudit))
+	mux.HandleFunc("POST /webhooks", authn.Required(webhookH.CreateWebhook))
+	mux.HandleFunc("GET /webhooks", authn.Required(webhookH.ListWebhooks))
+	mux.HandleFunc("DELETE /webhooks/{id}", authn.Required(webhookH.DeleteWebhook))
+	mux.HandleFunc("GET /webhooks/deliveries", authn.Required(webhookH.ListDeliveries))
+	mux.HandleFunc("POST /webhooks/deliveries/{id}/retry", authn.Required(webhookH.RetryDelivery))
+
+	// Organizations
+	mux.HandleFunc("POST /orgs", authn.Required(orgH.CreateOrg))
+	mux.HandleFunc("GET /orgs/{id}/members", authn.Required(orgH.ListMembers))
+	mux.HandleFunc("PUT /orgs/{id}/members/{userID}", authn.Required(orgH.UpdateMemberRole))
+	mux.HandleFunc("POST /orgs/{id}/invitations", authn.Required(orgH.Invite))
+	mux.HandleFunc("GET /orgs/{id}/invitations", authn.Required(orgH.ListInvitations))
+	mux.HandleFunc("DELETE /orgs/{id}/invitations/{invitationID}", authn.Required(orgH.RevokeInvitation))
+	mux.HandleFunc("POST /invitations/accept", authn.Optional(orgH.AcceptInvitation))
+
+	// Браузерные приложения других источников, без разрешенных источников CORS выключен
+	corsMiddleware, err := cors.New(cors.Options{
+		AllowedOrigins:   cors.List(cfg.CORS.AllowedOrigins),
+		AllowedMethods:   cors.List(cfg.CORS.AllowedMethods),
+		AllowedHeaders:   cors.List(cfg.CORS.AllowedHeaders),
+		ExposedHeaders:   cors.List(cfg.CORS.ExposedHeaders),
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
 	serv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
-		Handler: mux,
+		Handler: tracingMiddleware(requestMiddleware(log, corsMiddleware(mux))),
+	}
+
+	// С сертификатом сервер работает по HTTPS, и cookie токенов получают флаг Secure
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		tlsConfig, err := tlsconfig.New(tlsconfig.Options{
+			CertFile:     cfg.TLS.CertFile,
+			KeyFile:      cfg.TLS.KeyFile,
+			MinVersion:   cfg.TLS.MinVersion,
+			CipherSuites: cfg.TLS.CipherSuites,
+			NextProtos:   []string{"h2", "http/1.1"},
+		}, log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		serv.TLSConfig = tlsConfig
 	}
 
 	return &API{
 		server: serv,
 		cfg:    cfg,
 		log:    log,
-	}
+	}, nil
 }
 
 func SetSwagger(mux *http.ServeMux) {
@@ -70,15 +156,28 @@ func SetSwagger(mux *http.ServeMux) {
 func (a *API) StartServer() error {
 	const op = "httpserver.StartServer"
 
-	a.log.Info("Server started on " + a.server.Addr)
-	if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	var err error
+	if a.server.TLSConfig != nil {
+		a.log.Info("Server started on " + a.server.Addr + " with TLS")
+		err = a.server.ListenAndServeTLS("", "")
+	} else {
+		a.log.Info("Server started on " + a.server.Addr)
+		err = a.server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (a *API) Close() error {
-	a.log.Info("Shutting down http server...")
+// Stops accepting connections and waits for in-flight requests. When ctx is done
+// remaining connections are closed forcibly
+func (a *API) Shutdown(ctx context.Context) error {
+	a.log.InfoContext(ctx, "Shutting down http server...")
 
-	return a.server.Close()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.WarnContext(ctx, "Graceful shutdown timed out, closing open connections", "error", err)
+		return a.server.Close()
+	}
+	return nil
 }
@@ -2,10 +2,20 @@ package validate
 
 import (
 	"auth/internal/domain/models"
+	"auth/pkg/netguard"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/mail"
+	"net/url"
+	"regexp"
 	"slices"
+	"time"
+)
+
+var (
+	tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8}){0,3}$`)
 )
 
 func UserReq(userID int, name, role string) error {
@@ -55,13 +65,208 @@ func Credentials(name, email, password, role string) error {
 	return nil
 }
 
+// Validates user created by admin, empty password is generated by service
+func NewUser(name, email, password, role string) error {
+	if password == "" {
+		password = "temporaryPassword"
+	}
+	return Credentials(name, email, password, role)
+}
+
 func Role(role string) error {
 	if len(role) == 0 {
 		return errors.New("user role field is reqired")
 	}
 
-	if slices.Contains([]string{models.AdminRole, models.UserRole}, role) {
+	if slices.Contains([]string{models.AdminRole, models.UserRole, models.ServiceRole}, role) {
 		return nil
 	}
 	return fmt.Errorf("%w: %s", models.ErrInvalidRole, role)
 }
+
+func Tenant(tenantID string) error {
+	if len(tenantID) == 0 {
+		return models.ErrTenantRequired
+	}
+
+	if !tenantPattern.MatchString(tenantID) {
+		return errors.New("tenant id must contain only lowercase letters, digits, '-' or '_' and be at most 64 bytes")
+	}
+	return nil
+}
+
+func TenantSettings(accessTTL, refreshTTL time.Duration, policy *models.PasswordPolicy) error {
+	if accessTTL < 0 || refreshTTL < 0 {
+		return errors.New("token TTL must not be negative")
+	}
+
+	if accessTTL > 0 && refreshTTL > 0 && accessTTL > refreshTTL {
+		return errors.New("access token TTL must not exceed refresh token TTL")
+	}
+
+	if policy != nil && (policy.MinLength < 0 || policy.MinLength > 72) {
+		return errors.New("password min length must be in range of 0 and 72")
+	}
+	return nil
+}
+
+func OrgName(name string) error {
+	if len(name) == 0 {
+		return models.ErrEmptyName
+	}
+
+	if len(name) < 2 || len(name) > 128 {
+		return models.ErrInvalidName
+	}
+	return nil
+}
+
+// Validates invitation: owner role can't be granted, organization has exactly one owner
+func Invitation(email, role string) error {
+	if _, err := mail.ParseAddress(email); err != nil || len(email) > 255 {
+		return models.ErrInvalidEmail
+	}
+
+	return OrgRole(role)
+}
+
+func OrgRole(role string) error {
+	if len(role) == 0 {
+		return errors.New("member role field is reqired")
+	}
+
+	if slices.Contains([]string{models.OrgAdminRole, models.OrgMemberRole}, role) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", models.ErrInvalidRole, role)
+}
+
+func UserFilter(filter models.UserFilter) error {
+	if filter.Role != "" {
+		if err := Role(filter.Role); err != nil {
+			return err
+		}
+	}
+
+	if filter.Status != "" && !slices.Contains([]string{models.StatusActive, models.StatusDisabled, models.StatusPendingDeletion, models.StatusDeleted}, filter.Status) {
+		return fmt.Errorf("status is not valid: %s", filter.Status)
+	}
+
+	if filter.SortBy != "" && !slices.Contains([]string{models.SortByCreatedAt, models.SortByName, models.SortByEmail}, filter.SortBy) {
+		return fmt.Errorf("sort field is not valid: %s", filter.SortBy)
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return errors.New("created_from must be before created_to")
+	}
+
+	if filter.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+
+	if len(filter.Query) > 255 {
+		return errors.New("search query is too long")
+	}
+	return nil
+}
+
+// Validates own profile update, empty avatar, locale and timezone reset the field
+func Profile(update models.ProfileUpdate) error {
+	if !update.HasFields() && update.Email == nil {
+		return errors.New("nothing to update")
+	}
+
+	if update.Name != nil {
+		if len(*update.Name) == 0 {
+			return models.ErrEmptyName
+		}
+		if len(*update.Name) < 4 || len(*update.Name) > 72 {
+			return models.ErrInvalidName
+		}
+	}
+
+	if update.Email != nil {
+		if _, err := mail.ParseAddress(*update.Email); err != nil || len(*update.Email) > 255 {
+			return models.ErrInvalidEmail
+		}
+	}
+
+	if update.AvatarURL != nil && *update.AvatarURL != "" {
+		avatar, err := url.Parse(*update.AvatarURL)
+		if err != nil || (avatar.Scheme != "http" && avatar.Scheme != "https") || avatar.Host == "" || len(*update.AvatarURL) > 2048 {
+			return errors.New("avatar URL must be absolute http(s) URL of at most 2048 bytes")
+		}
+	}
+
+	if update.Locale != nil && *update.Locale != "" && !localePattern.MatchString(*update.Locale) {
+		return fmt.Errorf("locale is not valid BCP 47 tag: %s", *update.Locale)
+	}
+
+	if update.Timezone != nil && *update.Timezone != "" {
+		if _, err := time.LoadLocation(*update.Timezone); err != nil || *update.Timezone == "Local" {
+			return fmt.Errorf("timezone is not valid IANA time zone: %s", *update.Timezone)
+		}
+	}
+
+	if update.Metadata != nil {
+		var object map[string]any
+		if err := json.Unmarshal(update.Metadata, &object); err != nil || object == nil {
+			return errors.New("metadata must be JSON object")
+		}
+		if len(update.Metadata) > models.MaxProfileMetadata {
+			return fmt.Errorf("metadata must be at most %d bytes", models.MaxProfileMetadata)
+		}
+	}
+	return nil
+}
+
+func AuditFilter(filter models.AuditFilter) error {
+	if filter.Action != "" && !slices.Contains(models.AuditActions, filter.Action) {
+		return fmt.Errorf("action is not valid: %s", filter.Action)
+	}
+
+	if filter.UserID < 0 {
+		return errors.New("user id must not be negative")
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return errors.New("from must be before to")
+	}
+
+	if filter.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+	return nil
+}
+
+// Validates webhook endpoint and subscribed event types, empty events subscribe to all types
+func Webhook(endpoint string, events []string) error {
+	target, err := url.Parse(endpoint)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" || len(endpoint) > 2048 {
+		return errors.New("webhook URL must be absolute http(s) URL of at most 2048 bytes")
+	}
+	// Имена, указывающие во внутреннюю сеть, отклоняются при отправке
+	if netguard.InternalHost(target.Hostname()) {
+		return errors.New("webhook URL must not point to localhost or internal network")
+	}
+	return EventTypes(events)
+}
+
+func EventTypes(events []string) error {
+	for i, event := range events {
+		if !slices.Contains(models.EventTypes, event) {
+			return fmt.Errorf("event type is not valid: %s", event)
+		}
+		if slices.Contains(events[:i], event) {
+			return fmt.Errorf("event type is duplicated: %s", event)
+		}
+	}
+	return nil
+}
+
+func DeliveryStatus(status string) error {
+	if status != "" && status != models.DeliveryPending && status != models.DeliveryDelivered && status != models.DeliveryDead {
+		return fmt.Errorf("delivery status is not valid: %s", status)
+	}
+	return nil
+}
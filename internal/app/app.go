@@ -2,57 +2,157 @@ package app
 
 import (
 	"auth/config"
+	"auth/internal/adapters/notify"
 	"auth/internal/adapters/repo"
 	grpcserver "auth/internal/adapters/transport/grpc"
 	httpserver "auth/internal/adapters/transport/http"
+	"auth/internal/domain/models"
 	"auth/internal/service"
+	"auth/migrations"
 	"auth/pkg/logger"
 	"auth/pkg/postgres"
+	"auth/pkg/tracing"
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-const serviceName = "auth"
+const (
+	serviceName        = "auth"
+	tracesFlushTimeout = 5 * time.Second // Spans left in the batch are exported after the servers and jobs stop
+)
 
 type App struct {
 	httpServer *httpserver.API
 	postgresDB *postgres.PostgreDB
 	grpcServer *grpcserver.API
+	health     *service.HealthService
+	events     *service.EventService
+	purger     *service.Purger
+	dispatcher *service.Dispatcher
+	jobs       sync.WaitGroup
+	stopJobs   context.CancelFunc
+	shutdown   config.Shutdown
+	tracing    func(context.Context) error
 }
 
 func New(cfg config.Config, log *slog.Logger) (*App, error) {
 	log.Info(fmt.Sprintf("Starting %s service", serviceName))
 
-	log.Info("Starting database connection")
-	postgresDB, err := postgres.Connect(cfg.Db, cfg.App.Admin)
+	stopTracing, err := tracing.Setup(context.Background(), cfg.Tracing, serviceName)
 	if err != nil {
 		return nil, err
 	}
-	log.Info("connection established")
 
-	userDal := repo.NewUserDal(postgresDB.DB)
+	postgresDB, err := connect(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	prometheus.MustRegister(collectors.NewDBStatsCollector(postgresDB.DB, cfg.Db.Name))
+
+	timeouts := repo.Timeouts{Query: cfg.Db.QueryTimeout, Stream: cfg.Db.StreamTimeout}
+	userDal := repo.NewUserDal(postgresDB.DB, timeouts)
+	tenantDal := repo.NewTenantDal(postgresDB.DB, timeouts)
+	orgDal := repo.NewOrgDal(postgresDB.DB, timeouts)
+	auditDal := repo.NewAuditDal(postgresDB.DB, timeouts)
+	loginDal := repo.NewLoginDal(postgresDB.DB, timeouts)
+	webhookDal := repo.NewWebhookDal(postgresDB.DB, timeouts)
+	eventDal := repo.NewEventDal(postgresDB.DB, timeouts)
+	keyDal := repo.NewKeyDal(postgresDB.DB, timeouts)
+	notifier := notify.NewLogNotifier(log)
+
+	passwordPolicy := models.PasswordPolicy{
+		MinLength:     cfg.App.Password.MinLength,
+		RequireDigit:  cfg.App.Password.RequireDigit,
+		RequireUpper:  cfg.App.Password.RequireUpper,
+		RequireSymbol: cfg.App.Password.RequireSymbol,
+	}
 
-	tokenServ := service.NewTokenService(cfg.App.Secret, userDal, cfg.App.RefreshTTL, cfg.App.AccessTTL, log)
-	authServ := service.NewAuthService(userDal, tokenServ, log)
-	adminServ := service.NewAdminService(userDal, tokenServ, log)
+	tokenServ := service.NewTokenService(cfg.App.Secret, keyDal, userDal, tenantDal, cfg.App.RefreshTTL, cfg.App.AccessTTL, log)
+	auditServ := service.NewAuditService(auditDal, tokenServ, log)
+	loginServ := service.NewLoginService(loginDal, tokenServ, notifier, log)
+	authServ := service.NewAuthService(userDal, tenantDal, tokenServ, auditServ, loginServ, passwordPolicy, log)
+	adminServ := service.NewAdminService(userDal, tenantDal, authServ, tokenServ, auditServ, log)
+	exportServ := service.NewExportService(userDal, orgDal, loginDal, tokenServ, log)
+	orgServ := service.NewOrgService(orgDal, userDal, authServ, tokenServ, auditServ, notifier, cfg.App.Invite.URL, cfg.App.Invite.TTL, log)
+	profileServ := service.NewProfileService(userDal, tokenServ, notifier, cfg.App.Email.URL, cfg.App.Email.TTL, log)
+	webhookServ := service.NewWebhookService(webhookDal, tokenServ, auditServ, log)
+	eventServ := service.NewEventService(eventDal, tokenServ, log)
+
+	// Готовность зависит от БД, примененных миграций и загруженных ключей подписи
+	migrator, err := postgres.NewMigrator(postgresDB.DB, migrations.FS, log)
+	if err != nil {
+		return nil, err
+	}
+	healthServ := service.NewHealthService(log,
+		service.HealthCheck{Name: "database", Check: postgresDB.DB.PingContext},
+		service.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := migrator.Pending(ctx)
+			if err == nil && pending > 0 {
+				err = fmt.Errorf("%d migrations are not applied", pending)
+			}
+			return err
+		}},
+		service.HealthCheck{Name: "signing_keys", Check: func(context.Context) error {
+			return tokenServ.KeysLoaded()
+		}},
+	)
+
+	operatorServ := service.NewOperatorService(userDal, tenantDal, keyDal, authServ, auditServ, log)
+	purger := service.NewPurger(userDal, cfg.App.Retention.Period, cfg.App.Retention.PurgeInterval, log)
+	dispatcher := service.NewDispatcher(webhookDal, cfg.App.Webhook.Interval, cfg.App.Webhook.Timeout, cfg.App.Webhook.MaxAttempts,
+		cfg.App.Webhook.Backoff, cfg.App.Webhook.Retention, cfg.App.Webhook.AllowPrivate, log)
+
+	admin := cfg.App.Admin
+	if err := operatorServ.BootstrapAdmin(context.Background(), models.User{TenantID: admin.Tenant, Name: admin.Name, Email: admin.Email}, admin.Password); err != nil {
+		return nil, fmt.Errorf("failed to create admin: %w", err)
+	}
 
-	httpServ := httpserver.New(cfg.HttpServer, authServ, adminServ, tokenServ, log)
-	grpcServ := grpcserver.New(cfg.GrpcServer, authServ, adminServ, tokenServ, log)
+	httpServ, err := httpserver.New(cfg.HttpServer, authServ, adminServ, orgServ, exportServ, profileServ, loginServ, auditServ, webhookServ, healthServ, tokenServ, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup http server: %w", err)
+	}
+	grpcServ, err := grpcserver.New(cfg.GrpcServer, authServ, adminServ, orgServ, profileServ, loginServ, auditServ, webhookServ, eventServ, healthServ, tokenServ, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup grpc server: %w", err)
+	}
 
 	return &App{
 		httpServer: httpServ,
 		grpcServer: grpcServ,
 		postgresDB: postgresDB,
+		health:     healthServ,
+		events:     eventServ,
+		purger:     purger,
+		dispatcher: dispatcher,
+		shutdown:   cfg.App.Shutdown,
+		tracing:    stopTracing,
 	}, nil
 }
 
 func (a *App) Start(log *slog.Logger) {
 	errs := make(chan error, 2)
 
+	// Удаляем данные пользователей после окончания срока хранения и доставляем события в вебхуки
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopJobs = cancel
+	for _, job := range []func(context.Context){a.purger.Run, a.dispatcher.Run} {
+		a.jobs.Add(1)
+		go func() {
+			defer a.jobs.Done()
+			job(ctx)
+		}()
+	}
+
 	go func() {
 		if err := a.httpServer.StartServer(); err != nil && err != http.ErrServerClosed {
 			errs <- fmt.Errorf("http server: %w", err)
@@ -65,6 +165,8 @@ func (a *App) Start(log *slog.Logger) {
 		}
 	}()
 
+	a.health.SetReady(true)
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
@@ -78,14 +180,60 @@ func (a *App) Start(log *slog.Logger) {
 	log.Info("Shutting down...")
 }
 
-func (a *App) CleanUp(log *slog.Logger) {
-	if err := a.httpServer.Close(); err != nil {
-		log.Error("Failed to close http server conn", logger.Err(err))
+// Stops the service in order: readiness is turned off, servers stop accepting and drain in-flight
+// requests, background jobs stop, and only then the database is closed. Servers still busy
+// after the shutdown timeout are stopped forcibly
+func (a *App) Shutdown(log *slog.Logger) {
+	// Снимаем готовность и даем балансировщику время убрать инстанс
+	a.health.SetReady(false)
+	if a.shutdown.Delay > 0 {
+		log.Info("Waiting for load balancers to stop routing", "delay", a.shutdown.Delay)
+		time.Sleep(a.shutdown.Delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdown.Timeout)
+	defer cancel()
+
+	// Подписки на события сами не завершаются, закрываем их до ожидания сервера
+	a.events.Stop()
+
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := a.httpServer.Shutdown(ctx); err != nil {
+			log.Error("Failed to close http server conn", logger.Err(err))
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		a.grpcServer.Shutdown(ctx)
+	}()
+	servers.Wait()
+
+	// Фоновые задачи прерываются, незавершенные доставки повторятся после аренды
+	if a.stopJobs != nil {
+		a.stopJobs()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		a.jobs.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Warn("Background jobs did not stop before shutdown timeout")
 	}
 
 	if err := a.postgresDB.DB.Close(); err != nil {
 		log.Error("Failed to close database conn", logger.Err(err))
 	}
 
-	a.grpcServer.Close()
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracesFlushTimeout)
+	defer cancelFlush()
+	if err := a.tracing(flushCtx); err != nil {
+		log.Error("Failed to flush traces", logger.Err(err))
+	}
+	log.Info("Service stopped")
 }
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"auth/config"
+	"auth/internal/adapters/notify"
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/migrations"
+	"auth/pkg/postgres"
+	"log/slog"
+)
+
+// Builds operator service for the command line. Caller closes the returned database
+func NewOperator(cfg config.Config, log *slog.Logger) (*service.OperatorService, *postgres.PostgreDB, error) {
+	postgresDB, err := connect(cfg, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeouts := repo.Timeouts{Query: cfg.Db.QueryTimeout, Stream: cfg.Db.StreamTimeout}
+	userDal := repo.NewUserDal(postgresDB.DB, timeouts)
+	tenantDal := repo.NewTenantDal(postgresDB.DB, timeouts)
+	keyDal := repo.NewKeyDal(postgresDB.DB, timeouts)
+
+	passwordPolicy := models.PasswordPolicy{
+		MinLength:     cfg.App.Password.MinLength,
+		RequireDigit:  cfg.App.Password.RequireDigit,
+		RequireUpper:  cfg.App.Password.RequireUpper,
+		RequireSymbol: cfg.App.Password.RequireSymbol,
+	}
+
+	tokenServ := service.NewTokenService(cfg.App.Secret, keyDal, userDal, tenantDal, cfg.App.RefreshTTL, cfg.App.AccessTTL, log)
+	auditServ := service.NewAuditService(repo.NewAuditDal(postgresDB.DB, timeouts), tokenServ, log)
+	loginServ := service.NewLoginService(repo.NewLoginDal(postgresDB.DB, timeouts), tokenServ, notify.NewLogNotifier(log), log)
+	authServ := service.NewAuthService(userDal, tenantDal, tokenServ, auditServ, loginServ, passwordPolicy, log)
+
+	return service.NewOperatorService(userDal, tenantDal, keyDal, authServ, auditServ, log), postgresDB, nil
+}
+
+// Connects to the database and applies pending migrations if enabled
+func connect(cfg config.Config, log *slog.Logger) (*postgres.PostgreDB, error) {
+	log.Info("Starting database connection")
+	postgresDB, err := postgres.Connect(cfg.Db)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("connection established")
+
+	if cfg.Db.AutoMigrate {
+		migrator, err := postgres.NewMigrator(postgresDB.DB, migrations.FS, log)
+		if err != nil {
+			return nil, err
+		}
+		applied, err := migrator.Up()
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Database schema is up to date", "applied", applied)
+	}
+	return postgresDB, nil
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audited actions
+var (
+	AuditLogin        string = "login"
+	AuditRegister     string = "register"
+	AuditRefresh      string = "refresh"
+	AuditUserCreate   string = "user.create"
+	AuditUserImport   string = "user.import"
+	AuditUserUpdate   string = "user.update"
+	AuditUserDelete   string = "user.delete"
+	AuditUserDisable  string = "user.disable"
+	AuditUserEnable   string = "user.enable"
+	AuditUserRestore  string = "user.restore"
+	AuditUserPassword string = "user.password_reset"
+	AuditTenantUpdate string = "tenant.update"
+	AuditOrgInvite    string = "org.invite"
+	AuditOrgRevoke    string = "org.invitation_revoke"
+	AuditOrgRole      string = "org.member_role"
+	AuditWebhookAdd   string = "webhook.create"
+	AuditWebhookDel   string = "webhook.delete"
+	AuditWebhookRetry string = "webhook.delivery_retry"
+)
+
+var AuditActions = []string{AuditLogin, AuditRegister, AuditRefresh, AuditUserCreate, AuditUserImport, AuditUserUpdate,
+	AuditUserDelete, AuditUserDisable, AuditUserEnable, AuditUserRestore, AuditUserPassword, AuditTenantUpdate,
+	AuditOrgInvite, AuditOrgRevoke, AuditOrgRole, AuditWebhookAdd, AuditWebhookDel, AuditWebhookRetry}
+
+// Number of hash chains per tenant. Writers of different chains don't wait for each other
+const AuditChains = 16
+
+// Outcomes of audited actions
+var (
+	OutcomeSuccess string = "success"
+	OutcomeFailure string = "failure"
+)
+
+// Client info of the request which caused the event
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	DeviceID  string // Optional stable id of the client device
+}
+
+// Append-only audit log entry. Entries of a tenant form AuditChains hash chains:
+// each hash covers the entry and the hash of the previous one in its chain
+type AuditEntry struct {
+	ID         int64     `json:"ID"`
+	TenantID   string    `json:"tenant_id"`
+	Chain      int       `json:"chain"`
+	ActorID    int       `json:"actor_id,omitempty"`  // Zero when actor is not authenticated
+	TargetID   int       `json:"target_id,omitempty"` // Affected user, zero when unknown
+	Action     string    `json:"action"`
+	Outcome    string    `json:"outcome"`
+	Details    string    `json:"details,omitempty"` // Failure reason or action summary
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Created_At time.Time `json:"created_at"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// Computes hash of the entry chained to PrevHash
+func (e AuditEntry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		e.TenantID,
+		strconv.Itoa(e.ActorID),
+		strconv.Itoa(e.TargetID),
+		e.Action,
+		e.Outcome,
+		e.Details,
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.Created_At.UTC().Format(time.RFC3339Nano),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Audit log filter, zero values are not applied
+type AuditFilter struct {
+	TenantID string
+	UserID   int // Actor or target of the event
+	Action   string
+	From     time.Time
+	To       time.Time
+	Cursor   string // Opaque cursor from the previous page
+	Limit    int
+}
+
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// Result of the tenant audit chain check
+type AuditVerification struct {
+	Checked  int   `json:"checked"`
+	Valid    bool  `json:"valid"`
+	BrokenID int64 `json:"broken_id,omitempty"` // First entry whose hash does not match
+}
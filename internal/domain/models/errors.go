@@ -3,20 +3,35 @@ package models
 import "errors"
 
 var (
-	ErrEmptyPassword      = errors.New("password field is required")
-	ErrEmptyName          = errors.New("name field is required")
-	ErrInvalidCredentials = errors.New("credentials are invalid")
-	ErrInvalidToken       = errors.New("token is invalid")
-	ErrInvalidEmail       = errors.New("email is invalid")
-	ErrExpToken           = errors.New("token expired")
-	ErrNotUniqueEmail     = errors.New("email is not unique")
-	ErrInvalidPassword    = errors.New("password must be in range of 8 and 72 bytes")
-	ErrInvalidRole        = errors.New("role is not valid")
-	ErrInvalidName        = errors.New("name must be in range of 4 and 72 bytes")
-	ErrPermissionDenied   = errors.New("permission denied")
-	ErrUnexpected         = errors.New("internal server error: failed to handle incoming HTTP request")
-	ErrTokenGenerateFail  = errors.New("failed to generate new token")
-	ErrUserModelInvalid   = errors.New("user model is invalid")
-	ErrCannotDeleteSelf   = errors.New("you cannot delete your own account while logged in as admin")
-	ErrCannotCreateAdmin  = errors.New("admin can be created only with CLI")
+	ErrEmptyPassword          = errors.New("password field is required")
+	ErrEmptyName              = errors.New("name field is required")
+	ErrInvalidCredentials     = errors.New("credentials are invalid")
+	ErrInvalidToken           = errors.New("token is invalid")
+	ErrInvalidEmail           = errors.New("email is invalid")
+	ErrExpToken               = errors.New("token expired")
+	ErrNotUniqueEmail         = errors.New("email is not unique")
+	ErrInvalidPassword        = errors.New("password must be in range of 8 and 72 bytes")
+	ErrInvalidRole            = errors.New("role is not valid")
+	ErrInvalidName            = errors.New("name must be in range of 4 and 72 bytes")
+	ErrPermissionDenied       = errors.New("permission denied")
+	ErrUnexpected             = errors.New("internal server error: failed to handle incoming HTTP request")
+	ErrTokenGenerateFail      = errors.New("failed to generate new token")
+	ErrUserModelInvalid       = errors.New("user model is invalid")
+	ErrCannotDeleteSelf       = errors.New("you cannot delete your own account while logged in as admin")
+	ErrCannotCreateAdmin      = errors.New("admin can be created only with CLI")
+	ErrTenantRequired         = errors.New("tenant id is required")
+	ErrWeakPassword           = errors.New("password does not satisfy tenant password policy")
+	ErrInvitationInvalid      = errors.New("invitation is invalid, expired or already used")
+	ErrInvitationEmail        = errors.New("invitation was sent to another email")
+	ErrAlreadyMember          = errors.New("user is already a member of organization")
+	ErrCannotChangeOwner      = errors.New("organization owner role cannot be changed")
+	ErrInvalidCursor          = errors.New("pagination cursor is invalid")
+	ErrPasswordChangeRequired = errors.New("password must be changed before login")
+	ErrSamePassword           = errors.New("new password must differ from the old one")
+	ErrUserInactive           = errors.New("user account is not active")
+	ErrStatusTransition       = errors.New("action is not allowed for current user status")
+	ErrCannotDisableSelf      = errors.New("you cannot disable your own account")
+	ErrCursorExpired          = errors.New("cursor is older than retained events")
+	ErrLastAdmin              = errors.New("tenant must keep at least one active admin")
+	ErrNotReady               = errors.New("service is not ready")
 )
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of user lifecycle events
+var (
+	EventUserRegistered  string = "user.registered"
+	EventUserUpdated     string = "user.updated"
+	EventUserRoleChanged string = "user.role_changed"
+	EventUserDeleted     string = "user.deleted"
+	EventUserDisabled    string = "user.disabled"
+	EventUserEnabled     string = "user.enabled"
+	EventUserRestored    string = "user.restored"
+	EventUserPurged      string = "user.purged" // Personal data is erased after retention, payload has the anonymized user
+)
+
+var EventTypes = []string{EventUserRegistered, EventUserUpdated, EventUserRoleChanged, EventUserDeleted,
+	EventUserDisabled, EventUserEnabled, EventUserRestored, EventUserPurged}
+
+// Event about the user emitted by a write. Payload is built from the user row
+// in the same transaction, so the event is stored only if the write is committed
+type UserEvent struct {
+	Type  string
+	Extra map[string]string // Additional payload fields, e.g. previous role
+}
+
+// Event stored in the outbox and delivered to webhooks
+type Event struct {
+	ID         int64           `json:"id"`
+	TenantID   string          `json:"tenant_id"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	Created_At time.Time       `json:"created_at"`
+}
+
+// Endpoint registered by the tenant administrator. Secret is returned only on creation
+type Webhook struct {
+	ID         int       `json:"ID"`
+	TenantID   string    `json:"tenant_id"`
+	URL        string    `json:"url"`
+	Events     []string  `json:"events"` // Empty means all event types
+	Secret     string    `json:"secret,omitempty"`
+	Created_At time.Time `json:"created_at"`
+}
+
+// Delivery statuses
+var (
+	DeliveryPending   string = "pending"
+	DeliveryDelivered string = "delivered"
+	DeliveryDead      string = "dead"
+)
+
+// Delivery of the event to the webhook
+type WebhookDelivery struct {
+	ID            int64     `json:"ID"`
+	WebhookID     int       `json:"webhook_id"`
+	EventID       int64     `json:"event_id"`
+	EventType     string    `json:"event_type"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	Created_At    time.Time `json:"created_at"`
+	Updated_At    time.Time `json:"updated_at"`
+
+	// Filled for dispatching only
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+	Event  Event  `json:"-"`
+}
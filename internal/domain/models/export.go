@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Archive of the personal data stored about the user. Password hash is never included.
+// Empty sections are always present so consumers can rely on the archive layout.
+type UserExport struct {
+	ExportedAt    time.Time     `json:"exported_at"`
+	Profile       User          `json:"profile"`
+	Organizations []Membership  `json:"organizations"`
+	LoginHistory  []LoginRecord `json:"login_history"`
+}
+
+// Formats of admin users export
+var (
+	ExportCSV    string = "csv"
+	ExportNDJSON string = "ndjson"
+)
@@ -0,0 +1,19 @@
+package models
+
+// Readiness statuses
+const (
+	HealthReady    = "ready"
+	HealthNotReady = "not_ready"
+)
+
+// Results of a readiness check. Errors are only logged, the probe is public
+const (
+	HealthCheckOK   = "ok"
+	HealthCheckFail = "fail"
+)
+
+// Result of the readiness probe
+type HealthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"` // Check name to HealthCheckOK or HealthCheckFail
+}
@@ -0,0 +1,28 @@
+package models
+
+// Row of users bulk import
+type ImportRow struct {
+	Line     int
+	Name     string
+	Email    string
+	Password string // Empty password is replaced with a temporary one
+	Role     string
+	Err      error // Validation error found while parsing
+}
+
+type ImportResult struct {
+	Line         int    `json:"line"`
+	Email        string `json:"email"`
+	ID           int    `json:"ID,omitempty"`
+	TempPassword string `json:"temp_password,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Atomic import saves either all rows or none of them
+type ImportReport struct {
+	Total   int            `json:"total"`
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Atomic  bool           `json:"atomic"`
+	Results []ImportResult `json:"results"`
+}
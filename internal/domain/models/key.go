@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// HMAC key signing tokens. Its ID is put into the token `kid` header to pick the key on validation
+type SigningKey struct {
+	ID         string
+	Secret     []byte
+	Created_At time.Time
+	Retires_At *time.Time // Nil for the current key, retired keys only verify tokens until this time
+}
+
+// ID of the key row that retires the configured SECRET. It is written by the first rotation and has no secret,
+// tokens without kid are verified until its Retires_At
+const SecretKeyID = ""
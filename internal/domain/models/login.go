@@ -0,0 +1,36 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Login attempt of an existing user
+type LoginRecord struct {
+	ID          int64     `json:"ID"`
+	TenantID    string    `json:"-"`
+	UserID      int       `json:"-"`
+	IP          string    `json:"ip"`
+	UserAgent   string    `json:"user_agent"`
+	Fingerprint string    `json:"device"`
+	Success     bool      `json:"success"`
+	Reason      string    `json:"reason,omitempty"` // Failure reason
+	Created_At  time.Time `json:"created_at"`
+}
+
+type LoginPage struct {
+	Logins     []LoginRecord `json:"logins"`
+	NextCursor string        `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// Identifies client device by the device id sent by the client or by its user agent.
+// IP is not used: it changes between networks of the same device
+func (m RequestMeta) Fingerprint() string {
+	source := "ua:" + m.UserAgent
+	if m.DeviceID != "" {
+		source = "id:" + m.DeviceID
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Organization-level roles
+var (
+	OrgOwnerRole  string = "owner"
+	OrgAdminRole  string = "admin"
+	OrgMemberRole string = "member"
+)
+
+// Organization is a team of users inside one tenant
+type Organization struct {
+	ID         int       `json:"ID"`
+	TenantID   string    `json:"tenant_id"`
+	Name       string    `json:"name"`
+	OwnerID    int       `json:"owner_id"`
+	Created_At time.Time `json:"created_at"`
+}
+
+type Membership struct {
+	OrgID     int       `json:"org_id"`
+	OrgName   string    `json:"org_name,omitempty"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Joined_At time.Time `json:"joined_at"`
+}
+
+type Invitation struct {
+	ID          int        `json:"ID"`
+	OrgID       int        `json:"org_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedBy   int        `json:"invited_by"`
+	Created_At  time.Time  `json:"created_at"`
+	Expires_At  time.Time  `json:"expires_at"`
+	Accepted_At *time.Time `json:"accepted_at,omitempty"`
+	Revoked_At  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Pending invitation can still be accepted
+func (i Invitation) IsPending() bool {
+	return i.Accepted_At == nil && i.Revoked_At == nil && time.Now().Before(i.Expires_At)
+}
+
+// Owners and admins manage organization members and invitations
+func (m Membership) CanManage() bool {
+	return m.Role == OrgOwnerRole || m.Role == OrgAdminRole
+}
@@ -0,0 +1,22 @@
+package models
+
+import "encoding/json"
+
+// Max size of profile metadata JSON
+const MaxProfileMetadata = 4096
+
+// Update of the own profile, nil fields are left unchanged.
+// Email is changed only after confirmation link sent to the new address is opened
+type ProfileUpdate struct {
+	Name      *string
+	AvatarURL *string
+	Locale    *string
+	Timezone  *string
+	Metadata  json.RawMessage
+	Email     *string
+}
+
+// Reports whether update changes any profile field except email
+func (u ProfileUpdate) HasFields() bool {
+	return u.Name != nil || u.AvatarURL != nil || u.Locale != nil || u.Timezone != nil || u.Metadata != nil
+}
@@ -3,4 +3,6 @@ package models
 var (
 	UserRole  string = "user"
 	AdminRole string = "admin"
+	// Service accounts of internal consumers, e.g. user event subscribers
+	ServiceRole string = "service"
 )
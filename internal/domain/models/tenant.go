@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+	"unicode"
+)
+
+// Tenant is an isolated group of users sharing one deployment.
+// Zero TTLs and a nil password policy mean the global AppConf values are used.
+type Tenant struct {
+	ID         string          `json:"ID"`
+	Name       string          `json:"name"`
+	AccessTTL  time.Duration   `json:"access_ttl,omitempty"`
+	RefreshTTL time.Duration   `json:"refresh_ttl,omitempty"`
+	Password   *PasswordPolicy `json:"password_policy,omitempty"`
+	Created_At time.Time       `json:"created_at"`
+	Updated_At time.Time       `json:"updated_at,omitempty"`
+}
+
+// Effective tenant settings after applying overrides on top of global config
+type TenantSettings struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	Password   PasswordPolicy
+}
+
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+// Returns tenant settings with tenant overrides applied to defaults
+func (t Tenant) Settings(defaults TenantSettings) TenantSettings {
+	settings := defaults
+	if t.AccessTTL > 0 {
+		settings.AccessTTL = t.AccessTTL
+	}
+	if t.RefreshTTL > 0 {
+		settings.RefreshTTL = t.RefreshTTL
+	}
+	if t.Password != nil {
+		settings.Password = *t.Password
+	}
+	return settings
+}
+
+// Checks password against the policy.
+// Length bounds of bcrypt (8..72 bytes) are validated on transport level, policy can only tighten them.
+func (p PasswordPolicy) Check(password string) error {
+	if len(password) < p.MinLength {
+		return ErrWeakPassword
+	}
+
+	var hasDigit, hasUpper, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if (p.RequireDigit && !hasDigit) || (p.RequireUpper && !hasUpper) || (p.RequireSymbol && !hasSymbol) {
+		return ErrWeakPassword
+	}
+	return nil
+}
@@ -9,6 +9,14 @@ import (
 const (
 	Refresh = "refresh_token"
 	Access  = "access_token"
+	CSRF    = "csrf_token"
+)
+
+// Purposes of single-action tokens
+const (
+	InvitationPurpose  = "invitation"
+	EmailChangePurpose = "email_change"
+	CSRFPurpose        = "csrf"
 )
 
 type TokenPair struct {
@@ -16,15 +24,18 @@ type TokenPair struct {
 	AccessExpiresAt  time.Time
 	RefreshToken     string
 	RefreshExpiresAt time.Time
+	CSRFToken        string // Confirms unsafe requests of the cookie session
 }
 
 type CustomClaims struct {
 	ID        int    `json:"ID"`
+	TenantID  string `json:"tenant_id"`
 	Name      string `json:"name"`
 	Email     string `json:"email"`
 	Password  string `json:"password"`
 	IsAdmin   bool   `json:"is_admin"`
 	Role      string `json:"role"`
 	IsRefresh bool   `json:"is_refresh"`
+	SessionID string `json:"sid"` // Shared by the access and refresh tokens of one issue and their CSRF token
 	jwt.RegisteredClaims
 }
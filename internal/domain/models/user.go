@@ -1,9 +1,13 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	ID         int       `json:"ID"`
+	TenantID   string    `json:"tenant_id"`
 	Name       string    `json:"name"`
 	Email      string    `json:"email"`
 	password   string    `json:"-"`
@@ -11,6 +15,51 @@ type User struct {
 	Updated_At time.Time `json:"updated_at,omitempty"`
 	IsAdmin    bool      `json:"is_admin"`
 	Role       string    `json:"role"`
+	Status     string    `json:"status"`
+
+	MustChangePassword bool `json:"must_change_password"` // Set for temporary passwords issued by admin
+
+	// Profile fields editable by the user
+	AvatarURL string          `json:"avatar_url"`
+	Locale    string          `json:"locale"`   // BCP 47 language tag, e.g. "en-US"
+	Timezone  string          `json:"timezone"` // IANA time zone, e.g. "Europe/Berlin"
+	Metadata  json.RawMessage `json:"metadata"` // Arbitrary JSON object
+}
+
+// User account statuses. Pending deletion account can be restored until
+// the retention window passes, then it is removed. Owners of organizations stay anonymized as deleted.
+var (
+	StatusActive          string = "active"
+	StatusDisabled        string = "disabled"
+	StatusPendingDeletion string = "pending_deletion"
+	StatusDeleted         string = "deleted"
+)
+
+// Sort fields of users listing
+var (
+	SortByCreatedAt string = "created_at"
+	SortByName      string = "name"
+	SortByEmail     string = "email"
+)
+
+// Users listing filter, zero values are not applied
+type UserFilter struct {
+	TenantID    string
+	Role        string
+	Query       string // Case-insensitive substring of email or name
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	SortBy      string
+	Desc        bool
+	Cursor      string // Opaque cursor from the previous page
+	Limit       int
+}
+
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"` // Empty on the last page
+	Total      int    `json:"total"`                 // Count of users matching filter
 }
 
 func (u *User) GetPassword() string {
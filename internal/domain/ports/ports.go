@@ -1,17 +1,95 @@
 package ports
 
-import "auth/internal/domain/models"
+import (
+	"auth/internal/domain/models"
+	"context"
+	"time"
+)
 
 type UserRepo interface {
-	GetUser(email string) (models.User, error)
-	GetUserByID(userID int) (models.User, error)
-	SaveUser(user *models.User) error
-	DeleteUser(userID int) error
-	UpdateUser(name string, role string, userID int) error
+	GetUser(ctx context.Context, tenantID, email string) (models.User, error)
+	GetUserByID(ctx context.Context, tenantID string, userID int) (models.User, error)
+	SaveUser(ctx context.Context, user *models.User, events ...models.UserEvent) error
+	SaveUsers(ctx context.Context, users []*models.User, events ...models.UserEvent) error
+	UpdatePassword(ctx context.Context, tenantID string, userID int, passHash string, mustChange bool) error
+	SetAdmin(ctx context.Context, tenantID string, userID int, isAdmin bool, role string, events ...models.UserEvent) error
+	DeleteUser(ctx context.Context, tenantID string, userID int, events ...models.UserEvent) error
+	UpdateStatus(ctx context.Context, tenantID string, userID int, from []string, to string, events ...models.UserEvent) error
+	UpdateProfile(ctx context.Context, tenantID string, userID int, update models.ProfileUpdate, events ...models.UserEvent) error
+	UpdateEmail(ctx context.Context, tenantID string, userID int, oldEmail, newEmail string, events ...models.UserEvent) error
+	PurgeUsers(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+	UpdateUser(ctx context.Context, tenantID string, name string, role string, userID int, events ...models.UserEvent) error
+	ListUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error)
+	ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error
+}
+
+type TenantRepo interface {
+	GetTenant(ctx context.Context, tenantID string) (models.Tenant, error)
+	UpdateTenant(ctx context.Context, tenant models.Tenant) error
+	CreateTenant(ctx context.Context, tenantID, name string) error
+}
+
+type OrgRepo interface {
+	CreateOrg(ctx context.Context, org *models.Organization) error
+	GetOrg(ctx context.Context, tenantID string, orgID int) (models.Organization, error)
+	GetMembership(ctx context.Context, orgID, userID int) (models.Membership, error)
+	ListMembers(ctx context.Context, orgID int) ([]models.Membership, error)
+	ListUserMemberships(ctx context.Context, userID int) ([]models.Membership, error)
+	AddMember(ctx context.Context, orgID, userID int, role string) error
+	UpdateMemberRole(ctx context.Context, orgID, userID int, role string) error
+	SaveInvitation(ctx context.Context, inv *models.Invitation) error
+	GetInvitation(ctx context.Context, invitationID int) (models.Invitation, error)
+	ListInvitations(ctx context.Context, orgID int) ([]models.Invitation, error)
+	AcceptInvitation(ctx context.Context, inv models.Invitation, user *models.User, events ...models.UserEvent) error
+	RevokeInvitation(ctx context.Context, orgID, invitationID int) error
+}
+
+// Delivers messages to users (email, messengers, etc.)
+type Notifier interface {
+	SendInvitation(email string, org models.Organization, link string) error
+	SendEmailConfirmation(email, link string) error
+	SendNewDeviceLogin(email string, login models.LoginRecord) error
 }
 
 type TokenService interface {
-	GenerateTokens(user models.User) (models.TokenPair, error)
-	Refresh(refreshToken string) (models.TokenPair, error)
-	Validate(token string) (models.CustomClaims, error)
+	GenerateTokens(ctx context.Context, user models.User) (models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+	Validate(ctx context.Context, token string) (models.CustomClaims, error)
+	Claims(token string) (models.CustomClaims, error)
+	CheckCSRF(token string, claims models.CustomClaims) error
+}
+
+type AuditRepo interface {
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error)
+	WalkAudit(ctx context.Context, tenantID string, fn func(models.AuditEntry) error) error
+}
+
+type LoginRepo interface {
+	AddLogin(ctx context.Context, login *models.LoginRecord) error
+	KnownDevice(ctx context.Context, tenantID string, userID int, fingerprint string) (known bool, firstLogin bool, err error)
+	ListLogins(ctx context.Context, tenantID string, userID int, cursor string, limit int) (models.LoginPage, error)
+}
+
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	ListWebhooks(ctx context.Context, tenantID string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, tenantID string, webhookID int) error
+	ListDeliveries(ctx context.Context, tenantID, status string, limit int) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, tenantID string, deliveryID int64) error
+	FanOutEvents(ctx context.Context, limit int) (int, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, deliveryID int64) error
+	MarkFailed(ctx context.Context, deliveryID int64, lastErr string, nextAttemptAt time.Time, dead bool) error
+	PurgeEvents(ctx context.Context, dispatchedBefore time.Time) (int, error)
+}
+
+type KeyRepo interface {
+	ListKeys(ctx context.Context) ([]models.SigningKey, error)
+	RotateKey(ctx context.Context, key *models.SigningKey, grace time.Duration) error
+}
+
+type EventRepo interface {
+	ListEvents(ctx context.Context, tenantID string, afterID int64, types []string, limit int) ([]models.Event, error)
+	PurgedThrough(ctx context.Context, tenantID string) (int64, error)
 }
@@ -3,75 +3,103 @@ package service
 import (
 	"auth/internal/adapters/repo"
 	"auth/internal/domain/models"
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
 )
 
+// Users listing page size limits
+const (
+	DefaultUsersPage = 50
+	MaxUsersPage     = 200
+)
+
+// Max rows in one bulk import
+const MaxImportRows = 1000
+
 type AdminService struct {
 	UserDal   *repo.UserDal
+	TenantDal *repo.TenantDal
+	AuthServ  *AuthService
 	TokenServ *TokenService
+	AuditServ *AuditService
 	log       *slog.Logger
 }
 
-func NewAdminService(UserDal *repo.UserDal, TokenServ *TokenService, log *slog.Logger) *AdminService {
+func NewAdminService(UserDal *repo.UserDal, TenantDal *repo.TenantDal, AuthServ *AuthService, TokenServ *TokenService, AuditServ *AuditService, log *slog.Logger) *AdminService {
 	return &AdminService{
 		UserDal:   UserDal,
+		TenantDal: TenantDal,
+		AuthServ:  AuthServ,
 		TokenServ: TokenServ,
+		AuditServ: AuditServ,
 		log:       log,
 	}
 }
 
-func (s *AdminService) GetUser(userID int, access string) (models.User, error) {
+func (s *AdminService) GetUser(ctx context.Context, userID int, access string) (_ models.User, err error) {
 	const op = "AdminService.GetUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Int("ID", userID),
 	)
 
 	// Валидируем токен
-	claims, err := s.TokenServ.Validate(access)
+	claims, err := s.TokenServ.Validate(ctx, access)
 	if err != nil {
-		log.Error("Access token is invalid", "error", err)
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
 		return models.User{}, models.ErrInvalidToken
 	}
 
 	// Проверяем права пользователя
 	if !claims.IsAdmin {
-		log.Error("User is not administrator")
+		log.ErrorContext(ctx, "User is not administrator")
 		return models.User{}, models.ErrPermissionDenied
 	}
 
-	// Получаем user-а
-	existUser, err := s.UserDal.GetUserByID(userID)
+	// Получаем user-а (администратор видит только своего тенанта)
+	existUser, err := s.UserDal.GetUserByID(ctx, claims.TenantID, userID)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return models.User{}, repo.ErrUserNotExist
 		}
-		log.Error("Failed to check user uniqueness", "error", err)
+		log.ErrorContext(ctx, "Failed to check user uniqueness", "error", err)
 		return models.User{}, models.ErrUnexpected
 	}
 
 	return existUser, nil
 }
 
-func (s *AdminService) DeleteUser(userID int, access string) error {
+func (s *AdminService) DeleteUser(ctx context.Context, userID int, access string, meta models.RequestMeta) (err error) {
 	const op = "AdminService.DeleteUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Int("ID", userID),
 	)
 
+	entry := models.AuditEntry{Action: models.AuditUserDelete, TargetID: userID}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
 	// Валидируем токен
-	claims, err := s.TokenServ.Validate(access)
+	claims, err := s.TokenServ.Validate(ctx, access)
 	if err != nil {
-		log.Error("Access token is invalid", "error", err)
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
 		return models.ErrInvalidToken
 	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
 
 	// Проверяем права пользователя
 	if !claims.IsAdmin {
-		log.Error("User is not administrator")
+		log.ErrorContext(ctx, "User is not administrator")
 		return models.ErrPermissionDenied
 	}
 
@@ -79,20 +107,88 @@ func (s *AdminService) DeleteUser(userID int, access string) error {
 		return models.ErrCannotDeleteSelf
 	}
 
-	// Удаляем пользователя
-	if err := s.UserDal.DeleteUser(userID); err != nil {
+	// Помечаем пользователя к удалению, данные удаляются после окончания срока хранения
+	if err := s.UserDal.DeleteUser(ctx, claims.TenantID, userID, models.UserEvent{Type: models.EventUserDeleted}); err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return repo.ErrUserNotExist
 		}
-		log.Error("Failed to delete user data", "error", err)
+		if errors.Is(err, models.ErrStatusTransition) {
+			log.ErrorContext(ctx, "User is already deleted")
+			return models.ErrStatusTransition
+		}
+		log.ErrorContext(ctx, "Failed to delete user data", "error", err)
 		return models.ErrUnexpected
 	}
 	return nil
 }
 
-func (s *AdminService) UpdateUser(user models.User, access string) error {
+// Disables active user, disabled user cannot login or use issued tokens
+func (s *AdminService) DisableUser(ctx context.Context, userID int, access string, meta models.RequestMeta) error {
+	return s.updateStatus(ctx, "AdminService.DisableUser", models.AuditUserDisable, models.EventUserDisabled, userID, access, meta, []string{models.StatusActive}, models.StatusDisabled)
+}
+
+// Enables previously disabled user
+func (s *AdminService) EnableUser(ctx context.Context, userID int, access string, meta models.RequestMeta) error {
+	return s.updateStatus(ctx, "AdminService.EnableUser", models.AuditUserEnable, models.EventUserEnabled, userID, access, meta, []string{models.StatusDisabled}, models.StatusActive)
+}
+
+// Restores user pending deletion until retention window passes
+func (s *AdminService) RestoreUser(ctx context.Context, userID int, access string, meta models.RequestMeta) error {
+	return s.updateStatus(ctx, "AdminService.RestoreUser", models.AuditUserRestore, models.EventUserRestored, userID, access, meta, []string{models.StatusPendingDeletion}, models.StatusActive)
+}
+
+func (s *AdminService) updateStatus(ctx context.Context, op, action, event string, userID int, access string, meta models.RequestMeta, from []string, to string) (err error) {
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("ID", userID),
+	)
+
+	entry := models.AuditEntry{Action: action, TargetID: userID}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.ErrInvalidToken
+	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.ErrPermissionDenied
+	}
+
+	if claims.ID == userID {
+		return models.ErrCannotDisableSelf
+	}
+
+	if err := s.UserDal.UpdateStatus(ctx, claims.TenantID, userID, from, to, models.UserEvent{Type: event}); err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "User is not exist")
+			return repo.ErrUserNotExist
+		}
+		if errors.Is(err, models.ErrStatusTransition) {
+			log.ErrorContext(ctx, "Status transition is not allowed", "to", to)
+			return models.ErrStatusTransition
+		}
+		log.ErrorContext(ctx, "Failed to update user status", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "User status updated", "status", to)
+	return nil
+}
+
+func (s *AdminService) UpdateUser(ctx context.Context, user models.User, access string, meta models.RequestMeta) (err error) {
 	const op = "AdminService.UpdateUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 		slog.Int("ID", user.ID),
@@ -100,34 +196,428 @@ func (s *AdminService) UpdateUser(user models.User, access string) error {
 		slog.String("role", user.Role),
 	)
 
+	entry := models.AuditEntry{Action: models.AuditUserUpdate, TargetID: user.ID, Details: "role: " + user.Role}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
 	// Валидируем токен
-	claims, err := s.TokenServ.Validate(access)
+	claims, err := s.TokenServ.Validate(ctx, access)
 	if err != nil {
-		log.Error("Access token is invalid", "error", err)
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
 		return models.ErrInvalidToken
 	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
 
 	// Проверяем права пользователя
 	if !claims.IsAdmin {
-		log.Error("User is not administrator")
+		log.ErrorContext(ctx, "User is not administrator")
 		return models.ErrPermissionDenied
 	}
 
 	if user.Role == models.AdminRole {
-		log.Error("Attempt to update role to admin via API")
+		log.ErrorContext(ctx, "Attempt to update role to admin via API")
 		return models.ErrCannotCreateAdmin
 	}
 
+	current, err := s.UserDal.GetUserByID(ctx, claims.TenantID, user.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "User is not exist")
+			return repo.ErrUserNotExist
+		}
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.ErrUnexpected
+	}
+
+	// Смена роли публикуется отдельным событием с предыдущей ролью
+	events := []models.UserEvent{{Type: models.EventUserUpdated}}
+	if current.Role != user.Role {
+		events = append(events, models.UserEvent{Type: models.EventUserRoleChanged, Extra: map[string]string{"previous_role": current.Role}})
+	}
+
 	// Пока что обновляем name, role
 	// Можно полностью, когда будет доступен tokens-black-list
-	if err := s.UserDal.UpdateUser(user.Name, user.Role, user.ID); err != nil {
+	if err := s.UserDal.UpdateUser(ctx, claims.TenantID, user.Name, user.Role, user.ID, events...); err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return repo.ErrUserNotExist
 		}
-		log.Error("Failed to delete user data", "error", err)
+		log.ErrorContext(ctx, "Failed to delete user data", "error", err)
+		return models.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Creates user in the administrator's tenant. Without password a temporary one
+// is generated and returned, it must be changed at first login.
+func (s *AdminService) CreateUser(ctx context.Context, user models.User, password, access string, meta models.RequestMeta) (createdUser models.User, tempPassword string, err error) {
+	const op = "AdminService.CreateUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("email", user.Email),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditUserCreate}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.User{}, "", models.ErrInvalidToken
+	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.User{}, "", models.ErrPermissionDenied
+	}
+
+	user.TenantID = claims.TenantID
+
+	if password == "" {
+		tenant, err := s.AuthServ.getTenant(ctx, user.TenantID)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+			return models.User{}, "", err
+		}
+
+		if tempPassword, err = generatePassword(s.AuthServ.passwordPolicyOf(tenant)); err != nil {
+			log.ErrorContext(ctx, "Failed to generate password", "error", err)
+			return models.User{}, "", models.ErrUnexpected
+		}
+		password = tempPassword
+		user.MustChangePassword = true
+	}
+
+	createdUser, err = s.AuthServ.CreateUser(ctx, user, password)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to create user", "error", err)
+		return models.User{}, "", err
+	}
+	entry.TargetID = createdUser.ID
+
+	log.InfoContext(ctx, "User created by admin", "ID", createdUser.ID, "admin", claims.ID)
+	return createdUser, tempPassword, nil
+}
+
+// Imports users into the administrator's tenant. Rows are checked before saving;
+// atomic import saves nothing if any row fails, otherwise valid rows are saved.
+func (s *AdminService) ImportUsers(ctx context.Context, rows []models.ImportRow, atomic bool, access string, meta models.RequestMeta) (report models.ImportReport, err error) {
+	const op = "AdminService.ImportUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("rows", len(rows)),
+		slog.Bool("atomic", atomic),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditUserImport}
+	defer func() {
+		if err == nil {
+			entry.Details = fmt.Sprintf("created %d of %d rows", report.Created, report.Total)
+		}
+		s.AuditServ.Record(ctx, entry, meta, err)
+	}()
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.ImportReport{}, models.ErrInvalidToken
+	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.ImportReport{}, models.ErrPermissionDenied
+	}
+
+	tenant, err := s.AuthServ.getTenant(ctx, claims.TenantID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return models.ImportReport{}, err
+	}
+
+	report = models.ImportReport{
+		Total:   len(rows),
+		Atomic:  atomic,
+		Results: make([]models.ImportResult, len(rows)),
+	}
+	users := make([]*models.User, len(rows))
+	passwords := make([]string, len(rows))
+	seen := make(map[string]int, len(rows))
+
+	// Проверяем строки: ошибки парсинга, дубликаты и уже существующие email
+	for i, row := range rows {
+		result := &report.Results[i]
+		result.Line, result.Email = row.Line, row.Email
+
+		if row.Err != nil {
+			result.Error = row.Err.Error()
+			continue
+		}
+
+		email := strings.ToLower(row.Email)
+		if line, ok := seen[email]; ok {
+			result.Error = fmt.Sprintf("%s: duplicate of line %d", models.ErrNotUniqueEmail, line)
+			continue
+		}
+		seen[email] = row.Line
+
+		if _, err := s.UserDal.GetUser(ctx, tenant.ID, row.Email); err == nil {
+			result.Error = models.ErrNotUniqueEmail.Error()
+			continue
+		} else if !errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "Failed to check user uniqueness", "error", err)
+			return models.ImportReport{}, models.ErrUnexpected
+		}
+
+		users[i] = &models.User{
+			TenantID: tenant.ID,
+			Name:     row.Name,
+			Email:    row.Email,
+			Role:     row.Role,
+		}
+		passwords[i] = row.Password
+		if row.Password == "" {
+			if passwords[i], err = generatePassword(s.AuthServ.passwordPolicyOf(tenant)); err != nil {
+				log.ErrorContext(ctx, "Failed to generate password", "error", err)
+				return models.ImportReport{}, models.ErrUnexpected
+			}
+			result.TempPassword = passwords[i]
+			users[i].MustChangePassword = true
+		}
+	}
+
+	// Хэширование bcrypt медленное, выполняем параллельно
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i := range users {
+		if users[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if err := s.AuthServ.prepareUser(ctx, tenant, users[i], passwords[i]); err != nil {
+				report.Results[i].Error = err.Error()
+				users[i] = nil
+			}
+		}()
+	}
+	wg.Wait()
+
+	var valid []*models.User
+	for i := range users {
+		if users[i] != nil {
+			valid = append(valid, users[i])
+		}
+	}
+
+	switch {
+	case atomic && len(valid) != len(rows):
+		log.ErrorContext(ctx, "Atomic import has invalid rows, nothing is saved")
+		for i := range report.Results {
+			if report.Results[i].Error == "" {
+				report.Results[i].Error = "not saved: import has invalid rows"
+			}
+		}
+	case atomic:
+		if err := s.UserDal.SaveUsers(ctx, valid, models.UserEvent{Type: models.EventUserRegistered}); err != nil {
+			log.ErrorContext(ctx, "Failed to save users", "error", err)
+			for i := range report.Results {
+				report.Results[i].Error = "import aborted: " + cause(err).Error()
+			}
+		}
+	default:
+		for i, user := range users {
+			if user == nil {
+				continue
+			}
+			if err := s.UserDal.SaveUser(ctx, user, models.UserEvent{Type: models.EventUserRegistered}); err != nil {
+				log.ErrorContext(ctx, "Failed to save user", "line", rows[i].Line, "error", err)
+				report.Results[i].Error = cause(err).Error()
+			}
+		}
+	}
+
+	for i := range report.Results {
+		result := &report.Results[i]
+		if result.Error != "" || users[i] == nil || users[i].ID == 0 {
+			result.TempPassword = ""
+			report.Failed++
+			continue
+		}
+		result.ID = users[i].ID
+		report.Created++
+	}
+
+	log.InfoContext(ctx, "Users import finished", "created", report.Created, "failed", report.Failed)
+	return report, nil
+}
+
+// Returns domain error for the report, storage details are not exposed
+func cause(err error) error {
+	if errors.Is(err, models.ErrNotUniqueEmail) {
+		return models.ErrNotUniqueEmail
+	}
+	return models.ErrUnexpected
+}
+
+// Returns page of the administrator's tenant users
+func (s *AdminService) ListUsers(ctx context.Context, filter models.UserFilter, access string) (_ models.UserPage, err error) {
+	const op = "AdminService.ListUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.UserPage{}, models.ErrInvalidToken
+	}
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.UserPage{}, models.ErrPermissionDenied
+	}
+
+	filter.TenantID = claims.TenantID
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = DefaultUsersPage
+	case filter.Limit > MaxUsersPage:
+		filter.Limit = MaxUsersPage
+	}
+
+	page, err := s.UserDal.ListUsers(ctx, filter)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			log.ErrorContext(ctx, "Cursor is invalid")
+			return models.UserPage{}, models.ErrInvalidCursor
+		}
+		log.ErrorContext(ctx, "Failed to list users", "error", err)
+		return models.UserPage{}, models.ErrUnexpected
+	}
+
+	return page, nil
+}
+
+// Streams users of the administrator's tenant matching filter to fn
+func (s *AdminService) ExportUsers(ctx context.Context, filter models.UserFilter, access string, fn func(models.User) error) (err error) {
+	const op = "AdminService.ExportUsers"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.ErrInvalidToken
+	}
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.ErrPermissionDenied
+	}
+
+	filter.TenantID = claims.TenantID
+	if err := s.UserDal.ExportUsers(ctx, filter, fn); err != nil {
+		log.ErrorContext(ctx, "Failed to export users", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Users export finished", "admin", claims.ID)
+	return nil
+}
+
+// Returns settings of the administrator's tenant
+func (s *AdminService) GetTenant(ctx context.Context, access string) (_ models.Tenant, err error) {
+	const op = "AdminService.GetTenant"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.Tenant{}, models.ErrInvalidToken
+	}
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.Tenant{}, models.ErrPermissionDenied
+	}
+
+	tenant, err := s.TenantDal.GetTenant(ctx, claims.TenantID)
+	if err != nil {
+		if errors.Is(err, repo.ErrTenantNotExist) {
+			log.ErrorContext(ctx, "Tenant is not exist")
+			return models.Tenant{}, repo.ErrTenantNotExist
+		}
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return models.Tenant{}, models.ErrUnexpected
+	}
+
+	return tenant, nil
+}
+
+// Overrides token TTLs and password policy of the administrator's tenant
+func (s *AdminService) UpdateTenant(ctx context.Context, tenant models.Tenant, access string, meta models.RequestMeta) (err error) {
+	const op = "AdminService.UpdateTenant"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditTenantUpdate}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.ErrInvalidToken
+	}
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		log.ErrorContext(ctx, "User is not administrator")
+		return models.ErrPermissionDenied
+	}
+
+	// Администратор может менять только настройки своего тенанта
+	tenant.ID = claims.TenantID
+	if err := s.TenantDal.UpdateTenant(ctx, tenant); err != nil {
+		if errors.Is(err, repo.ErrTenantNotExist) {
+			log.ErrorContext(ctx, "Tenant is not exist")
+			return repo.ErrTenantNotExist
+		}
+		log.ErrorContext(ctx, "Failed to update tenant", "error", err)
 		return models.ErrUnexpected
 	}
 
+	log.InfoContext(ctx, "Tenant settings updated", "tenant", tenant.ID)
 	return nil
 }
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// Audit log page size limits
+const (
+	DefaultAuditPage = 50
+	MaxAuditPage     = 200
+)
+
+// Max stored user agent length
+const maxUserAgent = 512
+
+var errChainBroken = errors.New("audit chain is broken")
+
+type AuditService struct {
+	AuditDal  ports.AuditRepo
+	TokenServ ports.TokenService
+	log       *slog.Logger
+}
+
+func NewAuditService(AuditDal ports.AuditRepo, TokenServ ports.TokenService, log *slog.Logger) *AuditService {
+	return &AuditService{
+		AuditDal:  AuditDal,
+		TokenServ: TokenServ,
+		log:       log,
+	}
+}
+
+// Appends event with its outcome to the audit log. Events without tenant
+// (invalid token, unknown tenant) can't be attributed and are not recorded.
+// Audit failure doesn't fail the audited action, it is only logged.
+// The entry is written even if the request was canceled after the action
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry, meta models.RequestMeta, err error) {
+	if entry.TenantID == "" {
+		return
+	}
+
+	entry.Outcome = models.OutcomeSuccess
+	if err != nil {
+		entry.Outcome = models.OutcomeFailure
+		entry.Details = err.Error()
+	}
+	// Случайная цепочка, чтобы параллельные записи тенанта не ждали одну блокировку
+	entry.Chain = rand.IntN(models.AuditChains)
+	entry.IP, entry.RequestID = meta.IP, meta.RequestID
+	entry.UserAgent = meta.UserAgent
+	if len(entry.UserAgent) > maxUserAgent {
+		entry.UserAgent = entry.UserAgent[:maxUserAgent]
+	}
+
+	if err := s.AuditDal.Append(context.WithoutCancel(ctx), &entry); err != nil {
+		s.log.ErrorContext(ctx, "Failed to append audit entry", "action", entry.Action, "tenant", entry.TenantID, "error", err)
+	}
+}
+
+// Returns page of the administrator's tenant audit log, newest first
+func (s *AuditService) ListAudit(ctx context.Context, filter models.AuditFilter, access string) (models.AuditPage, error) {
+	const op = "AuditService.ListAudit"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := s.authorize(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return models.AuditPage{}, err
+	}
+
+	filter.TenantID = claims.TenantID
+	switch {
+	case filter.Limit <= 0:
+		filter.Limit = DefaultAuditPage
+	case filter.Limit > MaxAuditPage:
+		filter.Limit = MaxAuditPage
+	}
+
+	page, err := s.AuditDal.ListAudit(ctx, filter)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			log.ErrorContext(ctx, "Cursor is invalid")
+			return models.AuditPage{}, models.ErrInvalidCursor
+		}
+		log.ErrorContext(ctx, "Failed to list audit log", "error", err)
+		return models.AuditPage{}, models.ErrUnexpected
+	}
+
+	return page, nil
+}
+
+// Recomputes hash chains of the administrator's tenant and returns the first entry that doesn't match.
+// Changed, inserted or removed entries break the chain from that point on
+func (s *AuditService) VerifyAudit(ctx context.Context, access string) (models.AuditVerification, error) {
+	const op = "AuditService.VerifyAudit"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := s.authorize(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return models.AuditVerification{}, err
+	}
+
+	result := models.AuditVerification{Valid: true}
+	var prevHash string
+	chain := -1
+	err = s.AuditDal.WalkAudit(ctx, claims.TenantID, func(entry models.AuditEntry) error {
+		result.Checked++
+		// Каждая цепочка начинается с пустого хэша
+		if entry.Chain != chain {
+			chain, prevHash = entry.Chain, ""
+		}
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			result.Valid, result.BrokenID = false, entry.ID
+			return errChainBroken
+		}
+		prevHash = entry.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		log.ErrorContext(ctx, "Failed to walk audit log", "error", err)
+		return models.AuditVerification{}, models.ErrUnexpected
+	}
+
+	if !result.Valid {
+		log.ErrorContext(ctx, "Audit chain is broken", "tenant", claims.TenantID, "entry", result.BrokenID)
+	}
+	return result, nil
+}
+
+func (s *AuditService) authorize(ctx context.Context, access string) (models.CustomClaims, error) {
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		return models.CustomClaims{}, models.ErrInvalidToken
+	}
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		return models.CustomClaims{}, models.ErrPermissionDenied
+	}
+	return claims, nil
+}
@@ -4,135 +4,335 @@ import (
 	"auth/internal/adapters/repo"
 	"auth/internal/domain/models"
 	"auth/internal/domain/ports"
+	"auth/pkg/logger"
+	"context"
 	"errors"
 	"log/slog"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	UserDal   ports.UserRepo
-	TokenServ ports.TokenService
-	log       *slog.Logger
+	UserDal        ports.UserRepo
+	TenantDal      ports.TenantRepo
+	TokenServ      ports.TokenService
+	AuditServ      *AuditService
+	LoginServ      *LoginService
+	passwordPolicy models.PasswordPolicy
+	log            *slog.Logger
 }
 
-func NewAuthService(UserDal ports.UserRepo, TokenServ ports.TokenService, log *slog.Logger) *AuthService {
+func NewAuthService(UserDal ports.UserRepo, TenantDal ports.TenantRepo, TokenServ ports.TokenService, AuditServ *AuditService, LoginServ *LoginService, passwordPolicy models.PasswordPolicy, log *slog.Logger) *AuthService {
 	return &AuthService{
-		UserDal:   UserDal,
-		TokenServ: TokenServ,
-		log:       log,
+		UserDal:        UserDal,
+		TenantDal:      TenantDal,
+		TokenServ:      TokenServ,
+		AuditServ:      AuditServ,
+		LoginServ:      LoginServ,
+		passwordPolicy: passwordPolicy,
+		log:            log,
 	}
 }
 
 // Returns (AccessToken, RefreshToken, statusCode, error message)
-func (s *AuthService) Login(email, password string) (models.TokenPair, error) {
+func (s *AuthService) Login(ctx context.Context, tenantID, email, password string, meta models.RequestMeta) (tokens models.TokenPair, err error) {
 	const op = "AuthService.Login"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
+		slog.String("tenant", tenantID),
 		slog.String("email", email),
 	)
-	log.Info("User login started")
+	log.InfoContext(ctx, "User login started")
+
+	entry := models.AuditEntry{Action: models.AuditLogin}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+	defer func() { loginsTotal.WithLabelValues(outcome(err)).Inc() }()
+
+	// Проверяем существует ли тенант
+	if _, err := s.getTenant(ctx, tenantID); err != nil {
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return models.TokenPair{}, err
+	}
+	entry.TenantID = tenantID
 
 	// Проверяем существует ли пользователь
-	existUser, err := s.UserDal.GetUser(email)
+	existUser, err := s.UserDal.GetUser(ctx, tenantID, email)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return models.TokenPair{}, repo.ErrUserNotExist
 		}
-		log.Error("Failed to check user uniqueness", "error", err)
+		log.ErrorContext(ctx, "Failed to check user uniqueness", "error", err)
 		return models.TokenPair{}, models.ErrUnexpected
 	}
 
+	entry.TargetID = existUser.ID
+	defer func() { s.LoginServ.Record(ctx, existUser, meta, err) }()
+
 	// Сверяем пароли user-a и existing user-s с помощью compareHash
-	if err := bcrypt.CompareHashAndPassword([]byte(existUser.GetPassword()), []byte(password)); err != nil {
-		log.Error("Invalid credentials", "error", err)
+	if err := bcryptCompare(ctx, existUser.GetPassword(), password); err != nil {
+		log.ErrorContext(ctx, "Invalid credentials", "error", err)
 		return models.TokenPair{}, models.ErrInvalidCredentials
 	}
+	entry.ActorID = existUser.ID
+
+	// Отключенные и удаленные пользователи не могут войти
+	if existUser.Status != models.StatusActive {
+		log.ErrorContext(ctx, "User is not active", "status", existUser.Status)
+		return models.TokenPair{}, models.ErrUserInactive
+	}
+
+	// Временный пароль от администратора нужно сменить до входа
+	if existUser.MustChangePassword {
+		log.ErrorContext(ctx, "Temporary password must be changed")
+		return models.TokenPair{}, models.ErrPasswordChangeRequired
+	}
 
 	// Генерируем токены
-	tokens, err := s.TokenServ.GenerateTokens(existUser)
+	tokens, err = s.TokenServ.GenerateTokens(ctx, existUser)
 	if err != nil {
-		log.Error("Failed to generate token", "error", err)
+		log.ErrorContext(ctx, "Failed to generate token", "error", err)
 		return models.TokenPair{}, models.ErrTokenGenerateFail
 	}
 
+	logger.SetPrincipal(ctx, principal(tenantID, existUser.ID))
 	return tokens, nil
 }
 
-func (s *AuthService) Register(name, email, password, role string) (int, error) {
-	const op = "AuthService.Register"
+func (s *AuthService) Register(ctx context.Context, tenantID, name, email, password, role string, meta models.RequestMeta) (_ int, err error) {
+	ctx, span := startSpan(ctx, "AuthService.Register")
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.CreateUser(ctx, models.User{
+		TenantID: tenantID,
+		Name:     name,
+		Email:    email,
+		Role:     role,
+	}, password)
+	s.recordRegister(ctx, tenantID, user.ID, meta, err)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// Counts registration and writes it to the audit log
+func (s *AuthService) recordRegister(ctx context.Context, tenantID string, userID int, meta models.RequestMeta, err error) {
+	registrationsTotal.WithLabelValues(outcome(err)).Inc()
+
+	// Попытки регистрации в несуществующем тенанте не журналируются
+	if !errors.Is(err, repo.ErrTenantNotExist) && !errors.Is(err, models.ErrTenantRequired) {
+		s.AuditServ.Record(ctx, models.AuditEntry{TenantID: tenantID, ActorID: userID, TargetID: userID, Action: models.AuditRegister}, meta, err)
+	}
+}
+
+// Issues new token pair by refresh token
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta models.RequestMeta) (_ models.TokenPair, err error) {
+	ctx, span := startSpan(ctx, "AuthService.Refresh")
+	defer func() { endSpan(span, err) }()
+
+	tokens, err := s.TokenServ.Refresh(ctx, refreshToken)
+	refreshesTotal.WithLabelValues(outcome(err)).Inc()
+
+	// Владелец определяется по подписанному токену, в том числе при отказе
+	if claims, parseErr := s.TokenServ.Claims(refreshToken); parseErr == nil {
+		s.AuditServ.Record(ctx, models.AuditEntry{TenantID: claims.TenantID, ActorID: claims.ID, TargetID: claims.ID, Action: models.AuditRefresh}, meta, err)
+	}
+	return tokens, err
+}
+
+// Creates user with checks of tenant password policy and email uniqueness
+func (s *AuthService) CreateUser(ctx context.Context, user models.User, password string) (_ models.User, err error) {
+	const op = "AuthService.CreateUser"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
-		slog.String("name", name),
-		slog.String("email", email),
+		slog.String("tenant", user.TenantID),
+		slog.String("name", user.Name),
+		slog.String("email", user.Email),
 	)
-	log.Info("User register started")
-
-	// Проверяем уникальный ли email
-	if user, err := s.UserDal.GetUser(email); err != nil && !errors.Is(err, repo.ErrUserNotExist) {
-		log.Error("Failed to check user uniqueness", "error", err)
-		return 0, models.ErrUnexpected
-	} else {
-		if user.ID != 0 {
-			log.Error("User email is not unique")
-			return 0, models.ErrNotUniqueEmail
+	log.InfoContext(ctx, "User register started")
+
+	user, err = s.newUser(ctx, user, password)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to prepare user", "error", err)
+		return models.User{}, err
+	}
+
+	// Сохраняем нового пользователя
+	if err = s.UserDal.SaveUser(ctx, &user, models.UserEvent{Type: models.EventUserRegistered}); err != nil {
+		if errors.Is(err, models.ErrNotUniqueEmail) {
+			return models.User{}, models.ErrNotUniqueEmail
 		}
+		log.ErrorContext(ctx, "Failed to save user", "error", err)
+		return models.User{}, models.ErrUnexpected
 	}
 
-	// Генерация хэша с defaultSolt(чем оно выше, тем лучше защищен хэш)
-	hashedPass, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return user, nil
+}
+
+// Changes password by the old one. Used to replace temporary password, so no token is required
+func (s *AuthService) ChangePassword(ctx context.Context, tenantID, email, oldPassword, newPassword string) (err error) {
+	const op = "AuthService.ChangePassword"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", tenantID),
+		slog.String("email", email),
+	)
+
+	tenant, err := s.getTenant(ctx, tenantID)
 	if err != nil {
-		log.Error("Failed to generate hash from password", "error", err)
-		return 0, models.ErrUnexpected
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return err
 	}
 
-	if role == models.AdminRole {
-		log.Error("Attempt to create admin via API")
-		return 0, models.ErrCannotCreateAdmin
+	existUser, err := s.UserDal.GetUser(ctx, tenantID, email)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "User is not exist")
+			return models.ErrInvalidCredentials
+		}
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.ErrUnexpected
 	}
 
-	// Сохраняем нового пользователя
-	newUser := models.User{
-		Name:  name,
-		Email: email,
-		Role:  role,
+	if err := bcryptCompare(ctx, existUser.GetPassword(), oldPassword); err != nil {
+		log.ErrorContext(ctx, "Invalid credentials", "error", err)
+		return models.ErrInvalidCredentials
 	}
-	newUser.SetPassword(string(hashedPass))
 
-	if err = s.UserDal.SaveUser(&newUser); err != nil {
-		log.Error("Failed to save user", "error", err)
-		return 0, models.ErrUnexpected
+	if existUser.Status != models.StatusActive {
+		log.ErrorContext(ctx, "User is not active", "status", existUser.Status)
+		return models.ErrUserInactive
 	}
 
-	return newUser.ID, nil
+	if oldPassword == newPassword {
+		return models.ErrSamePassword
+	}
+
+	// Проверяем пароль по политике тенанта
+	if err := s.passwordPolicyOf(tenant).Check(newPassword); err != nil {
+		log.ErrorContext(ctx, "Password does not satisfy policy")
+		return err
+	}
+
+	hashedPass, err := bcryptHash(ctx, newPassword)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to generate hash from password", "error", err)
+		return models.ErrUnexpected
+	}
+
+	if err := s.UserDal.UpdatePassword(ctx, tenantID, existUser.ID, string(hashedPass), false); err != nil {
+		log.ErrorContext(ctx, "Failed to update password", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Password changed", "ID", existUser.ID)
+	return nil
 }
 
-func (s *AuthService) RoleCheck(token string) (models.User, error) {
+func (s *AuthService) RoleCheck(ctx context.Context, token string) (_ models.User, err error) {
 	const op = "AuthService.IsAdmin"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 	)
-	log.Info("Role check started")
+	log.InfoContext(ctx, "Role check started")
 
 	// Валидируем его
-	claim, err := s.TokenServ.Validate(token)
+	claim, err := s.TokenServ.Validate(ctx, token)
 	if err != nil {
-		log.Error("Access token is invalid", "error", err)
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
 		return models.User{}, models.ErrInvalidToken
 	}
 
-	// Проверяем существует ли пользователь
-	existUser, err := s.UserDal.GetUser(claim.Email)
+	// Пользователь ищется по ID: email в токене мог устареть и уже принадлежать другому аккаунту
+	existUser, err := s.UserDal.GetUserByID(ctx, claim.TenantID, claim.ID)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return models.User{}, repo.ErrUserNotExist
 		}
-		log.Error("Failed to check user uniqueness", "error", err)
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
 		return models.User{}, models.ErrUnexpected
 	}
 
 	// Читаем админ ли он
 	return existUser, nil
 }
+
+// Checks tenant and email uniqueness and hashes password of the user that is not saved yet
+func (s *AuthService) newUser(ctx context.Context, user models.User, password string) (models.User, error) {
+	tenant, err := s.getTenant(ctx, user.TenantID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	// Проверяем уникальный ли email в рамках тенанта
+	if existUser, err := s.UserDal.GetUser(ctx, user.TenantID, user.Email); err != nil && !errors.Is(err, repo.ErrUserNotExist) {
+		s.log.ErrorContext(ctx, "Failed to check user uniqueness", "error", err)
+		return models.User{}, models.ErrUnexpected
+	} else if existUser.ID != 0 {
+		return models.User{}, models.ErrNotUniqueEmail
+	}
+
+	if err := s.prepareUser(ctx, tenant, &user, password); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// Checks password by tenant policy and hashes it. Admins are created only with CLI
+func (s *AuthService) prepareUser(ctx context.Context, tenant models.Tenant, user *models.User, password string) error {
+	if user.Role == models.AdminRole {
+		return models.ErrCannotCreateAdmin
+	}
+
+	hashedPass, err := s.hashPassword(ctx, tenant, password)
+	if err != nil {
+		return err
+	}
+	user.SetPassword(hashedPass)
+	return nil
+}
+
+// Checks password by tenant policy and returns its hash
+func (s *AuthService) hashPassword(ctx context.Context, tenant models.Tenant, password string) (string, error) {
+	// Проверяем пароль по политике тенанта
+	if err := s.passwordPolicyOf(tenant).Check(password); err != nil {
+		return "", err
+	}
+
+	// Генерация хэша с defaultSolt(чем оно выше, тем лучше защищен хэш)
+	hashedPass, err := bcryptHash(ctx, password)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Failed to generate hash from password", "error", err)
+		return "", models.ErrUnexpected
+	}
+	return string(hashedPass), nil
+}
+
+// Returns password policy of the tenant
+func (s *AuthService) passwordPolicyOf(tenant models.Tenant) models.PasswordPolicy {
+	return tenant.Settings(models.TenantSettings{Password: s.passwordPolicy}).Password
+}
+
+func (s *AuthService) getTenant(ctx context.Context, tenantID string) (models.Tenant, error) {
+	if tenantID == "" {
+		return models.Tenant{}, models.ErrTenantRequired
+	}
+
+	tenant, err := s.TenantDal.GetTenant(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, repo.ErrTenantNotExist) {
+			return models.Tenant{}, repo.ErrTenantNotExist
+		}
+		s.log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return models.Tenant{}, models.ErrUnexpected
+	}
+	return tenant, nil
+}
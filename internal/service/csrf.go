@@ -0,0 +1,34 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signs CSRF token of the cookie session. It is bound to the session, so a token issued to another
+// session, even of the same account, is rejected
+func (s *TokenService) CSRFToken(user models.User, sessionID string, ttl time.Duration) (string, error) {
+	return s.SignAction(models.CSRFPurpose, jwt.MapClaims{
+		"ID":        user.ID,
+		"tenant_id": user.TenantID,
+		"sid":       sessionID,
+	}, ttl)
+}
+
+// Checks that CSRF token was issued to the session of the token claims
+func (s *TokenService) CheckCSRF(token string, claims models.CustomClaims) error {
+	csrfClaims, err := s.ParseAction(models.CSRFPurpose, token)
+	if err != nil {
+		return err
+	}
+
+	id, _ := csrfClaims["ID"].(float64)
+	tenantID, _ := csrfClaims["tenant_id"].(string)
+	sessionID, _ := csrfClaims["sid"].(string)
+	if int(id) != claims.ID || tenantID != claims.TenantID || sessionID != claims.SessionID {
+		return models.ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"auth/pkg/netguard"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Webhook request headers
+const (
+	WebhookIDHeader        = "X-Webhook-ID"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	dispatchBatch      = 100              // Max events fanned out and deliveries sent per dispatch
+	dispatchWorkers    = 8                // Max concurrent delivery requests
+	maxWebhookBackoff  = 6 * time.Hour    // Max delay between attempts
+	outboxPurgeEvery   = time.Hour        // Interval between outbox cleanups
+	maxDeliveryErrSize = 512              // Max stored length of delivery error
+	maxResponseRead    = 4 << 10          // Response body bytes read to reuse connection
+	deliveryLeaseExtra = 30 * time.Second // Lease over request timeout before delivery is taken again
+)
+
+// Dispatcher delivers outbox events to tenant webhooks with retries and backoff
+type Dispatcher struct {
+	WebhookDal  ports.WebhookRepo
+	client      *http.Client
+	Interval    time.Duration
+	MaxAttempts int
+	Backoff     time.Duration
+	Retention   time.Duration
+	lastPurge   time.Time
+	log         *slog.Logger
+}
+
+// Endpoints in internal network are reachable only with allowPrivate, otherwise webhooks could be used to send
+// requests to services and cloud metadata behind the firewall
+func NewDispatcher(WebhookDal ports.WebhookRepo, Interval, Timeout time.Duration, MaxAttempts int, Backoff, Retention time.Duration, allowPrivate bool, log *slog.Logger) *Dispatcher {
+	dialer := &net.Dialer{Timeout: Timeout}
+	if !allowPrivate {
+		dialer.Control = netguard.Control
+	}
+	return &Dispatcher{
+		WebhookDal: WebhookDal,
+		client: &http.Client{
+			Timeout: Timeout,
+			// Прокси из окружения обошел бы проверку адреса
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: Timeout,
+				MaxIdleConnsPerHost: dispatchWorkers,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// Редирект не следует, иначе получатель направит запрос на любой адрес
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Interval:    Interval,
+		MaxAttempts: MaxAttempts,
+		Backoff:     Backoff,
+		Retention:   Retention,
+		log:         log,
+	}
+}
+
+// Runs dispatch every interval until context is canceled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.Dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fans out new events to webhooks and sends due deliveries. Returns count of sent deliveries
+func (d *Dispatcher) Dispatch(ctx context.Context) int {
+	const op = "Dispatcher.Dispatch"
+	log := d.log.With(
+		slog.String("op", op),
+	)
+
+	for {
+		dispatched, err := d.WebhookDal.FanOutEvents(ctx, dispatchBatch)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to fan out events", "error", err)
+			break
+		}
+		if dispatched < dispatchBatch {
+			break
+		}
+	}
+
+	deliveries, err := d.WebhookDal.ClaimDeliveries(ctx, dispatchBatch, d.client.Timeout+deliveryLeaseExtra)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to claim deliveries", "error", err)
+		return 0
+	}
+
+	// Отправляем параллельно, но не больше dispatchWorkers запросов одновременно
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, dispatchWorkers)
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery models.WebhookDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			d.deliver(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	if time.Since(d.lastPurge) >= outboxPurgeEvery {
+		d.lastPurge = time.Now()
+		if purged, err := d.WebhookDal.PurgeEvents(ctx, time.Now().Add(-d.Retention)); err != nil {
+			log.ErrorContext(ctx, "Failed to purge outbox", "error", err)
+		} else if purged > 0 {
+			log.InfoContext(ctx, "Outbox events purged", "count", purged)
+		}
+	}
+	return len(deliveries)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) {
+	log := d.log.With(
+		slog.Int64("delivery", delivery.ID),
+		slog.Int("webhook", delivery.WebhookID),
+		slog.String("event", delivery.EventType),
+	)
+
+	err := d.send(ctx, delivery)
+	if err != nil && ctx.Err() != nil {
+		// Прервано остановкой: попытку не засчитываем, доставка повторится после аренды
+		log.InfoContext(ctx, "Delivery interrupted", "error", err)
+		return
+	}
+
+	// Результат отправки сохраняем и при остановке, иначе получатель получит событие повторно
+	ctx = context.WithoutCancel(ctx)
+	if err == nil {
+		if err := d.WebhookDal.MarkDelivered(ctx, delivery.ID); err != nil {
+			log.ErrorContext(ctx, "Failed to mark delivery as delivered", "error", err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts >= d.MaxAttempts
+	lastErr := err.Error()
+	if len(lastErr) > maxDeliveryErrSize {
+		lastErr = lastErr[:maxDeliveryErrSize]
+	}
+	log.ErrorContext(ctx, "Failed to deliver event", "attempt", attempts, "dead", dead, "error", err)
+
+	if err := d.WebhookDal.MarkFailed(ctx, delivery.ID, lastErr, time.Now().Add(RetryDelay(d.Backoff, attempts)), dead); err != nil {
+		log.ErrorContext(ctx, "Failed to mark delivery as failed", "error", err)
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery models.WebhookDelivery) error {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseRead))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Returns hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret.
+// Receivers recompute it to check the sender and reject old timestamps against replays
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Exponential delay before the next attempt: base, 2*base, 4*base... up to maxWebhookBackoff
+func RetryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookBackoff)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	watchBatch        = 100         // Max events read per poll
+	watchPollInterval = time.Second // Interval between polls when the subscriber caught up
+)
+
+// Streaming of the tenant user events to internal subscribers
+type EventService struct {
+	EventDal     ports.EventRepo
+	TokenServ    ports.TokenService
+	PollInterval time.Duration
+	done         chan struct{}
+	stopOnce     sync.Once
+	log          *slog.Logger
+}
+
+func NewEventService(EventDal ports.EventRepo, TokenServ ports.TokenService, log *slog.Logger) *EventService {
+	return &EventService{
+		EventDal:     EventDal,
+		TokenServ:    TokenServ,
+		PollInterval: watchPollInterval,
+		done:         make(chan struct{}),
+		log:          log,
+	}
+}
+
+// Ends all watch streams, subscribers reconnect to other instance with their cursor.
+// Streams don't end by themselves, so it is called on shutdown before servers drain
+func (s *EventService) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// Calls fn for every event of the caller's tenant after the cursor (event ID, 0 streams
+// all retained events) until ctx is done or the token expires. Empty types means all types.
+// Subscriber resumes after reconnect with ID of the last received event
+func (s *EventService) WatchUserEvents(ctx context.Context, cursor int64, types []string, access string, fn func(models.Event) error) error {
+	const op = "EventService.WatchUserEvents"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int64("cursor", cursor),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.ErrInvalidToken
+	}
+
+	// Подписываться могут администраторы и сервисные аккаунты
+	if !claims.IsAdmin && claims.Role != models.ServiceRole {
+		log.ErrorContext(ctx, "User is not administrator or service")
+		return models.ErrPermissionDenied
+	}
+
+	// События до курсора могли быть удалены, тогда продолжить нельзя
+	if cursor > 0 {
+		purged, err := s.EventDal.PurgedThrough(ctx, claims.TenantID)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to get purged events", "error", err)
+			return models.ErrUnexpected
+		}
+		if cursor < purged {
+			log.ErrorContext(ctx, "Cursor is expired", "purged", purged)
+			return models.ErrCursorExpired
+		}
+	}
+
+	// Поток закрывается по истечении токена, подписчик переподключается с новым
+	var expired <-chan time.Time
+	if claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	log.InfoContext(ctx, "Subscriber connected", "tenant", claims.TenantID, "user", claims.ID)
+	for {
+		events, err := s.EventDal.ListEvents(ctx, claims.TenantID, cursor, types, watchBatch)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to list events", "error", err)
+			return models.ErrUnexpected
+		}
+
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				log.InfoContext(ctx, "Subscriber disconnected", "cursor", cursor, "error", err)
+				return err
+			}
+			cursor = event.ID
+		}
+
+		// Полная страница означает, что подписчик отстает: читаем дальше без ожидания
+		if len(events) == watchBatch {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.InfoContext(ctx, "Subscriber disconnected", "cursor", cursor)
+			return nil
+		case <-expired:
+			log.InfoContext(ctx, "Subscriber token expired", "cursor", cursor)
+			return models.ErrInvalidToken
+		case <-s.done:
+			log.InfoContext(ctx, "Service is stopping, subscriber disconnected", "cursor", cursor)
+			return nil
+		case <-time.After(s.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type ExportService struct {
+	UserDal   ports.UserRepo
+	OrgDal    ports.OrgRepo
+	LoginDal  ports.LoginRepo
+	TokenServ ports.TokenService
+	log       *slog.Logger
+}
+
+func NewExportService(UserDal ports.UserRepo, OrgDal ports.OrgRepo, LoginDal ports.LoginRepo, TokenServ ports.TokenService, log *slog.Logger) *ExportService {
+	return &ExportService{
+		UserDal:   UserDal,
+		OrgDal:    OrgDal,
+		LoginDal:  LoginDal,
+		TokenServ: TokenServ,
+		log:       log,
+	}
+}
+
+// Collects personal data of the token owner
+func (s *ExportService) ExportMe(ctx context.Context, access string) (models.UserExport, error) {
+	const op = "ExportService.ExportMe"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.UserExport{}, models.ErrInvalidToken
+	}
+
+	user, err := s.UserDal.GetUserByID(ctx, claims.TenantID, claims.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "User is not exist")
+			return models.UserExport{}, repo.ErrUserNotExist
+		}
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.UserExport{}, models.ErrUnexpected
+	}
+
+	memberships, err := s.OrgDal.ListUserMemberships(ctx, user.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list memberships", "error", err)
+		return models.UserExport{}, models.ErrUnexpected
+	}
+
+	logins, err := s.loginHistory(ctx, user)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list logins", "error", err)
+		return models.UserExport{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "User data exported", "ID", user.ID)
+	return models.UserExport{
+		ExportedAt:    time.Now().UTC(),
+		Profile:       user,
+		Organizations: memberships,
+		LoginHistory:  logins,
+	}, nil
+}
+
+// Collects the whole login history page by page
+func (s *ExportService) loginHistory(ctx context.Context, user models.User) ([]models.LoginRecord, error) {
+	logins := []models.LoginRecord{}
+	var cursor string
+	for {
+		page, err := s.LoginDal.ListLogins(ctx, user.TenantID, user.ID, cursor, MaxLoginPage)
+		if err != nil {
+			return nil, err
+		}
+		logins = append(logins, page.Logins...)
+		if page.NextCursor == "" {
+			return logins, nil
+		}
+		cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const healthCheckTimeout = 2 * time.Second // Time limit of one dependency check
+
+// Named dependency check of the readiness probe
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Readiness of the instance to receive traffic. It is turned on after the servers start
+// and turned off at the beginning of shutdown, so load balancers stop routing new requests
+// while in-flight ones drain. While turned on, the instance is ready if all checks pass
+type HealthService struct {
+	checks  []HealthCheck
+	ready   atomic.Bool
+	mu      sync.Mutex
+	changed chan struct{} // Closed and replaced when readiness is turned on or off
+	log     *slog.Logger
+}
+
+func NewHealthService(log *slog.Logger, checks ...HealthCheck) *HealthService {
+	return &HealthService{
+		checks:  checks,
+		changed: make(chan struct{}),
+		log:     log,
+	}
+}
+
+// Marks the instance as ready or not ready to receive traffic
+func (s *HealthService) SetReady(ready bool) {
+	if s.ready.Swap(ready) == ready {
+		return
+	}
+	s.log.Info("Readiness changed", "ready", ready)
+
+	s.mu.Lock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// Runs dependency checks. Returns ErrNotReady while the instance is starting,
+// shutting down or any check fails; the report tells which one, the error is only logged
+func (s *HealthService) Ready(ctx context.Context) (models.HealthReport, error) {
+	const op = "HealthService.Ready"
+
+	if !s.ready.Load() {
+		return models.HealthReport{Status: models.HealthNotReady}, models.ErrNotReady
+	}
+
+	report := models.HealthReport{Status: models.HealthReady, Checks: make(map[string]string, len(s.checks))}
+	for _, check := range s.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+
+		report.Checks[check.Name] = models.HealthCheckOK
+		if err != nil {
+			s.log.WarnContext(ctx, "Health check failed", "op", op, "check", check.Name, "error", err)
+			report.Checks[check.Name] = models.HealthCheckFail
+			report.Status = models.HealthNotReady
+		}
+	}
+
+	if report.Status != models.HealthReady {
+		return report, models.ErrNotReady
+	}
+	return report, nil
+}
+
+// Calls fn with the result of Ready every interval and right after readiness
+// is turned on or off, until ctx is done
+func (s *HealthService) Watch(ctx context.Context, interval time.Duration, fn func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		_, err := s.Ready(ctx)
+		fn(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// Login history page size limits
+const (
+	DefaultLoginPage = 20
+	MaxLoginPage     = 100
+)
+
+// Login history of users and notifications about logins from new devices
+type LoginService struct {
+	LoginDal  ports.LoginRepo
+	TokenServ ports.TokenService
+	notifier  ports.Notifier
+	log       *slog.Logger
+}
+
+func NewLoginService(LoginDal ports.LoginRepo, TokenServ ports.TokenService, notifier ports.Notifier, log *slog.Logger) *LoginService {
+	return &LoginService{
+		LoginDal:  LoginDal,
+		TokenServ: TokenServ,
+		notifier:  notifier,
+		log:       log,
+	}
+}
+
+// Saves login attempt of the user. Successful login from a device never used before is
+// notified to the user, except for the very first login. Failures are only logged
+func (s *LoginService) Record(ctx context.Context, user models.User, meta models.RequestMeta, err error) {
+	const op = "LoginService.Record"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("ID", user.ID),
+	)
+
+	login := models.LoginRecord{
+		TenantID:    user.TenantID,
+		UserID:      user.ID,
+		IP:          meta.IP,
+		UserAgent:   meta.UserAgent,
+		Fingerprint: meta.Fingerprint(),
+		Success:     err == nil,
+	}
+	if len(login.UserAgent) > maxUserAgent {
+		login.UserAgent = login.UserAgent[:maxUserAgent]
+	}
+	if err != nil {
+		login.Reason = err.Error()
+	}
+
+	// Попытка входа записывается, даже если клиент уже отключился
+	ctx = context.WithoutCancel(ctx)
+
+	// Устройство проверяем до записи, иначе текущий вход сделает его известным
+	var notify bool
+	if login.Success {
+		known, firstLogin, err := s.LoginDal.KnownDevice(ctx, user.TenantID, user.ID, login.Fingerprint)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to check device", "error", err)
+		}
+		notify = err == nil && !known && !firstLogin
+	}
+
+	if err := s.LoginDal.AddLogin(ctx, &login); err != nil {
+		log.ErrorContext(ctx, "Failed to save login", "error", err)
+		return
+	}
+
+	if notify {
+		if err := s.notifier.SendNewDeviceLogin(user.Email, login); err != nil {
+			log.ErrorContext(ctx, "Failed to send new device notification", "error", err)
+			return
+		}
+		log.InfoContext(ctx, "Login from new device", "device", login.Fingerprint)
+	}
+}
+
+// Returns page of the token owner logins, newest first
+func (s *LoginService) ListLogins(ctx context.Context, cursor string, limit int, access string) (models.LoginPage, error) {
+	const op = "LoginService.ListLogins"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.LoginPage{}, models.ErrInvalidToken
+	}
+
+	switch {
+	case limit <= 0:
+		limit = DefaultLoginPage
+	case limit > MaxLoginPage:
+		limit = MaxLoginPage
+	}
+
+	page, err := s.LoginDal.ListLogins(ctx, claims.TenantID, claims.ID, cursor, limit)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			log.ErrorContext(ctx, "Cursor is invalid")
+			return models.LoginPage{}, models.ErrInvalidCursor
+		}
+		log.ErrorContext(ctx, "Failed to list logins", "error", err)
+		return models.LoginPage{}, models.ErrUnexpected
+	}
+	return page, nil
+}
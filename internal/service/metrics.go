@@ -0,0 +1,80 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	loginsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts by outcome.",
+	}, []string{"outcome"})
+	registrationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_registrations_total",
+		Help: "Self registrations by outcome.",
+	}, []string{"outcome"})
+	refreshesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_refreshes_total",
+		Help: "Token pair refreshes by outcome.",
+	}, []string{"outcome"})
+	validationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_validation_failures_total",
+		Help: "Rejected access and refresh tokens by reason.",
+	}, []string{"reason"})
+
+	// bcrypt с DefaultCost занимает десятки миллисекунд, бакеты смещены вверх
+	passwordHashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_password_hash_duration_seconds",
+		Help:    "Duration of bcrypt hashing and comparison.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"op"})
+)
+
+// Returns low cardinality label of the auth flow result
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, models.ErrInvalidCredentials), errors.Is(err, repo.ErrUserNotExist):
+		return "invalid_credentials"
+	case errors.Is(err, models.ErrUserInactive):
+		return "user_inactive"
+	case errors.Is(err, models.ErrPasswordChangeRequired):
+		return "password_change_required"
+	case errors.Is(err, models.ErrTenantRequired), errors.Is(err, repo.ErrTenantNotExist):
+		return "unknown_tenant"
+	case errors.Is(err, models.ErrNotUniqueEmail):
+		return "email_taken"
+	case errors.Is(err, models.ErrWeakPassword):
+		return "weak_password"
+	case errors.Is(err, models.ErrInvalidToken):
+		return "invalid_token"
+	case errors.Is(err, models.ErrUnexpected), errors.Is(err, models.ErrTokenGenerateFail):
+		return "error"
+	default:
+		return "rejected"
+	}
+}
+
+// Hashes password with bcrypt default cost
+func bcryptHash(ctx context.Context, password string) ([]byte, error) {
+	_, span := startSpan(ctx, "bcrypt.Hash")
+	defer span.End()
+	defer prometheus.NewTimer(passwordHashDuration.WithLabelValues("hash")).ObserveDuration()
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+// Compares password with its bcrypt hash. Mismatch is not a span error, callers report it
+func bcryptCompare(ctx context.Context, hash, password string) error {
+	_, span := startSpan(ctx, "bcrypt.Compare")
+	defer span.End()
+	defer prometheus.NewTimer(passwordHashDuration.WithLabelValues("compare")).ObserveDuration()
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// User agent of audit entries written by the command line
+const operatorAgent = "auth-cli"
+
+// Trusted operations run from the command line by the service operator.
+// They need no token: access to the configuration and the database is the authorization
+type OperatorService struct {
+	UserDal   ports.UserRepo
+	TenantDal ports.TenantRepo
+	KeyDal    ports.KeyRepo
+	AuthServ  *AuthService
+	AuditServ *AuditService
+	log       *slog.Logger
+}
+
+func NewOperatorService(UserDal ports.UserRepo, TenantDal ports.TenantRepo, KeyDal ports.KeyRepo, AuthServ *AuthService, AuditServ *AuditService, log *slog.Logger) *OperatorService {
+	return &OperatorService{
+		UserDal:   UserDal,
+		TenantDal: TenantDal,
+		KeyDal:    KeyDal,
+		AuthServ:  AuthServ,
+		AuditServ: AuditServ,
+		log:       log,
+	}
+}
+
+// Creates administrator, the tenant is created if it doesn't exist. Without password
+// a temporary one is generated and returned, it must be changed at first login
+func (s *OperatorService) CreateAdmin(ctx context.Context, user models.User, password string) (createdUser models.User, tempPassword string, err error) {
+	const op = "OperatorService.CreateAdmin"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", user.TenantID),
+		slog.String("email", user.Email),
+	)
+
+	entry := models.AuditEntry{TenantID: user.TenantID, Action: models.AuditUserCreate, Details: "role: " + models.AdminRole}
+	defer func() { s.AuditServ.Record(ctx, entry, operatorMeta(), err) }()
+
+	if err := s.TenantDal.CreateTenant(ctx, user.TenantID, user.TenantID); err != nil {
+		log.ErrorContext(ctx, "Failed to create tenant", "error", err)
+		return models.User{}, "", models.ErrUnexpected
+	}
+
+	tenant, err := s.AuthServ.getTenant(ctx, user.TenantID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return models.User{}, "", err
+	}
+
+	if password == "" {
+		if tempPassword, err = generatePassword(s.AuthServ.passwordPolicyOf(tenant)); err != nil {
+			log.ErrorContext(ctx, "Failed to generate password", "error", err)
+			return models.User{}, "", models.ErrUnexpected
+		}
+		password = tempPassword
+		user.MustChangePassword = true
+	}
+
+	hashedPass, err := s.AuthServ.hashPassword(ctx, tenant, password)
+	if err != nil {
+		log.ErrorContext(ctx, "Password is invalid", "error", err)
+		return models.User{}, "", err
+	}
+	user.SetPassword(hashedPass)
+	user.IsAdmin, user.Role = true, models.AdminRole
+
+	if err := s.UserDal.SaveUser(ctx, &user, models.UserEvent{Type: models.EventUserRegistered}); err != nil {
+		if errors.Is(err, models.ErrNotUniqueEmail) {
+			log.ErrorContext(ctx, "User email is not unique")
+			return models.User{}, "", models.ErrNotUniqueEmail
+		}
+		log.ErrorContext(ctx, "Failed to save admin", "error", err)
+		return models.User{}, "", models.ErrUnexpected
+	}
+	entry.TargetID = user.ID
+
+	log.InfoContext(ctx, "Admin created", "ID", user.ID)
+	return user, tempPassword, nil
+}
+
+// Creates the configured administrator on startup unless the tenant already has an active one
+func (s *OperatorService) BootstrapAdmin(ctx context.Context, user models.User, password string) error {
+	const op = "OperatorService.BootstrapAdmin"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", user.TenantID),
+	)
+
+	if user.Email == "" || password == "" {
+		log.WarnContext(ctx, "Admin credentials are not configured, skipping bootstrap")
+		return nil
+	}
+
+	page, err := s.UserDal.ListUsers(ctx, models.UserFilter{TenantID: user.TenantID, Role: models.AdminRole, Status: models.StatusActive, Limit: 1})
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list admins", "error", err)
+		return models.ErrUnexpected
+	}
+	if len(page.Users) > 0 {
+		log.InfoContext(ctx, "Tenant already has admin, skipping bootstrap")
+		return nil
+	}
+
+	// Пользователь с этим email мог быть понижен, повторно не назначаем
+	if _, _, err = s.CreateAdmin(ctx, user, password); errors.Is(err, models.ErrNotUniqueEmail) {
+		log.WarnContext(ctx, "Admin email is taken by other user, use `admin promote`")
+		return nil
+	}
+	return err
+}
+
+// Grants admin rights to the user with the admin role
+func (s *OperatorService) PromoteAdmin(ctx context.Context, tenantID, email string) error {
+	return s.setAdmin(ctx, "OperatorService.PromoteAdmin", tenantID, email, true)
+}
+
+// Revokes admin rights, the user gets the user role. The last active admin of the tenant can't be demoted
+func (s *OperatorService) DemoteAdmin(ctx context.Context, tenantID, email string) error {
+	return s.setAdmin(ctx, "OperatorService.DemoteAdmin", tenantID, email, false)
+}
+
+func (s *OperatorService) setAdmin(ctx context.Context, op, tenantID, email string, isAdmin bool) (err error) {
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", tenantID),
+		slog.String("email", email),
+	)
+
+	role := models.UserRole
+	if isAdmin {
+		role = models.AdminRole
+	}
+	entry := models.AuditEntry{TenantID: tenantID, Action: models.AuditUserUpdate, Details: "role: " + role}
+	defer func() { s.AuditServ.Record(ctx, entry, operatorMeta(), err) }()
+
+	user, err := s.getUser(ctx, tenantID, email)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return err
+	}
+	entry.TargetID = user.ID
+
+	if user.IsAdmin == isAdmin && user.Role == role {
+		log.InfoContext(ctx, "User already has the role", "role", role)
+		return nil
+	}
+	if isAdmin && user.Status != models.StatusActive {
+		log.ErrorContext(ctx, "User is not active", "status", user.Status)
+		return models.ErrUserInactive
+	}
+
+	events := []models.UserEvent{{Type: models.EventUserUpdated}}
+	if user.Role != role {
+		events = append(events, models.UserEvent{Type: models.EventUserRoleChanged, Extra: map[string]string{"previous_role": user.Role}})
+	}
+
+	if err := s.UserDal.SetAdmin(ctx, tenantID, user.ID, isAdmin, role, events...); err != nil {
+		if errors.Is(err, models.ErrLastAdmin) {
+			log.ErrorContext(ctx, "Attempt to demote the last admin")
+			return models.ErrLastAdmin
+		}
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "User is not exist")
+			return repo.ErrUserNotExist
+		}
+		log.ErrorContext(ctx, "Failed to update admin rights", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "User role updated", "role", role)
+	return nil
+}
+
+// Calls fn for every user matching the filter
+func (s *OperatorService) ListUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	const op = "OperatorService.ListUsers"
+
+	if err := s.UserDal.ExportUsers(ctx, filter, fn); err != nil {
+		s.log.ErrorContext(ctx, "Failed to list users", "op", op, "error", err)
+		return models.ErrUnexpected
+	}
+	return nil
+}
+
+// Sets user password. Without password a temporary one is generated and returned,
+// it must be changed at next login
+func (s *OperatorService) ResetPassword(ctx context.Context, tenantID, email, password string) (tempPassword string, err error) {
+	const op = "OperatorService.ResetPassword"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", tenantID),
+		slog.String("email", email),
+	)
+
+	entry := models.AuditEntry{TenantID: tenantID, Action: models.AuditUserPassword}
+	defer func() { s.AuditServ.Record(ctx, entry, operatorMeta(), err) }()
+
+	user, err := s.getUser(ctx, tenantID, email)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return "", err
+	}
+	entry.TargetID = user.ID
+
+	tenant, err := s.AuthServ.getTenant(ctx, tenantID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get tenant", "error", err)
+		return "", err
+	}
+
+	if password == "" {
+		if tempPassword, err = generatePassword(s.AuthServ.passwordPolicyOf(tenant)); err != nil {
+			log.ErrorContext(ctx, "Failed to generate password", "error", err)
+			return "", models.ErrUnexpected
+		}
+		password = tempPassword
+	}
+
+	hashedPass, err := s.AuthServ.hashPassword(ctx, tenant, password)
+	if err != nil {
+		log.ErrorContext(ctx, "Password is invalid", "error", err)
+		return "", err
+	}
+
+	if err := s.UserDal.UpdatePassword(ctx, tenantID, user.ID, hashedPass, tempPassword != ""); err != nil {
+		log.ErrorContext(ctx, "Failed to update password", "error", err)
+		return "", models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Password reset", "ID", user.ID, "temporary", tempPassword != "")
+	return tempPassword, nil
+}
+
+// Disables active user, disabled user cannot login or use issued tokens
+func (s *OperatorService) DisableUser(ctx context.Context, tenantID, email string) (err error) {
+	const op = "OperatorService.DisableUser"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("tenant", tenantID),
+		slog.String("email", email),
+	)
+
+	entry := models.AuditEntry{TenantID: tenantID, Action: models.AuditUserDisable}
+	defer func() { s.AuditServ.Record(ctx, entry, operatorMeta(), err) }()
+
+	user, err := s.getUser(ctx, tenantID, email)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return err
+	}
+	entry.TargetID = user.ID
+
+	if err := s.UserDal.UpdateStatus(ctx, tenantID, user.ID, []string{models.StatusActive}, models.StatusDisabled,
+		models.UserEvent{Type: models.EventUserDisabled}); err != nil {
+		if errors.Is(err, models.ErrLastAdmin) {
+			log.ErrorContext(ctx, "Attempt to disable the last admin")
+			return models.ErrLastAdmin
+		}
+		if errors.Is(err, models.ErrStatusTransition) {
+			log.ErrorContext(ctx, "Status transition is not allowed")
+			return models.ErrStatusTransition
+		}
+		log.ErrorContext(ctx, "Failed to update user status", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "User disabled", "ID", user.ID)
+	return nil
+}
+
+// Creates new signing key for tokens. Previous keys verify already issued tokens during grace period
+func (s *OperatorService) RotateKey(ctx context.Context, grace time.Duration) (models.SigningKey, error) {
+	const op = "OperatorService.RotateKey"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	id, secret := make([]byte, 8), make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		log.ErrorContext(ctx, "Failed to generate key id", "error", err)
+		return models.SigningKey{}, models.ErrUnexpected
+	}
+	if _, err := rand.Read(secret); err != nil {
+		log.ErrorContext(ctx, "Failed to generate key", "error", err)
+		return models.SigningKey{}, models.ErrUnexpected
+	}
+
+	key := models.SigningKey{ID: hex.EncodeToString(id), Secret: secret}
+	if err := s.KeyDal.RotateKey(ctx, &key, grace); err != nil {
+		log.ErrorContext(ctx, "Failed to save key", "error", err)
+		return models.SigningKey{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Signing key rotated", "kid", key.ID, "grace", grace)
+	return key, nil
+}
+
+func (s *OperatorService) getUser(ctx context.Context, tenantID, email string) (models.User, error) {
+	user, err := s.UserDal.GetUser(ctx, tenantID, email)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return models.User{}, repo.ErrUserNotExist
+		}
+		return models.User{}, models.ErrUnexpected
+	}
+	return user, nil
+}
+
+func operatorMeta() models.RequestMeta {
+	return models.RequestMeta{UserAgent: operatorAgent}
+}
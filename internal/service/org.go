@@ -0,0 +1,427 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type OrgService struct {
+	OrgDal    ports.OrgRepo
+	UserDal   ports.UserRepo
+	AuthServ  *AuthService
+	TokenServ *TokenService
+	AuditServ *AuditService
+	notifier  ports.Notifier
+	inviteURL string
+	inviteTTL time.Duration
+	log       *slog.Logger
+}
+
+func NewOrgService(OrgDal ports.OrgRepo, UserDal ports.UserRepo, AuthServ *AuthService, TokenServ *TokenService, AuditServ *AuditService, notifier ports.Notifier, inviteURL string, inviteTTL time.Duration, log *slog.Logger) *OrgService {
+	return &OrgService{
+		OrgDal:    OrgDal,
+		UserDal:   UserDal,
+		AuthServ:  AuthServ,
+		TokenServ: TokenServ,
+		AuditServ: AuditServ,
+		notifier:  notifier,
+		inviteURL: inviteURL,
+		inviteTTL: inviteTTL,
+		log:       log,
+	}
+}
+
+// Creates organization, the caller becomes its owner
+func (s *OrgService) CreateOrg(ctx context.Context, name, access string) (models.Organization, error) {
+	const op = "OrgService.CreateOrg"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("name", name),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.Organization{}, models.ErrInvalidToken
+	}
+
+	org := models.Organization{
+		TenantID: claims.TenantID,
+		Name:     name,
+		OwnerID:  claims.ID,
+	}
+	if err := s.OrgDal.CreateOrg(ctx, &org); err != nil {
+		log.ErrorContext(ctx, "Failed to create organization", "error", err)
+		return models.Organization{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Organization created", "ID", org.ID, "owner", org.OwnerID)
+	return org, nil
+}
+
+// Creates invitation and sends signed link to the invitee email. Admins can be invited only by the owner
+func (s *OrgService) Invite(ctx context.Context, orgID int, email, role, access string, meta models.RequestMeta) (_ models.Invitation, err error) {
+	const op = "OrgService.Invite"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("org", orgID),
+		slog.String("email", email),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditOrgInvite, Details: fmt.Sprintf("org: %d, role: %s", orgID, role)}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, org, actor, err := s.authorize(ctx, orgID, access, true)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return models.Invitation{}, err
+	}
+
+	// Админов назначает только владелец, в том числе через приглашение
+	if role == models.OrgAdminRole && actor.Role != models.OrgOwnerRole {
+		log.ErrorContext(ctx, "Only owner can invite admins")
+		return models.Invitation{}, models.ErrPermissionDenied
+	}
+
+	// Проверяем не состоит ли пользователь уже в организации
+	if user, err := s.UserDal.GetUser(ctx, org.TenantID, email); err == nil {
+		entry.TargetID = user.ID
+		if _, err := s.OrgDal.GetMembership(ctx, orgID, user.ID); err == nil {
+			log.ErrorContext(ctx, "User is already a member")
+			return models.Invitation{}, models.ErrAlreadyMember
+		} else if !errors.Is(err, repo.ErrMemberNotExist) {
+			log.ErrorContext(ctx, "Failed to check membership", "error", err)
+			return models.Invitation{}, models.ErrUnexpected
+		}
+	} else if !errors.Is(err, repo.ErrUserNotExist) {
+		log.ErrorContext(ctx, "Failed to check user", "error", err)
+		return models.Invitation{}, models.ErrUnexpected
+	}
+
+	inv := models.Invitation{
+		OrgID:      orgID,
+		Email:      email,
+		Role:       role,
+		InvitedBy:  claims.ID,
+		Expires_At: time.Now().Add(s.inviteTTL),
+	}
+	if err := s.OrgDal.SaveInvitation(ctx, &inv); err != nil {
+		log.ErrorContext(ctx, "Failed to save invitation", "error", err)
+		return models.Invitation{}, models.ErrUnexpected
+	}
+
+	// Подписанная ссылка содержит только ID приглашения, состояние хранится в БД
+	token, err := s.TokenServ.SignAction(models.InvitationPurpose, jwt.MapClaims{
+		"inv":       inv.ID,
+		"tenant_id": org.TenantID,
+	}, s.inviteTTL)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to sign invitation", "error", err)
+		return models.Invitation{}, err
+	}
+
+	if err := s.notifier.SendInvitation(email, org, s.inviteURL+"?token="+url.QueryEscape(token)); err != nil {
+		log.ErrorContext(ctx, "Failed to send invitation", "error", err)
+		return models.Invitation{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Invitation sent", "ID", inv.ID)
+	return inv, nil
+}
+
+// Accepts invitation. Invitee is identified by access token, by password of the existing
+// account with invitation email, or is registered with the given name and password.
+func (s *OrgService) AcceptInvitation(ctx context.Context, token, access, name, password string, meta models.RequestMeta) (models.Membership, error) {
+	const op = "OrgService.AcceptInvitation"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	invClaims, err := s.TokenServ.ParseAction(models.InvitationPurpose, token)
+	if err != nil {
+		log.ErrorContext(ctx, "Invitation token is invalid", "error", err)
+		return models.Membership{}, models.ErrInvitationInvalid
+	}
+
+	invID, ok := invClaims["inv"].(float64)
+	tenantID, _ := invClaims["tenant_id"].(string)
+	if !ok {
+		return models.Membership{}, models.ErrInvitationInvalid
+	}
+
+	inv, err := s.OrgDal.GetInvitation(ctx, int(invID))
+	if err != nil {
+		if errors.Is(err, repo.ErrInvitationNotExist) {
+			log.ErrorContext(ctx, "Invitation is not exist")
+			return models.Membership{}, models.ErrInvitationInvalid
+		}
+		log.ErrorContext(ctx, "Failed to get invitation", "error", err)
+		return models.Membership{}, models.ErrUnexpected
+	}
+
+	if !inv.IsPending() {
+		log.ErrorContext(ctx, "Invitation is not pending", "ID", inv.ID)
+		return models.Membership{}, models.ErrInvitationInvalid
+	}
+
+	org, err := s.OrgDal.GetOrg(ctx, tenantID, inv.OrgID)
+	if err != nil {
+		if errors.Is(err, repo.ErrOrgNotExist) {
+			log.ErrorContext(ctx, "Organization is not exist")
+			return models.Membership{}, models.ErrInvitationInvalid
+		}
+		log.ErrorContext(ctx, "Failed to get organization", "error", err)
+		return models.Membership{}, models.ErrUnexpected
+	}
+
+	user, err := s.resolveInvitee(ctx, org, inv, access, name, password, meta)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to resolve invitee", "error", err)
+		return models.Membership{}, err
+	}
+	registering := user.ID == 0
+
+	// Регистрация, использование приглашения и членство сохраняются одной транзакцией,
+	// повторно принять приглашение нельзя
+	err = s.OrgDal.AcceptInvitation(ctx, inv, &user, models.UserEvent{Type: models.EventUserRegistered})
+	if registering {
+		s.AuthServ.recordRegister(ctx, org.TenantID, user.ID, meta, err)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvitationInvalid):
+			return models.Membership{}, models.ErrInvitationInvalid
+		case errors.Is(err, models.ErrAlreadyMember):
+			return models.Membership{}, models.ErrAlreadyMember
+		case errors.Is(err, models.ErrNotUniqueEmail):
+			return models.Membership{}, models.ErrNotUniqueEmail
+		}
+		log.ErrorContext(ctx, "Failed to accept invitation", "error", err)
+		return models.Membership{}, models.ErrUnexpected
+	}
+
+	member, err := s.OrgDal.GetMembership(ctx, org.ID, user.ID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get membership", "error", err)
+		return models.Membership{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Invitation accepted", "ID", inv.ID, "user", user.ID)
+	return member, nil
+}
+
+func (s *OrgService) RevokeInvitation(ctx context.Context, orgID, invitationID int, access string, meta models.RequestMeta) (err error) {
+	const op = "OrgService.RevokeInvitation"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("org", orgID),
+		slog.Int("ID", invitationID),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditOrgRevoke, Details: fmt.Sprintf("org: %d, invitation: %d", orgID, invitationID)}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, _, _, err := s.authorize(ctx, orgID, access, true)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return err
+	}
+
+	if err := s.OrgDal.RevokeInvitation(ctx, orgID, invitationID); err != nil {
+		if errors.Is(err, repo.ErrInvitationNotExist) {
+			log.ErrorContext(ctx, "Pending invitation is not exist")
+			return repo.ErrInvitationNotExist
+		}
+		log.ErrorContext(ctx, "Failed to revoke invitation", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Invitation revoked")
+	return nil
+}
+
+func (s *OrgService) ListInvitations(ctx context.Context, orgID int, access string) ([]models.Invitation, error) {
+	const op = "OrgService.ListInvitations"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("org", orgID),
+	)
+
+	if _, _, _, err := s.authorize(ctx, orgID, access, true); err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return nil, err
+	}
+
+	invitations, err := s.OrgDal.ListInvitations(ctx, orgID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list invitations", "error", err)
+		return nil, models.ErrUnexpected
+	}
+	return invitations, nil
+}
+
+// Lists organization members, available to any member
+func (s *OrgService) ListMembers(ctx context.Context, orgID int, access string) ([]models.Membership, error) {
+	const op = "OrgService.ListMembers"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("org", orgID),
+	)
+
+	if _, _, _, err := s.authorize(ctx, orgID, access, false); err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return nil, err
+	}
+
+	members, err := s.OrgDal.ListMembers(ctx, orgID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list members", "error", err)
+		return nil, models.ErrUnexpected
+	}
+	return members, nil
+}
+
+// Changes org-level role of the member. Owner role cannot be changed,
+// admins can be appointed and demoted only by the owner.
+func (s *OrgService) UpdateMemberRole(ctx context.Context, orgID, userID int, role, access string, meta models.RequestMeta) (err error) {
+	const op = "OrgService.UpdateMemberRole"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("org", orgID),
+		slog.Int("user", userID),
+		slog.String("role", role),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditOrgRole, TargetID: userID, Details: fmt.Sprintf("org: %d, role: %s", orgID, role)}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, _, actor, err := s.authorize(ctx, orgID, access, true)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return err
+	}
+
+	target, err := s.OrgDal.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrMemberNotExist) {
+			log.ErrorContext(ctx, "Member is not exist")
+			return repo.ErrMemberNotExist
+		}
+		log.ErrorContext(ctx, "Failed to get member", "error", err)
+		return models.ErrUnexpected
+	}
+
+	if target.Role == models.OrgOwnerRole || role == models.OrgOwnerRole {
+		log.ErrorContext(ctx, "Attempt to change owner role")
+		return models.ErrCannotChangeOwner
+	}
+
+	if actor.Role != models.OrgOwnerRole && (target.Role == models.OrgAdminRole || role == models.OrgAdminRole) {
+		log.ErrorContext(ctx, "Only owner can manage admins")
+		return models.ErrPermissionDenied
+	}
+
+	if err := s.OrgDal.UpdateMemberRole(ctx, orgID, userID, role); err != nil {
+		if errors.Is(err, repo.ErrMemberNotExist) {
+			return repo.ErrMemberNotExist
+		}
+		log.ErrorContext(ctx, "Failed to update member role", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Member role updated")
+	return nil
+}
+
+// Validates token and returns caller membership in the organization, with manage flag caller must be
+// owner or admin of it. Claims of the valid token are returned even if access is denied
+func (s *OrgService) authorize(ctx context.Context, orgID int, access string, manage bool) (models.CustomClaims, models.Organization, models.Membership, error) {
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		return models.CustomClaims{}, models.Organization{}, models.Membership{}, models.ErrInvalidToken
+	}
+
+	// Организации других тенантов не видны
+	org, err := s.OrgDal.GetOrg(ctx, claims.TenantID, orgID)
+	if err != nil {
+		if errors.Is(err, repo.ErrOrgNotExist) {
+			return claims, models.Organization{}, models.Membership{}, repo.ErrOrgNotExist
+		}
+		s.log.ErrorContext(ctx, "Failed to get organization", "error", err)
+		return claims, models.Organization{}, models.Membership{}, models.ErrUnexpected
+	}
+
+	member, err := s.OrgDal.GetMembership(ctx, orgID, claims.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrMemberNotExist) {
+			return claims, models.Organization{}, models.Membership{}, models.ErrPermissionDenied
+		}
+		s.log.ErrorContext(ctx, "Failed to get membership", "error", err)
+		return claims, models.Organization{}, models.Membership{}, models.ErrUnexpected
+	}
+
+	if manage && !member.CanManage() {
+		return claims, models.Organization{}, models.Membership{}, models.ErrPermissionDenied
+	}
+	return claims, org, member, nil
+}
+
+// Returns invitee account. Account of a new user is returned without ID and is saved on accept
+func (s *OrgService) resolveInvitee(ctx context.Context, org models.Organization, inv models.Invitation, access, name, password string, meta models.RequestMeta) (models.User, error) {
+	// Пользователь уже вошел в систему
+	if access != "" {
+		claims, err := s.TokenServ.Validate(ctx, access)
+		if err != nil {
+			return models.User{}, models.ErrInvalidToken
+		}
+		if claims.TenantID != org.TenantID || !strings.EqualFold(claims.Email, inv.Email) {
+			return models.User{}, models.ErrInvitationEmail
+		}
+		return models.User{ID: claims.ID, TenantID: claims.TenantID, Email: claims.Email}, nil
+	}
+
+	existUser, err := s.UserDal.GetUser(ctx, org.TenantID, inv.Email)
+	switch {
+	case err == nil:
+		// Привязываем существующий аккаунт после проверки пароля
+		if err := bcryptCompare(ctx, existUser.GetPassword(), password); err != nil {
+			return models.User{}, models.ErrInvalidCredentials
+		}
+		if existUser.Status != models.StatusActive {
+			return models.User{}, models.ErrUserInactive
+		}
+		return existUser, nil
+	case errors.Is(err, repo.ErrUserNotExist):
+		if name == "" {
+			return models.User{}, models.ErrEmptyName
+		}
+		user, err := s.AuthServ.newUser(ctx, models.User{
+			TenantID: org.TenantID,
+			Name:     name,
+			Email:    inv.Email,
+			Role:     models.UserRole,
+		}, password)
+		if err != nil {
+			s.AuthServ.recordRegister(ctx, org.TenantID, 0, meta, err)
+			return models.User{}, err
+		}
+		return user, nil
+	default:
+		s.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.User{}, models.ErrUnexpected
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"crypto/rand"
+	"math/big"
+)
+
+const (
+	tempPasswordLength = 16
+
+	lowerChars  = "abcdefghijkmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	digitChars  = "23456789"
+	symbolChars = "!#$%&*+-=?@_"
+)
+
+// Generates random password satisfying the policy. Look-alike characters are excluded
+func generatePassword(policy models.PasswordPolicy) (string, error) {
+	length := max(tempPasswordLength, policy.MinLength)
+
+	// По одному символу каждого класса, остальное из общего алфавита
+	classes := []string{lowerChars, upperChars, digitChars, symbolChars}
+	all := lowerChars + upperChars + digitChars + symbolChars
+
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Перемешиваем, чтобы классы символов не стояли на фиксированных позициях
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
+
+func randomChar(alphabet string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+	if err != nil {
+		return 0, err
+	}
+	return alphabet[n.Int64()], nil
+}
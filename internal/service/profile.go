@@ -0,0 +1,192 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Self-service management of the own profile
+type ProfileService struct {
+	UserDal    ports.UserRepo
+	TokenServ  *TokenService
+	notifier   ports.Notifier
+	confirmURL string
+	confirmTTL time.Duration
+	log        *slog.Logger
+}
+
+func NewProfileService(UserDal ports.UserRepo, TokenServ *TokenService, notifier ports.Notifier, confirmURL string, confirmTTL time.Duration, log *slog.Logger) *ProfileService {
+	return &ProfileService{
+		UserDal:    UserDal,
+		TokenServ:  TokenServ,
+		notifier:   notifier,
+		confirmURL: confirmURL,
+		confirmTTL: confirmTTL,
+		log:        log,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, access string) (models.User, error) {
+	const op = "ProfileService.GetProfile"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.User{}, models.ErrInvalidToken
+	}
+
+	return s.getUser(ctx, claims.TenantID, claims.ID)
+}
+
+// Updates profile fields. New email is not applied here: confirmation link is sent to it
+// and the returned pending email is empty when email is not changed
+func (s *ProfileService) UpdateProfile(ctx context.Context, update models.ProfileUpdate, access string) (models.User, string, error) {
+	const op = "ProfileService.UpdateProfile"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Access token is invalid", "error", err)
+		return models.User{}, "", models.ErrInvalidToken
+	}
+	log = log.With(slog.Int("ID", claims.ID))
+
+	user, err := s.getUser(ctx, claims.TenantID, claims.ID)
+	if err != nil {
+		return models.User{}, "", err
+	}
+
+	// Занятый email отклоняет все изменение, поля профиля не сохраняются
+	emailChanged := update.Email != nil && !strings.EqualFold(*update.Email, user.Email)
+	if emailChanged {
+		if err := s.checkEmail(ctx, user.TenantID, *update.Email); err != nil {
+			log.ErrorContext(ctx, "Failed to check new email", "error", err)
+			return models.User{}, "", err
+		}
+	}
+
+	if update.HasFields() {
+		if err := s.UserDal.UpdateProfile(ctx, claims.TenantID, claims.ID, update, models.UserEvent{Type: models.EventUserUpdated}); err != nil {
+			if errors.Is(err, repo.ErrUserNotExist) {
+				log.ErrorContext(ctx, "User is not exist")
+				return models.User{}, "", repo.ErrUserNotExist
+			}
+			log.ErrorContext(ctx, "Failed to update profile", "error", err)
+			return models.User{}, "", models.ErrUnexpected
+		}
+		log.InfoContext(ctx, "Profile updated")
+
+		if user, err = s.getUser(ctx, claims.TenantID, claims.ID); err != nil {
+			return models.User{}, "", err
+		}
+	}
+
+	// Смена email подтверждается по ссылке, отправленной на новый адрес
+	var pendingEmail string
+	if emailChanged {
+		if err := s.requestEmailChange(ctx, user, *update.Email); err != nil {
+			log.ErrorContext(ctx, "Failed to request email change", "error", err)
+			return models.User{}, "", err
+		}
+		pendingEmail = *update.Email
+	}
+
+	return user, pendingEmail, nil
+}
+
+// Applies email change from the confirmation link
+func (s *ProfileService) ConfirmEmail(ctx context.Context, token string) (models.User, error) {
+	const op = "ProfileService.ConfirmEmail"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := s.TokenServ.ParseAction(models.EmailChangePurpose, token)
+	if err != nil {
+		log.ErrorContext(ctx, "Confirmation token is invalid", "error", err)
+		return models.User{}, models.ErrInvalidToken
+	}
+
+	userID, ok := claims["ID"].(float64)
+	tenantID, _ := claims["tenant_id"].(string)
+	oldEmail, _ := claims["email"].(string)
+	newEmail, _ := claims["new_email"].(string)
+	if !ok || tenantID == "" || oldEmail == "" || newEmail == "" {
+		return models.User{}, models.ErrInvalidToken
+	}
+	log = log.With(slog.Int("ID", int(userID)))
+
+	// Email меняется только если не менялся после отправки ссылки, поэтому ссылка одноразовая
+	if err := s.UserDal.UpdateEmail(ctx, tenantID, int(userID), oldEmail, newEmail, models.UserEvent{Type: models.EventUserUpdated}); err != nil {
+		if errors.Is(err, models.ErrNotUniqueEmail) {
+			log.ErrorContext(ctx, "User email is not unique")
+			return models.User{}, models.ErrNotUniqueEmail
+		}
+		if errors.Is(err, repo.ErrUserNotExist) {
+			log.ErrorContext(ctx, "Email was already changed or user is not active")
+			return models.User{}, models.ErrInvalidToken
+		}
+		log.ErrorContext(ctx, "Failed to update email", "error", err)
+		return models.User{}, models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Email changed")
+	return s.getUser(ctx, tenantID, int(userID))
+}
+
+// Checks that email is not used in the tenant. It is checked again on confirmation
+func (s *ProfileService) checkEmail(ctx context.Context, tenantID, email string) error {
+	if _, err := s.UserDal.GetUser(ctx, tenantID, email); err == nil {
+		return models.ErrNotUniqueEmail
+	} else if !errors.Is(err, repo.ErrUserNotExist) {
+		s.log.ErrorContext(ctx, "Failed to check user uniqueness", "error", err)
+		return models.ErrUnexpected
+	}
+	return nil
+}
+
+func (s *ProfileService) requestEmailChange(ctx context.Context, user models.User, newEmail string) error {
+	token, err := s.TokenServ.SignAction(models.EmailChangePurpose, jwt.MapClaims{
+		"ID":        user.ID,
+		"tenant_id": user.TenantID,
+		"email":     user.Email,
+		"new_email": newEmail,
+	}, s.confirmTTL)
+	if err != nil {
+		return err
+	}
+
+	if err := s.notifier.SendEmailConfirmation(newEmail, s.confirmURL+"?token="+url.QueryEscape(token)); err != nil {
+		s.log.ErrorContext(ctx, "Failed to send email confirmation", "error", err)
+		return models.ErrUnexpected
+	}
+	return nil
+}
+
+func (s *ProfileService) getUser(ctx context.Context, tenantID string, userID int) (models.User, error) {
+	user, err := s.UserDal.GetUserByID(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return models.User{}, repo.ErrUserNotExist
+		}
+		s.log.ErrorContext(ctx, "Failed to get user", "error", err)
+		return models.User{}, models.ErrUnexpected
+	}
+	return user, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"auth/internal/domain/ports"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Max users purged by one transaction
+const purgeBatch = 500
+
+// Purger deletes users whose deletion retention window has passed
+type Purger struct {
+	UserDal   ports.UserRepo
+	Retention time.Duration
+	Interval  time.Duration
+	log       *slog.Logger
+}
+
+func NewPurger(UserDal ports.UserRepo, Retention, Interval time.Duration, log *slog.Logger) *Purger {
+	return &Purger{
+		UserDal:   UserDal,
+		Retention: Retention,
+		Interval:  Interval,
+		log:       log,
+	}
+}
+
+// Runs purge every interval until context is canceled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purges expired users batch by batch, returns count of purged users
+func (p *Purger) Purge(ctx context.Context) int {
+	const op = "Purger.Purge"
+	log := p.log.With(
+		slog.String("op", op),
+	)
+
+	deletedBefore := time.Now().Add(-p.Retention)
+	total := 0
+	for {
+		purged, err := p.UserDal.PurgeUsers(ctx, deletedBefore, purgeBatch)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to purge users", "error", err)
+			return total
+		}
+		total += purged
+		if purged < purgeBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.InfoContext(ctx, "Expired users purged", "count", total)
+	}
+	return total
+}
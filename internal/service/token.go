@@ -4,25 +4,94 @@ import (
 	"auth/internal/adapters/repo"
 	"auth/internal/domain/models"
 	"auth/internal/domain/ports"
+	"auth/pkg/logger"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	keysRefresh      = time.Minute     // Interval of reloading signing keys, new key starts signing after it
+	keysForceRefresh = 5 * time.Second // Min interval of reloads caused by unknown key id
+)
+
+var (
+	errUnknownKey    = errors.New("unknown signing key")
+	errKeysNotLoaded = errors.New("signing keys are not loaded")
+	errNoSigningKey  = errors.New("no signing key, rotate keys or set SECRET")
+	errSecretRetired = errors.New("configured secret is retired by key rotation")
+)
+
+// Rejected token with the reason reported by validation metrics. Callers see it as ErrInvalidToken
+type tokenError struct {
+	reason string
+}
+
+func (e tokenError) Error() string { return models.ErrInvalidToken.Error() }
+func (e tokenError) Unwrap() error { return models.ErrInvalidToken }
+
+// Returns reason of the token rejection by parse error of jwt
+func parseFailure(err error) tokenError {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return tokenError{reason: "expired"}
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return tokenError{reason: "not_valid_yet"}
+	case errors.Is(err, errUnknownKey), errors.Is(err, errSecretRetired):
+		return tokenError{reason: "unknown_key"}
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return tokenError{reason: "bad_signature"}
+	default:
+		return tokenError{reason: "malformed"}
+	}
+}
+
+// Returns label of validation failure
+func failureReason(err error) string {
+	var tokenErr tokenError
+	switch {
+	case errors.As(err, &tokenErr):
+		return tokenErr.reason
+	case errors.Is(err, models.ErrExpToken):
+		return "expired"
+	case errors.Is(err, models.ErrUserInactive):
+		return "user_inactive"
+	case errors.Is(err, models.ErrUnexpected):
+		return "error"
+	default:
+		return "malformed"
+	}
+}
+
 type TokenService struct {
+	KeyDal     ports.KeyRepo
 	UserDal    ports.UserRepo
+	TenantDal  ports.TenantRepo
 	RefreshTTL time.Duration
 	AccessTTL  time.Duration
 	log        *slog.Logger
 	secret     string
+
+	keysMu     sync.RWMutex
+	keys       map[string][]byte // Secrets of not retired keys by ID
+	currentKey string            // ID of the signing key, empty to sign with secret
+	secretOK   bool              // Configured secret still verifies tokens without kid
+	keysAt     time.Time
+	keysLoaded bool
 }
 
-func NewTokenService(secret string, UserDal ports.UserRepo, RefreshTTL time.Duration, AccessTTL time.Duration, log *slog.Logger) *TokenService {
+func NewTokenService(secret string, KeyDal ports.KeyRepo, UserDal ports.UserRepo, TenantDal ports.TenantRepo, RefreshTTL time.Duration, AccessTTL time.Duration, log *slog.Logger) *TokenService {
 	return &TokenService{
+		KeyDal:     KeyDal,
 		UserDal:    UserDal,
+		TenantDal:  TenantDal,
 		RefreshTTL: RefreshTTL,
 		AccessTTL:  AccessTTL,
 		secret:     secret,
@@ -30,99 +99,322 @@ func NewTokenService(secret string, UserDal ports.UserRepo, RefreshTTL time.Dura
 	}
 }
 
-func (s *TokenService) getSecret() string {
-	return s.secret
+// Signs claims with the current key and puts its ID into the kid header
+func (s *TokenService) sign(claims jwt.Claims) (string, error) {
+	s.loadKeys(false)
+
+	s.keysMu.RLock()
+	kid, secret := s.currentKey, s.keys[s.currentKey]
+	s.keysMu.RUnlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid == "" {
+		// Ключи еще не ротировались, подписываем секретом из конфигурации
+		return token.SignedString([]byte(s.secret))
+	}
+	token.Header["kid"] = kid
+	return token.SignedString(secret)
 }
 
-func (s *TokenService) GenerateTokens(user models.User) (models.TokenPair, error) {
+// Returns verification key by kid header, tokens without it are signed with the configured secret
+// until it is retired by key rotation
+func (s *TokenService) verifyKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		s.loadKeys(false)
+
+		s.keysMu.RLock()
+		defer s.keysMu.RUnlock()
+		// Пока ключи не загружены, токены подписываются секретом
+		if s.keysLoaded && !s.secretOK {
+			return nil, errSecretRetired
+		}
+		return []byte(s.secret), nil
+	}
+
+	secret, ok := s.key(kid)
+	if !ok {
+		// Ключ мог быть создан после последней загрузки
+		s.loadKeys(true)
+		if secret, ok = s.key(kid); !ok {
+			return nil, errUnknownKey
+		}
+	}
+	return secret, nil
+}
+
+func (s *TokenService) key(kid string) ([]byte, bool) {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+	secret, ok := s.keys[kid]
+	return secret, ok
+}
+
+// Reloads signing keys when they are older than keysRefresh, or keysForceRefresh if forced.
+// On failure previously loaded keys are kept
+func (s *TokenService) loadKeys(force bool) {
+	fresh := func() bool {
+		age := time.Since(s.keysAt)
+		// Пока ключи ни разу не загрузились, повторяем загрузку чаще
+		return age < keysForceRefresh || (!force && s.keysLoaded && age < keysRefresh)
+	}
+
+	s.keysMu.RLock()
+	skip := fresh()
+	s.keysMu.RUnlock()
+	if skip {
+		return
+	}
+
+	// Загрузку выполняет один вызов, остальные продолжают со старыми ключами
+	s.keysMu.Lock()
+	if fresh() {
+		s.keysMu.Unlock()
+		return
+	}
+	s.keysAt = time.Now()
+	s.keysMu.Unlock()
+
+	// Ключи общие для всех запросов, поэтому загрузка не зависит от контекста вызова
+	keys, err := s.KeyDal.ListKeys(context.Background())
+	if err != nil {
+		s.log.Error("Failed to load signing keys", "error", err)
+		return
+	}
+
+	// До первой ротации ключей нет и секрет подписывает токены, после нее действует до своего Retires_At
+	secrets, currentKey, secretOK := make(map[string][]byte, len(keys)), "", len(keys) == 0
+	for _, key := range keys {
+		if key.ID == models.SecretKeyID {
+			secretOK = true
+			continue
+		}
+		if currentKey == "" {
+			currentKey = key.ID
+		}
+		secrets[key.ID] = key.Secret
+	}
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+	s.keys, s.currentKey, s.secretOK, s.keysLoaded = secrets, currentKey, secretOK, true
+}
+
+// Returns error until signing keys are loaded from the database. Before the first
+// rotation tokens are signed with the configured secret, so it must be set
+func (s *TokenService) KeysLoaded() error {
+	s.loadKeys(false)
+
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+	if !s.keysLoaded {
+		return errKeysNotLoaded
+	}
+	if s.currentKey == "" && s.secret == "" {
+		return errNoSigningKey
+	}
+	return nil
+}
+
+func (s *TokenService) GenerateTokens(ctx context.Context, user models.User) (_ models.TokenPair, err error) {
 	const op = "TokenService.GenerateTokens"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 	)
 
+	// TTL токенов могут быть переопределены настройками тенанта
+	tenant, err := s.TenantDal.GetTenant(ctx, user.TenantID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to get tenant settings", "error", err)
+		return models.TokenPair{}, err
+	}
+	settings := tenant.Settings(models.TenantSettings{AccessTTL: s.AccessTTL, RefreshTTL: s.RefreshTTL})
+
+	// Id сессии связывает пару токенов с ее CSRF токеном, refresh начинает новую сессию
+	sid := make([]byte, 16)
+	if _, err := rand.Read(sid); err != nil {
+		log.ErrorContext(ctx, "Failed to generate session id", "error", err)
+		return models.TokenPair{}, err
+	}
+	sessionID := hex.EncodeToString(sid)
+
 	var signed []string
-	for _, claim := range []jwt.Claims{NewAccessClaim(user, s.AccessTTL), NewRefreshClaim(user, s.RefreshTTL)} {
+	for _, claim := range []jwt.Claims{NewAccessClaim(user, sessionID, settings.AccessTTL), NewRefreshClaim(user, sessionID, settings.RefreshTTL)} {
 		// Подпись каждого jwt токена
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
-		signedToken, err := token.SignedString([]byte(s.getSecret()))
+		signedToken, err := s.sign(claim)
 		if err != nil {
-			log.Error("Failed to sign string", "error", err)
+			log.ErrorContext(ctx, "Failed to sign string", "error", err)
 			return models.TokenPair{}, err
 		}
 		signed = append(signed, signedToken)
 	}
+
+	// CSRF токен живет столько же, сколько refresh токен сессии
+	csrfToken, err := s.CSRFToken(user, sessionID, settings.RefreshTTL)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to sign CSRF token", "error", err)
+		return models.TokenPair{}, err
+	}
 	return models.TokenPair{
-		AccessExpiresAt:  time.Now().Add(s.AccessTTL),
-		RefreshExpiresAt: time.Now().Add(s.RefreshTTL),
+		AccessExpiresAt:  time.Now().Add(settings.AccessTTL),
+		RefreshExpiresAt: time.Now().Add(settings.RefreshTTL),
 		AccessToken:      signed[0],
 		RefreshToken:     signed[1],
+		CSRFToken:        csrfToken,
 	}, nil
 }
 
-func NewAccessClaim(user models.User, accessTTL time.Duration) jwt.Claims {
+func NewAccessClaim(user models.User, sessionID string, accessTTL time.Duration) jwt.Claims {
 	return jwt.MapClaims{
 		"ID":         user.ID,
+		"tenant_id":  user.TenantID,
 		"name":       user.Name,
 		"email":      user.Email,
 		"is_admin":   user.IsAdmin,
 		"is_refresh": false,
 		"role":       user.Role,
+		"sid":        sessionID,
 		"exp":        time.Now().Add(accessTTL).Unix(),
 	}
 }
 
-func NewRefreshClaim(user models.User, refreshTTL time.Duration) jwt.Claims {
+func NewRefreshClaim(user models.User, sessionID string, refreshTTL time.Duration) jwt.Claims {
 	return jwt.MapClaims{
 		"ID":         user.ID,
+		"tenant_id":  user.TenantID,
 		"name":       user.Name,
 		"email":      user.Email,
 		"is_admin":   user.IsAdmin,
 		"is_refresh": true,
 		"role":       user.Role,
+		"sid":        sessionID,
 		"exp":        time.Now().Add(refreshTTL).Unix(),
 	}
 }
 
-func (s *TokenService) Refresh(refreshToken string) (models.TokenPair, error) {
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (_ models.TokenPair, err error) {
 	const op = "TokenService.RefreshToken"
+	ctx, span := startSpan(ctx, op)
+	defer func() { endSpan(span, err) }()
 	log := s.log.With(
 		slog.String("op", op),
 	)
-	log.Info("Token refresh started")
+	log.InfoContext(ctx, "Token refresh started")
 
-	claims, err := s.Validate(refreshToken)
+	claims, err := s.Validate(ctx, refreshToken)
 	if err != nil {
-		log.Error("Refresh token is invalid", "error", err)
+		if errors.Is(err, models.ErrUserInactive) {
+			log.ErrorContext(ctx, "User is not active")
+			return models.TokenPair{}, models.ErrUserInactive
+		}
+		log.ErrorContext(ctx, "Refresh token is invalid", "error", err)
+		return models.TokenPair{}, models.ErrInvalidToken
+	}
+	// Короткоживущий access токен не продлевает сессию
+	if !claims.IsRefresh {
+		validationFailures.WithLabelValues("wrong_type").Inc()
+		log.ErrorContext(ctx, "Token is not refresh token")
 		return models.TokenPair{}, models.ErrInvalidToken
 	}
 
-	// Проверяем существует ли пользователь
-	user, err := s.UserDal.GetUser(claims.Email)
+	// Пользователь ищется по ID: email в токене мог устареть и уже принадлежать другому аккаунту
+	user, err := s.UserDal.GetUserByID(ctx, claims.TenantID, claims.ID)
 	if err != nil {
 		if errors.Is(err, repo.ErrUserNotExist) {
-			log.Error("User is not exist")
+			log.ErrorContext(ctx, "User is not exist")
 			return models.TokenPair{}, repo.ErrUserNotExist
 		}
-		log.Error("Failed to check user uniqueness", "error", err)
+		log.ErrorContext(ctx, "Failed to get user", "error", err)
 		return models.TokenPair{}, models.ErrUnexpected
 	}
 
-	pair, err := s.GenerateTokens(user)
+	pair, err := s.GenerateTokens(ctx, user)
 	if err != nil {
-		log.Error("Failed to generate tokens", "error", err)
+		log.ErrorContext(ctx, "Failed to generate tokens", "error", err)
 		return models.TokenPair{}, models.ErrUnexpected
 	}
 
 	return pair, nil
 }
 
-func (s *TokenService) Validate(token string) (models.CustomClaims, error) {
-	parsedToken, err := jwt.ParseWithClaims(token, jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(s.getSecret()), nil
-	})
+// Token validated by Authenticate earlier in the request
+type authenticatedKey struct{}
+
+type authenticated struct {
+	token  string
+	claims models.CustomClaims
+}
+
+// Validates access token of the request. Returned context carries the result, so services validating
+// the same token during the request don't query its owner again
+func (s *TokenService) Authenticate(ctx context.Context, token string) (context.Context, models.CustomClaims, error) {
+	claims, err := s.Validate(ctx, token)
+	if err != nil {
+		return ctx, models.CustomClaims{}, err
+	}
+
+	// Долгоживущий refresh токен обменивается только на новую пару и не заменяет access токен
+	if claims.IsRefresh {
+		err := tokenError{reason: "wrong_type"}
+		validationFailures.WithLabelValues(err.reason).Inc()
+		return ctx, models.CustomClaims{}, err
+	}
+	return context.WithValue(ctx, authenticatedKey{}, authenticated{token: token, claims: claims}), claims, nil
+}
+
+// Validates token and checks that its owner is still active, so disabled and deleted users lose access immediately
+func (s *TokenService) Validate(ctx context.Context, token string) (_ models.CustomClaims, err error) {
+	if auth, ok := ctx.Value(authenticatedKey{}).(authenticated); ok && auth.token == token {
+		return auth.claims, nil
+	}
+
+	ctx, span := startSpan(ctx, "TokenService.Validate")
+	defer func() {
+		if err != nil {
+			validationFailures.WithLabelValues(failureReason(err)).Inc()
+		}
+		endSpan(span, err)
+	}()
+
+	claims, err := s.Claims(token)
+	if err != nil {
+		return models.CustomClaims{}, err
+	}
+
+	// Проверяем статус владельца токена
+	user, err := s.UserDal.GetUserByID(ctx, claims.TenantID, claims.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotExist) {
+			return models.CustomClaims{}, tokenError{reason: "unknown_user"}
+		}
+		s.log.ErrorContext(ctx, "Failed to get token owner", "error", err)
+		return models.CustomClaims{}, models.ErrUnexpected
+	}
+	if user.Status != models.StatusActive {
+		return models.CustomClaims{}, models.ErrUserInactive
+	}
+	// Права берутся из БД, чтобы понижение админа действовало сразу, а не после истечения токена
+	claims.IsAdmin, claims.Role = user.IsAdmin, user.Role
+
+	// Владелец токена попадает в журнал доступа
+	logger.SetPrincipal(ctx, principal(claims.TenantID, claims.ID))
+	return claims, nil
+}
+
+// Returns principal of the access log line
+func principal(tenantID string, userID int) string {
+	return fmt.Sprintf("%s/%d", tenantID, userID)
+}
+
+// Parses signed token without checking its owner. Used to attribute requests, not to authorize them
+func (s *TokenService) Claims(token string) (models.CustomClaims, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, jwt.MapClaims{}, s.verifyKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		s.log.Error("Failed to parse with claims", "error", err)
-		return models.CustomClaims{}, models.ErrInvalidToken
+		return models.CustomClaims{}, parseFailure(err)
 	}
 
 	if !parsedToken.Valid {
@@ -134,6 +426,11 @@ func (s *TokenService) Validate(token string) (models.CustomClaims, error) {
 		return models.CustomClaims{}, models.ErrInvalidToken
 	}
 
+	// Токены действий (приглашения и т.п.) не являются токенами доступа
+	if _, ok := mapClaims["purpose"]; ok {
+		return models.CustomClaims{}, models.ErrInvalidToken
+	}
+
 	var claims models.CustomClaims
 	var invOrMissingForm string = "invalid or missing '%s' in token claims"
 
@@ -144,6 +441,12 @@ func (s *TokenService) Validate(token string) (models.CustomClaims, error) {
 		return models.CustomClaims{}, fmt.Errorf(invOrMissingForm, "ID")
 	}
 
+	if tenantID, ok := mapClaims["tenant_id"].(string); ok && tenantID != "" {
+		claims.TenantID = tenantID
+	} else {
+		return models.CustomClaims{}, fmt.Errorf(invOrMissingForm, "tenant_id")
+	}
+
 	if role, ok := mapClaims["role"].(string); ok {
 		claims.Role = role
 	} else {
@@ -177,6 +480,9 @@ func (s *TokenService) Validate(token string) (models.CustomClaims, error) {
 		return models.CustomClaims{}, fmt.Errorf(invOrMissingForm, "is_refresh")
 	}
 
+	// Id сессии отсутствует у токенов, выпущенных до его появления
+	claims.SessionID, _ = mapClaims["sid"].(string)
+
 	// Извлекаем exp и проверяем время
 	expFloat, ok := mapClaims["exp"].(float64)
 	if !ok {
@@ -191,3 +497,31 @@ func (s *TokenService) Validate(token string) (models.CustomClaims, error) {
 
 	return claims, nil
 }
+
+// Signs short-lived token for a single action (invitation links, confirmations)
+func (s *TokenService) SignAction(purpose string, claims jwt.MapClaims, ttl time.Duration) (string, error) {
+	claims["purpose"] = purpose
+	claims["exp"] = time.Now().Add(ttl).Unix()
+
+	signed, err := s.sign(claims)
+	if err != nil {
+		s.log.Error("Failed to sign action token", "purpose", purpose, "error", err)
+		return "", models.ErrTokenGenerateFail
+	}
+	return signed, nil
+}
+
+// Parses action token and checks that it was issued for the purpose
+func (s *TokenService) ParseAction(purpose, token string) (jwt.MapClaims, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, jwt.MapClaims{}, s.verifyKey, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || !parsedToken.Valid {
+		s.log.Error("Failed to parse action token", "purpose", purpose, "error", err)
+		return nil, models.ErrInvalidToken
+	}
+
+	claims, ok := parsedToken.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != purpose {
+		return nil, models.ErrInvalidToken
+	}
+	return claims, nil
+}
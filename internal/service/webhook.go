@@ -0,0 +1,198 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/domain/ports"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Max deliveries returned by one listing
+const MaxDeliveriesPage = 100
+
+// Management of the tenant webhooks by administrators
+type WebhookService struct {
+	WebhookDal ports.WebhookRepo
+	TokenServ  ports.TokenService
+	AuditServ  *AuditService
+	log        *slog.Logger
+}
+
+func NewWebhookService(WebhookDal ports.WebhookRepo, TokenServ ports.TokenService, AuditServ *AuditService, log *slog.Logger) *WebhookService {
+	return &WebhookService{
+		WebhookDal: WebhookDal,
+		TokenServ:  TokenServ,
+		AuditServ:  AuditServ,
+		log:        log,
+	}
+}
+
+// Registers webhook in the administrator's tenant. Generated signing secret is returned only here
+func (s *WebhookService) CreateWebhook(ctx context.Context, webhook models.Webhook, access string, meta models.RequestMeta) (_ models.Webhook, err error) {
+	const op = "WebhookService.CreateWebhook"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("url", webhook.URL),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditWebhookAdd, Details: "url: " + webhook.URL}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, err := s.authorize(ctx, access)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return models.Webhook{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.ErrorContext(ctx, "Failed to generate secret", "error", err)
+		return models.Webhook{}, models.ErrUnexpected
+	}
+	webhook.TenantID = claims.TenantID
+	webhook.Secret = "whsec_" + hex.EncodeToString(secret)
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	if err := s.WebhookDal.CreateWebhook(ctx, &webhook); err != nil {
+		log.ErrorContext(ctx, "Failed to create webhook", "error", err)
+		return models.Webhook{}, models.ErrUnexpected
+	}
+
+	entry.Details = fmt.Sprintf("webhook: %d, url: %s", webhook.ID, webhook.URL)
+	log.InfoContext(ctx, "Webhook created", "ID", webhook.ID)
+	return webhook, nil
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, access string) ([]models.Webhook, error) {
+	const op = "WebhookService.ListWebhooks"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := s.authorize(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return nil, err
+	}
+
+	webhooks, err := s.WebhookDal.ListWebhooks(ctx, claims.TenantID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list webhooks", "error", err)
+		return nil, models.ErrUnexpected
+	}
+	return webhooks, nil
+}
+
+// Deletes webhook, its pending deliveries are dropped
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID int, access string, meta models.RequestMeta) (err error) {
+	const op = "WebhookService.DeleteWebhook"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int("ID", webhookID),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditWebhookDel, Details: fmt.Sprintf("webhook: %d", webhookID)}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, err := s.authorize(ctx, access)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return err
+	}
+
+	if err := s.WebhookDal.DeleteWebhook(ctx, claims.TenantID, webhookID); err != nil {
+		if errors.Is(err, repo.ErrWebhookNotExist) {
+			log.ErrorContext(ctx, "Webhook is not exist")
+			return repo.ErrWebhookNotExist
+		}
+		log.ErrorContext(ctx, "Failed to delete webhook", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Webhook deleted")
+	return nil
+}
+
+// Returns deliveries with the status, newest first. Dead status gives the dead-letter view
+func (s *WebhookService) ListDeliveries(ctx context.Context, status string, limit int, access string) ([]models.WebhookDelivery, error) {
+	const op = "WebhookService.ListDeliveries"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	claims, err := s.authorize(ctx, access)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return nil, err
+	}
+
+	if limit <= 0 || limit > MaxDeliveriesPage {
+		limit = MaxDeliveriesPage
+	}
+
+	deliveries, err := s.WebhookDal.ListDeliveries(ctx, claims.TenantID, status, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list deliveries", "error", err)
+		return nil, models.ErrUnexpected
+	}
+	return deliveries, nil
+}
+
+// Requeues dead delivery with reset attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, deliveryID int64, access string, meta models.RequestMeta) (err error) {
+	const op = "WebhookService.RetryDelivery"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Int64("ID", deliveryID),
+	)
+
+	entry := models.AuditEntry{Action: models.AuditWebhookRetry, Details: fmt.Sprintf("delivery: %d", deliveryID)}
+	defer func() { s.AuditServ.Record(ctx, entry, meta, err) }()
+
+	claims, err := s.authorize(ctx, access)
+	entry.TenantID, entry.ActorID = claims.TenantID, claims.ID
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to authorize", "error", err)
+		return err
+	}
+
+	if err := s.WebhookDal.RetryDelivery(ctx, claims.TenantID, deliveryID); err != nil {
+		if errors.Is(err, repo.ErrDeliveryNotExist) {
+			log.ErrorContext(ctx, "Delivery is not exist")
+			return repo.ErrDeliveryNotExist
+		}
+		if errors.Is(err, models.ErrStatusTransition) {
+			log.ErrorContext(ctx, "Delivery is not dead")
+			return models.ErrStatusTransition
+		}
+		log.ErrorContext(ctx, "Failed to retry delivery", "error", err)
+		return models.ErrUnexpected
+	}
+
+	log.InfoContext(ctx, "Delivery requeued")
+	return nil
+}
+
+// Claims of the valid token are returned even if access is denied
+func (s *WebhookService) authorize(ctx context.Context, access string) (models.CustomClaims, error) {
+	// Валидируем токен
+	claims, err := s.TokenServ.Validate(ctx, access)
+	if err != nil {
+		return models.CustomClaims{}, models.ErrInvalidToken
+	}
+
+	// Проверяем права пользователя
+	if !claims.IsAdmin {
+		return claims, models.ErrPermissionDenied
+	}
+	return claims, nil
+}
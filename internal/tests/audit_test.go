@@ -0,0 +1,97 @@
+package service
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestAuditLogin(t *testing.T) {
+	auditRepo := mock.NewMockAuditRepo()
+	tokenServ := mock.NewMockTokenService()
+	auditServ := service.NewAuditService(auditRepo, tokenServ, slog.Default())
+	authServ := service.NewAuthService(mock.NewMockUserRepo(), mock.NewMockTenantRepo(), tokenServ, auditServ, service.NewLoginService(mock.NewMockLoginRepo(), tokenServ, mock.NewMockNotifier(), slog.Default()), models.PasswordPolicy{MinLength: 8}, slog.Default())
+
+	meta := models.RequestMeta{IP: "10.0.0.1", UserAgent: "test-agent", RequestID: "req-1"}
+	if _, err := authServ.Login(context.Background(), "default", "defaultEmail@gmail.com", "notvalidPassword", meta); !errors.Is(err, models.ErrInvalidCredentials) {
+		t.Fatalf("expected error = %v, got %v", models.ErrInvalidCredentials, err)
+	}
+	if _, err := authServ.Login(context.Background(), "default", "defaultEmail@gmail.com", "validPassword", meta); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Неизвестный тенант не попадает в журнал
+	if _, err := authServ.Login(context.Background(), "unknown", "defaultEmail@gmail.com", "validPassword", meta); err == nil {
+		t.Fatal("expected error for unknown tenant")
+	}
+
+	if len(auditRepo.Entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(auditRepo.Entries))
+	}
+	for i, outcome := range []string{models.OutcomeFailure, models.OutcomeSuccess} {
+		entry := auditRepo.Entries[i]
+		if entry.Action != models.AuditLogin || entry.Outcome != outcome {
+			t.Errorf("expected %s %s entry, got %s %s", models.AuditLogin, outcome, entry.Action, entry.Outcome)
+		}
+		if entry.IP != meta.IP || entry.UserAgent != meta.UserAgent || entry.RequestID != meta.RequestID {
+			t.Errorf("expected request meta %+v in entry, got %+v", meta, entry)
+		}
+	}
+	if auditRepo.Entries[0].ActorID != 0 || auditRepo.Entries[0].Details == "" {
+		t.Errorf("failed login must have no actor and a reason, got %+v", auditRepo.Entries[0])
+	}
+
+	if _, err := auditServ.ListAudit(context.Background(), models.AuditFilter{}, "userToken"); !errors.Is(err, models.ErrPermissionDenied) {
+		t.Errorf("expected error = %v, got %v", models.ErrPermissionDenied, err)
+	}
+	page, err := auditServ.ListAudit(context.Background(), models.AuditFilter{Action: models.AuditLogin}, "adminToken")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.Entries) != 2 || page.Entries[0].ID != 2 {
+		t.Errorf("expected 2 entries newest first, got %+v", page.Entries)
+	}
+}
+
+func TestVerifyAudit(t *testing.T) {
+	auditRepo := mock.NewMockAuditRepo()
+	auditServ := service.NewAuditService(auditRepo, mock.NewMockTokenService(), slog.Default())
+	for i := 0; i < 3; i++ {
+		auditServ.Record(context.Background(), models.AuditEntry{TenantID: "default", ActorID: 1, TargetID: i + 2, Action: models.AuditUserUpdate}, models.RequestMeta{}, nil)
+	}
+	auditServ.Record(context.Background(), models.AuditEntry{TenantID: "other", Action: models.AuditLogin}, models.RequestMeta{}, nil)
+
+	result, err := auditServ.VerifyAudit(context.Background(), "adminToken")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.Valid || result.Checked != 3 {
+		t.Fatalf("expected valid chain of 3 entries, got %+v", result)
+	}
+
+	// Подменяем цель второй записи
+	auditRepo.Entries[1].TargetID = 42
+	result, err = auditServ.VerifyAudit(context.Background(), "adminToken")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Valid || result.BrokenID != auditRepo.Entries[1].ID {
+		t.Errorf("expected chain broken at %d, got %+v", auditRepo.Entries[1].ID, result)
+	}
+}
+
+func TestAuditFilterActions(t *testing.T) {
+	for _, action := range []string{models.AuditUserPassword, models.AuditOrgInvite, models.AuditOrgRevoke, models.AuditOrgRole,
+		models.AuditWebhookAdd, models.AuditWebhookDel, models.AuditWebhookRetry} {
+		if err := validate.AuditFilter(models.AuditFilter{Action: action}); err != nil {
+			t.Errorf("expected action %s to be valid, got %v", action, err)
+		}
+	}
+	if err := validate.AuditFilter(models.AuditFilter{Action: "unknown"}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
@@ -6,12 +6,14 @@ import (
 	"auth/internal/domain/models"
 	"auth/internal/service"
 	"auth/internal/tests/mock"
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateCredentials(t *testing.T) {
@@ -109,9 +111,38 @@ func TestValidateCredentials(t *testing.T) {
 	}
 }
 
+func TestValidateUserFilter(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name    string
+		filter  models.UserFilter
+		wantErr bool
+	}{
+		{name: "empty filter", filter: models.UserFilter{}},
+		{name: "full filter", filter: models.UserFilter{Role: models.UserRole, Query: "john", Status: models.StatusActive,
+			CreatedFrom: now.Add(-time.Hour), CreatedTo: now, SortBy: models.SortByEmail, Limit: 10}},
+		{name: "unknown role", filter: models.UserFilter{Role: "superuser"}, wantErr: true},
+		{name: "pending deletion status", filter: models.UserFilter{Status: models.StatusPendingDeletion}},
+		{name: "unknown status", filter: models.UserFilter{Status: "banned"}, wantErr: true},
+		{name: "unknown sort field", filter: models.UserFilter{SortBy: "PassHash"}, wantErr: true},
+		{name: "reversed created range", filter: models.UserFilter{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)}, wantErr: true},
+		{name: "negative limit", filter: models.UserFilter{Limit: -1}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := validate.UserFilter(tc.filter); (err != nil) != tc.wantErr {
+				t.Errorf("expected error = %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestLogin(t *testing.T) {
 	testCases := []struct {
 		name             string
+		tenantID         string
 		email            string
 		password         string
 		expectedHTTPcode int
@@ -119,27 +150,58 @@ func TestLogin(t *testing.T) {
 	}{
 		{
 			name:        "not exist email",
+			tenantID:    "default",
 			email:       "uniqueMail@gmail.com",
 			password:    "password",
 			expectedErr: repo.ErrUserNotExist,
 		},
 		{
 			name:        "invalid password",
+			tenantID:    "default",
 			email:       "defaultEmail@gmail.com",
 			password:    "notvalidPassword",
 			expectedErr: models.ErrInvalidCredentials,
 		}, {
 			name:        "validLogin",
+			tenantID:    "default",
 			email:       "defaultEmail@gmail.com",
 			password:    "validPassword",
 			expectedErr: nil,
 		},
+		{
+			name:        "empty tenant",
+			tenantID:    "",
+			email:       "defaultEmail@gmail.com",
+			password:    "validPassword",
+			expectedErr: models.ErrTenantRequired,
+		},
+		{
+			name:        "not exist tenant",
+			tenantID:    "unknown",
+			email:       "defaultEmail@gmail.com",
+			password:    "validPassword",
+			expectedErr: repo.ErrTenantNotExist,
+		},
+		{
+			name:        "temporary password",
+			tenantID:    "default",
+			email:       "tempPassword@gmail.com",
+			password:    "validPassword",
+			expectedErr: models.ErrPasswordChangeRequired,
+		},
+		{
+			name:        "disabled user",
+			tenantID:    "default",
+			email:       "disabledEmail@gmail.com",
+			password:    "validPassword",
+			expectedErr: models.ErrUserInactive,
+		},
 	}
-	authServ := service.NewAuthService(mock.NewMockUserRepo(), mock.NewMockTokenService(), slog.Default())
+	authServ := newAuthService()
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			_, err := authServ.Login(tc.email, tc.password)
+			_, err := authServ.Login(context.Background(), tc.tenantID, tc.email, tc.password, models.RequestMeta{})
 			if !errors.Is(err, tc.expectedErr) {
 				t.Errorf("expected error = %v, got error = %v, err = %v", tc.expectedErr, err != nil, err)
 			}
@@ -150,6 +212,7 @@ func TestLogin(t *testing.T) {
 func TestRegister(t *testing.T) {
 	tests := []struct {
 		name             string
+		tenantID         string
 		userName         string
 		email            string
 		password         string
@@ -159,6 +222,7 @@ func TestRegister(t *testing.T) {
 	}{
 		{
 			name:        "not unique email",
+			tenantID:    "default",
 			userName:    "user name",
 			email:       "ExistMail@gmail.com",
 			password:    "password",
@@ -167,6 +231,7 @@ func TestRegister(t *testing.T) {
 		},
 		{
 			name:        "admin registration",
+			tenantID:    "default",
 			userName:    "New admin",
 			role:        models.AdminRole,
 			email:       "uniqueMail@gmail.com",
@@ -175,18 +240,46 @@ func TestRegister(t *testing.T) {
 		},
 		{
 			name:        "valid registration",
+			tenantID:    "default",
 			userName:    "New User",
 			role:        models.UserRole,
 			email:       "uniqueMail@gmail.com",
 			password:    "validPassword",
 			expectedErr: nil,
 		},
+		{
+			name:        "not exist tenant",
+			tenantID:    "unknown",
+			userName:    "New User",
+			role:        models.UserRole,
+			email:       "uniqueMail@gmail.com",
+			password:    "validPassword",
+			expectedErr: repo.ErrTenantNotExist,
+		},
+		{
+			name:        "weak password for tenant policy",
+			tenantID:    "strict",
+			userName:    "New User",
+			role:        models.UserRole,
+			email:       "uniqueMail@gmail.com",
+			password:    "validPassword",
+			expectedErr: models.ErrWeakPassword,
+		},
+		{
+			name:        "strong password for tenant policy",
+			tenantID:    "strict",
+			userName:    "New User",
+			role:        models.UserRole,
+			email:       "uniqueMail@gmail.com",
+			password:    "Valid-Password-1",
+			expectedErr: nil,
+		},
 	}
-	authServ := service.NewAuthService(mock.NewMockUserRepo(), mock.NewMockTokenService(), slog.Default())
+	authServ := newAuthService()
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			_, err := authServ.Register(tc.userName, tc.email, tc.password, tc.role)
+			_, err := authServ.Register(context.Background(), tc.tenantID, tc.userName, tc.email, tc.password, tc.role, models.RequestMeta{})
 			if !errors.Is(err, tc.expectedErr) {
 				t.Errorf("expected error = %v, got error = %v, err = %v", tc.expectedErr, err != nil, err)
 			}
@@ -230,7 +323,7 @@ func TestCheckRole(t *testing.T) {
 			name:  "admin user token",
 			token: "adminToken",
 			expectedUser: models.User{
-				ID:      1,
+				ID:      mock.AdminUserID,
 				Name:    "testName",
 				Email:   "adminEmail@gmail.com",
 				IsAdmin: true,
@@ -239,10 +332,10 @@ func TestCheckRole(t *testing.T) {
 		},
 	}
 
-	authServ := service.NewAuthService(mock.NewMockUserRepo(), mock.NewMockTokenService(), slog.Default())
+	authServ := newAuthService()
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			user, err := authServ.RoleCheck(tc.token)
+			user, err := authServ.RoleCheck(context.Background(), tc.token)
 			if !errors.Is(err, tc.expectedErr) {
 				t.Errorf("expected error = %v, got error = %v, error = %v", tc.expectedErr, err != nil, err)
 			}
@@ -253,6 +346,62 @@ func TestCheckRole(t *testing.T) {
 	}
 }
 
+func TestChangePassword(t *testing.T) {
+	testCases := []struct {
+		name        string
+		email       string
+		oldPassword string
+		newPassword string
+		expectedErr error
+	}{
+		{
+			name:        "valid change of temporary password",
+			email:       "tempPassword@gmail.com",
+			oldPassword: "validPassword",
+			newPassword: "newValidPassword",
+		},
+		{
+			name:        "invalid old password",
+			email:       "defaultEmail@gmail.com",
+			oldPassword: "notvalidPassword",
+			newPassword: "newValidPassword",
+			expectedErr: models.ErrInvalidCredentials,
+		},
+		{
+			name:        "same password",
+			email:       "defaultEmail@gmail.com",
+			oldPassword: "validPassword",
+			newPassword: "validPassword",
+			expectedErr: models.ErrSamePassword,
+		},
+		{
+			name:        "not exist user",
+			email:       "uniqueMail@gmail.com",
+			oldPassword: "validPassword",
+			newPassword: "newValidPassword",
+			expectedErr: models.ErrInvalidCredentials,
+		},
+	}
+
+	authServ := newAuthService()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := authServ.ChangePassword(context.Background(), "default", tc.email, tc.oldPassword, tc.newPassword)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error = %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func newAuthService() *service.AuthService {
+	tokenServ := mock.NewMockTokenService()
+	auditServ := service.NewAuditService(mock.NewMockAuditRepo(), tokenServ, slog.Default())
+	loginServ := service.NewLoginService(mock.NewMockLoginRepo(), tokenServ, mock.NewMockNotifier(), slog.Default())
+	return service.NewAuthService(mock.NewMockUserRepo(), mock.NewMockTenantRepo(), tokenServ, auditServ, loginServ, models.PasswordPolicy{MinLength: 8}, slog.Default())
+}
+
 func EqualUsers(got, expected models.User) error {
 	if got.ID != expected.ID {
 		return fmt.Errorf("expected user ID = %d, got ID = %v", expected.ID, got.ID)
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"auth/internal/adapters/transport/http/routers"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator(t *testing.T) {
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), mock.NewMockUserRepo(), mock.NewMockTenantRepo(),
+		time.Minute*5, time.Minute*5, slog.Default())
+	tokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default", Email: "defaultEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+	other, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 2, TenantID: "default", Email: "uniqueMail@gmail.com"})
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		precedence   string
+		header       string
+		cookie       string
+		method       string
+		csrf         string
+		optional     bool
+		expectedCode int
+	}{
+		{name: "no token", precedence: routers.PrecedenceHeader, expectedCode: http.StatusUnauthorized},
+		{name: "no token optional", precedence: routers.PrecedenceHeader, optional: true, expectedCode: http.StatusOK},
+		{name: "bearer", precedence: routers.PrecedenceHeader, header: "Bearer " + tokens.AccessToken, expectedCode: http.StatusOK},
+		{name: "lower case scheme", precedence: routers.PrecedenceHeader, header: "bearer " + tokens.AccessToken, expectedCode: http.StatusOK},
+		{name: "cookie", precedence: routers.PrecedenceHeader, cookie: tokens.AccessToken, expectedCode: http.StatusOK},
+		{name: "basic scheme ignored", precedence: routers.PrecedenceHeader, header: "Basic dXNlcjpwYXNz", expectedCode: http.StatusUnauthorized},
+		{name: "refresh token bearer", precedence: routers.PrecedenceHeader, header: "Bearer " + tokens.RefreshToken, expectedCode: http.StatusUnauthorized},
+		{name: "invalid bearer", precedence: routers.PrecedenceHeader, header: "Bearer invalidToken", expectedCode: http.StatusUnauthorized},
+		{name: "invalid bearer optional", precedence: routers.PrecedenceHeader, header: "Bearer invalidToken", optional: true, expectedCode: http.StatusUnauthorized},
+		{name: "header first", precedence: routers.PrecedenceHeader, header: "Bearer invalidToken", cookie: tokens.AccessToken, expectedCode: http.StatusUnauthorized},
+		{name: "cookie first", precedence: routers.PrecedenceCookie, header: "Bearer invalidToken", cookie: tokens.AccessToken, expectedCode: http.StatusOK},
+		{name: "cookie unsafe without csrf", precedence: routers.PrecedenceHeader, cookie: tokens.AccessToken, method: http.MethodDelete, expectedCode: http.StatusForbidden},
+		{name: "cookie unsafe with csrf", precedence: routers.PrecedenceHeader, cookie: tokens.AccessToken, method: http.MethodDelete, csrf: tokens.CSRFToken, expectedCode: http.StatusOK},
+		{name: "cookie unsafe with csrf of other user", precedence: routers.PrecedenceHeader, cookie: tokens.AccessToken, method: http.MethodPut, csrf: other.CSRFToken, expectedCode: http.StatusForbidden},
+		{name: "bearer unsafe without csrf", precedence: routers.PrecedenceHeader, header: "Bearer " + tokens.AccessToken, method: http.MethodDelete, expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			authn, err := routers.NewAuthenticator(tokenServ, tc.precedence, slog.Default())
+			if err != nil {
+				t.Fatalf("new authenticator: %v", err)
+			}
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				// Сервисы получают результат проверки из контекста запроса
+				if tc.header != "" || tc.cookie != "" {
+					if _, err := tokenServ.Validate(r.Context(), tokens.AccessToken); err != nil {
+						t.Errorf("token is not valid in handler: %v", err)
+					}
+				}
+				w.WriteHeader(http.StatusOK)
+			}
+			handler := authn.Required(next)
+			if tc.optional {
+				handler = authn.Optional(next)
+			}
+
+			method := http.MethodGet
+			if tc.method != "" {
+				method = tc.method
+			}
+			req := httptest.NewRequest(method, "/me", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: models.Access, Value: tc.cookie})
+			}
+			if tc.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: models.CSRF, Value: tc.csrf})
+				req.Header.Set(routers.CSRFHeader, tc.csrf)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Errorf("expected status %d, got %d: %s", tc.expectedCode, rec.Code, rec.Body)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header")
+			}
+		})
+	}
+
+	if _, err := routers.NewAuthenticator(tokenServ, "query", slog.Default()); err == nil {
+		t.Error("expected error for unknown precedence")
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"auth/pkg/cors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	middleware, err := cors.New(cors.Options{
+		AllowedOrigins:   cors.List("https://app.example.com, https://admin.example.com"),
+		AllowedMethods:   cors.List("GET,POST,PUT,DELETE"),
+		AllowedHeaders:   cors.List("Content-Type,X-CSRF-Token"),
+		ExposedHeaders:   cors.List("X-CSRF-Token"),
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("new cors: %v", err)
+	}
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string
+		requestHeader string
+		expectedCode  int
+		expectOrigin  string
+	}{
+		{name: "same origin", method: http.MethodGet, expectedCode: http.StatusOK},
+		{name: "allowed origin", method: http.MethodPost, origin: "https://app.example.com", expectedCode: http.StatusOK, expectOrigin: "https://app.example.com"},
+		{name: "unknown origin", method: http.MethodPost, origin: "https://evil.example.com", expectedCode: http.StatusOK},
+		{name: "preflight", method: http.MethodOptions, origin: "https://admin.example.com", requestMethod: http.MethodDelete, requestHeader: "content-type, x-csrf-token", expectedCode: http.StatusNoContent, expectOrigin: "https://admin.example.com"},
+		{name: "preflight unknown origin", method: http.MethodOptions, origin: "https://evil.example.com", requestMethod: http.MethodDelete, expectedCode: http.StatusForbidden},
+		{name: "preflight method not allowed", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: http.MethodPatch, expectedCode: http.StatusForbidden},
+		{name: "preflight header not allowed", method: http.MethodOptions, origin: "https://app.example.com", requestMethod: http.MethodPost, requestHeader: "X-Custom", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/user/1", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+			}
+			if tc.requestHeader != "" {
+				req.Header.Set("Access-Control-Request-Headers", tc.requestHeader)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d", tc.expectedCode, rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tc.expectOrigin {
+				t.Fatalf("expected Access-Control-Allow-Origin %q, got %q", tc.expectOrigin, got)
+			}
+			// Cookie разрешаются только перечисленным источникам
+			if tc.expectOrigin != "" && rec.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("expected Access-Control-Allow-Credentials: true")
+			}
+		})
+	}
+
+	if _, err := cors.New(cors.Options{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Error("expected error for credentials with any origin")
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"auth/internal/adapters/transport/http/dto"
+	"auth/internal/adapters/transport/http/routers"
+	"auth/internal/domain/models"
+	"auth/internal/tests/mock"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTokenDelivery(t *testing.T) {
+	testCases := []struct {
+		name         string
+		path         string
+		body         string
+		header       string
+		cookie       string
+		csrf         string
+		expectedCode int
+		expectJSON   bool
+	}{
+		{name: "login cookies by default", path: "/login", body: `{"email":"defaultEmail@gmail.com","password":"validPassword"}`, expectedCode: http.StatusOK},
+		{name: "login json by header", path: "/login", body: `{"email":"defaultEmail@gmail.com","password":"validPassword"}`, header: routers.DeliveryJSON, expectedCode: http.StatusOK, expectJSON: true},
+		{name: "login json by field", path: "/login", body: `{"email":"defaultEmail@gmail.com","password":"validPassword","token_delivery":"json"}`, header: routers.DeliveryCookie, expectedCode: http.StatusOK, expectJSON: true},
+		{name: "login unknown delivery", path: "/login", body: `{"email":"defaultEmail@gmail.com","password":"validPassword","token_delivery":"query"}`, expectedCode: http.StatusBadRequest},
+		{name: "refresh by cookie", path: "/refresh", cookie: "refreshToken", csrf: "csrfToken", expectedCode: http.StatusOK},
+		{name: "refresh by cookie without csrf", path: "/refresh", cookie: "refreshToken", expectedCode: http.StatusForbidden},
+		{name: "refresh by cookie with foreign csrf", path: "/refresh", cookie: "refreshToken", csrf: "otherCsrfToken", expectedCode: http.StatusForbidden},
+		{name: "refresh by body", path: "/refresh", body: `{"refresh_token":"refreshToken"}`, expectedCode: http.StatusOK, expectJSON: true},
+		{name: "refresh by body to cookies", path: "/refresh", body: `{"refresh_token":"refreshToken","token_delivery":"cookie"}`, expectedCode: http.StatusOK},
+		{name: "refresh by cookie to json", path: "/refresh", cookie: "refreshToken", csrf: "csrfToken", header: routers.DeliveryJSON, expectedCode: http.StatusOK, expectJSON: true},
+		{name: "refresh without token", path: "/refresh", expectedCode: http.StatusUnauthorized},
+	}
+
+	authH := routers.NewAuthHandler(newAuthService(), mock.NewMockTokenService(), slog.Default())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set(routers.TenantHeader, "default")
+			if tc.header != "" {
+				req.Header.Set(routers.TokenDeliveryHeader, tc.header)
+			}
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: models.Refresh, Value: tc.cookie})
+			}
+			if tc.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: models.CSRF, Value: tc.csrf})
+				req.Header.Set(routers.CSRFHeader, tc.csrf)
+			}
+			rec := httptest.NewRecorder()
+			if tc.path == "/login" {
+				authH.Login(rec, req)
+			} else {
+				authH.RefreshToken(rec, req)
+			}
+
+			if rec.Code != tc.expectedCode {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedCode, rec.Code, rec.Body)
+			}
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			cookies := rec.Result().Cookies()
+			if !tc.expectJSON {
+				if len(cookies) == 0 {
+					t.Error("expected token cookies")
+				}
+				return
+			}
+
+			// Токены только в теле, cookie не выставляются
+			if len(cookies) != 0 {
+				t.Errorf("expected no cookies, got %v", cookies)
+			}
+			if rec.Header().Get("Cache-Control") != "no-store" {
+				t.Error("expected Cache-Control: no-store")
+			}
+			var resp dto.TokenResp
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.TokenType != "Bearer" {
+				t.Errorf("expected Bearer token type, got %q", resp.TokenType)
+			}
+			if tc.path == "/login" && (resp.AccessToken != "accessToken" || resp.RefreshToken != "refreshToken" || resp.ExpiresIn != 900) {
+				t.Errorf("unexpected tokens %+v", resp)
+			}
+		})
+	}
+}
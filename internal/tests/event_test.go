@@ -0,0 +1,127 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWatchUserEvents(t *testing.T) {
+	eventRepo := mock.NewMockEventRepo()
+	for i, eventType := range []string{models.EventUserRegistered, models.EventUserUpdated, models.EventUserDeleted} {
+		eventRepo.Add(models.Event{ID: int64(i + 1), TenantID: "default", Type: eventType})
+	}
+	eventRepo.Add(models.Event{ID: 4, TenantID: "other", Type: models.EventUserUpdated})
+
+	eventServ := service.NewEventService(eventRepo, mock.NewMockTokenService(), slog.Default())
+	eventServ.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Подписчик продолжает с курсора и получает новые события без переподключения
+	var received []int64
+	err := eventServ.WatchUserEvents(ctx, 1, nil, "serviceToken", func(event models.Event) error {
+		received = append(received, event.ID)
+		if event.ID == 3 {
+			eventRepo.Add(models.Event{ID: 5, TenantID: "default", Type: models.EventUserRoleChanged})
+		}
+		if event.ID == 5 {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WatchUserEvents() error = %v", err)
+	}
+
+	want := []int64{2, 3, 5}
+	if len(received) != len(want) {
+		t.Fatalf("received events %v, want %v", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Fatalf("received events %v, want %v", received, want)
+		}
+	}
+}
+
+func TestWatchUserEventsTypes(t *testing.T) {
+	eventRepo := mock.NewMockEventRepo()
+	eventRepo.Add(models.Event{ID: 1, TenantID: "default", Type: models.EventUserRegistered})
+	eventRepo.Add(models.Event{ID: 2, TenantID: "default", Type: models.EventUserRoleChanged})
+
+	eventServ := service.NewEventService(eventRepo, mock.NewMockTokenService(), slog.Default())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var received []models.Event
+	err := eventServ.WatchUserEvents(ctx, 0, []string{models.EventUserRoleChanged}, "adminToken", func(event models.Event) error {
+		received = append(received, event)
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WatchUserEvents() error = %v", err)
+	}
+	if len(received) != 1 || received[0].Type != models.EventUserRoleChanged {
+		t.Errorf("expected only role change event, got %v", received)
+	}
+}
+
+func TestWatchUserEventsErrors(t *testing.T) {
+	eventRepo := mock.NewMockEventRepo()
+	eventRepo.Purged = 10
+	eventServ := service.NewEventService(eventRepo, mock.NewMockTokenService(), slog.Default())
+
+	tests := []struct {
+		name   string
+		token  string
+		cursor int64
+		want   error
+	}{
+		{"Invalid token", "invalidToken", 0, models.ErrInvalidToken},
+		{"Not administrator", "validToken", 0, models.ErrPermissionDenied},
+		{"Purged cursor", "serviceToken", 5, models.ErrCursorExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := eventServ.WatchUserEvents(context.Background(), tt.cursor, nil, tt.token, func(models.Event) error {
+				t.Fatal("unexpected event")
+				return nil
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("WatchUserEvents() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchUserEventsStop(t *testing.T) {
+	eventServ := service.NewEventService(mock.NewMockEventRepo(), mock.NewMockTokenService(), slog.Default())
+	eventServ.PollInterval = time.Hour
+
+	// При остановке сервиса поток завершается без ошибки, подписчик переподключится
+	done := make(chan error, 1)
+	go func() {
+		done <- eventServ.WatchUserEvents(context.Background(), 0, nil, "serviceToken", func(models.Event) error { return nil })
+	}()
+	time.Sleep(50 * time.Millisecond)
+	eventServ.Stop()
+	eventServ.Stop()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("WatchUserEvents() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch stream is not closed after Stop")
+	}
+}
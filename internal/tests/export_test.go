@@ -0,0 +1,51 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestExportMe(t *testing.T) {
+	exportServ := service.NewExportService(mock.NewMockUserRepo(), mock.NewMockOrgRepo(), mock.NewMockLoginRepo(), mock.NewMockTokenService(), slog.Default())
+
+	export, err := exportServ.ExportMe(context.Background(), "accessToken")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if export.Profile.Email != "defaultEmail@gmail.com" {
+		t.Errorf("expected profile email %v, got %v", "defaultEmail@gmail.com", export.Profile.Email)
+	}
+
+	encoded, err := json.Marshal(export)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Хэш пароля не попадает в архив
+	if strings.Contains(string(encoded), "$2a$") {
+		t.Errorf("export contains password hash: %s", encoded)
+	}
+
+	// Пустые разделы отдаются массивами, а не null
+	for _, section := range []string{`"organizations":[]`, `"login_history":[]`} {
+		if !strings.Contains(string(encoded), section) {
+			t.Errorf("expected section %s in export: %s", section, encoded)
+		}
+	}
+}
+
+func TestExportMe_InvalidToken(t *testing.T) {
+	exportServ := service.NewExportService(mock.NewMockUserRepo(), mock.NewMockOrgRepo(), mock.NewMockLoginRepo(), mock.NewMockTokenService(), slog.Default())
+
+	if _, err := exportServ.ExportMe(context.Background(), "invalidToken"); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("expected error %v, got %v", models.ErrInvalidToken, err)
+	}
+}
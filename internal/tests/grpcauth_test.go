@@ -0,0 +1,133 @@
+package service
+
+import (
+	"auth/config"
+	grpcserver "auth/internal/adapters/transport/grpc"
+	authv1 "auth/internal/adapters/transport/grpc/gen"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"auth/pkg/logger"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Handlers return principal seen in the context of the call
+type principalAuthServer struct {
+	authv1.UnimplementedAuthServiceServer
+}
+
+func (principalAuthServer) WhoAmI(ctx context.Context, req *authv1.WhoAmIRequest) (*authv1.WhoAmIResponse, error) {
+	return &authv1.WhoAmIResponse{User: &authv1.User{Name: logger.Principal(ctx)}}, nil
+}
+
+func (principalAuthServer) Login(ctx context.Context, req *authv1.LoginRequest) (*authv1.LoginResponse, error) {
+	return &authv1.LoginResponse{}, nil
+}
+
+type principalAdminServer struct {
+	authv1.UnimplementedAdminServiceServer
+}
+
+func (principalAdminServer) WatchUserEvents(req *authv1.WatchUserEventsRequest, stream grpc.ServerStreamingServer[authv1.UserEvent]) error {
+	return stream.Send(&authv1.UserEvent{TenantId: logger.Principal(stream.Context())})
+}
+
+func TestGRPCAuthInterceptor(t *testing.T) {
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), mock.NewMockUserRepo(), mock.NewMockTenantRepo(),
+		time.Minute*5, time.Minute*5, slog.Default())
+	tokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default", Email: "defaultEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("generate tokens: %v", err)
+	}
+
+	opts, err := grpcserver.GetOptions(config.GrpcServer{MaxRecvMsgSize: 1 << 20, MaxSendMsgSize: 1 << 20}, tokenServ, slog.Default())
+	if err != nil {
+		t.Fatalf("get options: %v", err)
+	}
+	server := grpc.NewServer(opts...)
+	authv1.RegisterAuthServiceServer(server, principalAuthServer{})
+	authv1.RegisterAdminServiceServer(server, principalAdminServer{})
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	authClient := authv1.NewAuthServiceClient(conn)
+	adminClient := authv1.NewAdminServiceClient(conn)
+
+	const principal = "default/1"
+	testCases := []struct {
+		name          string
+		authorization string
+		field         string
+		expectedCode  codes.Code
+	}{
+		{name: "metadata", authorization: "Bearer " + tokens.AccessToken, expectedCode: codes.OK},
+		{name: "deprecated field", field: tokens.AccessToken, expectedCode: codes.OK},
+		{name: "no token", expectedCode: codes.Unauthenticated},
+		{name: "refresh token", authorization: "Bearer " + tokens.RefreshToken, expectedCode: codes.Unauthenticated},
+		{name: "basic scheme", authorization: "Basic dXNlcjpwYXNz", field: tokens.AccessToken, expectedCode: codes.Unauthenticated},
+		{name: "metadata first", authorization: "Bearer invalidToken", field: tokens.AccessToken, expectedCode: codes.Unauthenticated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.authorization)
+			}
+
+			resp, err := authClient.WhoAmI(ctx, &authv1.WhoAmIRequest{Token: tc.field})
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("WhoAmI: expected code %v, got %v", tc.expectedCode, err)
+			}
+			if err == nil && resp.GetUser().GetName() != principal {
+				t.Errorf("WhoAmI: expected principal %q, got %q", principal, resp.GetUser().GetName())
+			}
+
+			// Поток проверяется так же, устаревшее поле читается из первого сообщения
+			stream, err := adminClient.WatchUserEvents(ctx, &authv1.WatchUserEventsRequest{Token: tc.field})
+			if err != nil {
+				t.Fatalf("WatchUserEvents: %v", err)
+			}
+			event, err := stream.Recv()
+			if status.Code(err) != tc.expectedCode {
+				t.Fatalf("WatchUserEvents: expected code %v, got %v", tc.expectedCode, err)
+			}
+			if err == nil && event.GetTenantId() != principal {
+				t.Errorf("WatchUserEvents: expected principal %q, got %q", principal, event.GetTenantId())
+			}
+		})
+	}
+
+	// Публичные методы не требуют токена
+	if _, err := authClient.Login(context.Background(), &authv1.LoginRequest{}); err != nil {
+		t.Errorf("Login without token: %v", err)
+	}
+}
+
+func TestGRPCRequireClientCertWithoutCA(t *testing.T) {
+	cfg := config.GrpcServer{MaxRecvMsgSize: 1 << 20, MaxSendMsgSize: 1 << 20}
+	cfg.TLS.RequireClientCert = true
+	if _, err := grpcserver.GetOptions(cfg, nil, slog.Default()); err == nil {
+		t.Error("expected error for required client certificates without client CAs")
+	}
+}
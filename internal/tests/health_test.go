@@ -0,0 +1,63 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestHealthReady(t *testing.T) {
+	var dbErr error
+	healthServ := service.NewHealthService(slog.Default(),
+		service.HealthCheck{Name: "database", Check: func(context.Context) error { return dbErr }},
+		service.HealthCheck{Name: "signing_keys", Check: func(context.Context) error { return nil }},
+	)
+
+	// До запуска серверов инстанс не готов
+	if _, err := healthServ.Ready(context.Background()); !errors.Is(err, models.ErrNotReady) {
+		t.Fatalf("expected error = %v before start, got %v", models.ErrNotReady, err)
+	}
+
+	healthServ.SetReady(true)
+	report, err := healthServ.Ready(context.Background())
+	if err != nil || report.Status != models.HealthReady || report.Checks["database"] != models.HealthCheckOK {
+		t.Fatalf("expected ready report, got %+v, err = %v", report, err)
+	}
+
+	dbErr = errors.New("connection refused")
+	report, err = healthServ.Ready(context.Background())
+	// Причина ошибки только в логе, публичный ответ ее не раскрывает
+	if !errors.Is(err, models.ErrNotReady) || report.Checks["database"] != models.HealthCheckFail || report.Checks["signing_keys"] != models.HealthCheckOK {
+		t.Errorf("expected failed database check, got %+v, err = %v", report, err)
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	healthServ := service.NewHealthService(slog.Default())
+	healthServ.SetReady(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := make(chan error, 10)
+	go healthServ.Watch(ctx, time.Hour, func(err error) { results <- err })
+
+	if err := <-results; err != nil {
+		t.Fatalf("expected ready, got %v", err)
+	}
+
+	// Снятие готовности сообщается сразу, без ожидания интервала
+	healthServ.SetReady(false)
+	select {
+	case err := <-results:
+		if !errors.Is(err, models.ErrNotReady) {
+			t.Errorf("expected error = %v, got %v", models.ErrNotReady, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readiness change is not reported")
+	}
+}
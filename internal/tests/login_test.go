@@ -0,0 +1,73 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLoginHistory(t *testing.T) {
+	userRepo := mock.NewMockUserRepo()
+	loginRepo := mock.NewMockLoginRepo()
+	notifier := mock.NewMockNotifier()
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), userRepo, mock.NewMockTenantRepo(), time.Hour, time.Minute*5, slog.Default())
+	loginServ := service.NewLoginService(loginRepo, tokenServ, notifier, slog.Default())
+	auditServ := service.NewAuditService(mock.NewMockAuditRepo(), tokenServ, slog.Default())
+	authServ := service.NewAuthService(userRepo, mock.NewMockTenantRepo(), mock.NewMockTokenService(), auditServ, loginServ, models.PasswordPolicy{MinLength: 8}, slog.Default())
+
+	laptop := models.RequestMeta{IP: "10.0.0.1", UserAgent: "Firefox"}
+	phone := models.RequestMeta{IP: "10.0.0.2", UserAgent: "Safari", DeviceID: "phone-1"}
+	steps := []struct {
+		name        string
+		password    string
+		meta        models.RequestMeta
+		expectedErr error
+		notified    int
+	}{
+		{"first login is not notified", "validPassword", laptop, nil, 0},
+		{"known device", "validPassword", models.RequestMeta{IP: "10.0.0.9", UserAgent: "Firefox"}, nil, 0},
+		{"failed login from new device", "notvalidPassword", phone, models.ErrInvalidCredentials, 0},
+		{"new device", "validPassword", phone, nil, 1},
+		{"same device with other user agent", "validPassword", models.RequestMeta{UserAgent: "Chrome", DeviceID: "phone-1"}, nil, 1},
+	}
+	for _, step := range steps {
+		if _, err := authServ.Login(context.Background(), "default", "defaultEmail@gmail.com", step.password, step.meta); !errors.Is(err, step.expectedErr) {
+			t.Fatalf("%s: expected error = %v, got %v", step.name, step.expectedErr, err)
+		}
+		if len(notifier.NewDeviceLogins) != step.notified {
+			t.Fatalf("%s: expected %d notifications, got %d", step.name, step.notified, len(notifier.NewDeviceLogins))
+		}
+	}
+	if notifier.Email != "defaultEmail@gmail.com" || notifier.NewDeviceLogins[0].IP != phone.IP {
+		t.Errorf("expected notification about %s to user, got %+v to %s", phone.IP, notifier.NewDeviceLogins[0], notifier.Email)
+	}
+
+	// Несуществующий пользователь не попадает в историю
+	if _, err := authServ.Login(context.Background(), "default", "uniqueMail@gmail.com", "validPassword", laptop); err == nil {
+		t.Fatal("expected error for not exist user")
+	}
+
+	tokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default", Name: "testName", Email: "defaultEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	page, err := loginServ.ListLogins(context.Background(), "", 0, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(page.Logins) != len(steps) {
+		t.Fatalf("expected %d logins, got %d", len(steps), len(page.Logins))
+	}
+	if failed := page.Logins[2]; failed.Success || failed.Reason == "" {
+		t.Errorf("expected failed login with reason, got %+v", failed)
+	}
+
+	if _, err := loginServ.ListLogins(context.Background(), "", 0, "invalidToken"); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("expected error = %v, got %v", models.ErrInvalidToken, err)
+	}
+}
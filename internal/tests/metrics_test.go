@@ -0,0 +1,72 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestAuthMetrics(t *testing.T) {
+	const (
+		invalidLogins  = `auth_logins_total{outcome="invalid_credentials"}`
+		bcryptCompares = `auth_password_hash_duration_seconds_count{op="compare"}`
+		malformed      = `auth_token_validation_failures_total{reason="malformed"}`
+		inactive       = `auth_token_validation_failures_total{reason="user_inactive"}`
+	)
+	before := scrapeMetrics(t)
+
+	authServ := newAuthService()
+	if _, err := authServ.Login(context.Background(), "default", "defaultEmail@gmail.com", "notvalidPassword", models.RequestMeta{}); err == nil {
+		t.Fatal("expected login error")
+	}
+
+	tokenService := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), mock.NewMockUserRepo(), mock.NewMockTenantRepo(), time.Minute, time.Minute, slog.Default())
+	tokenService.Validate(context.Background(), "invalid.super.token")
+	tokens, err := tokenService.GenerateTokens(context.Background(), models.User{ID: mock.DisabledUserID, TenantID: "default", Name: "Test User", Email: "disabledEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("GenerateTokens error: %v", err)
+	}
+	tokenService.Validate(context.Background(), tokens.AccessToken)
+
+	after := scrapeMetrics(t)
+	for _, series := range []string{invalidLogins, bcryptCompares, malformed, inactive} {
+		if got := after[series] - before[series]; got != 1 {
+			t.Errorf("expected %s to grow by 1, got %v", series, got)
+		}
+	}
+}
+
+// Returns samples of the metrics endpoint by series
+func scrapeMetrics(t *testing.T) map[string]float64 {
+	rec := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("invalid sample %q: %v", line, err)
+		}
+		samples[line[:i]] = value
+	}
+	return samples
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"auth/migrations"
+	"auth/pkg/postgres"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := postgres.LoadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	if len(loaded) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	// Версии идут подряд и каждую можно откатить
+	for i, migration := range loaded {
+		if migration.Version != i+1 {
+			t.Errorf("migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %03d_%s must have up and down SQL", migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "Ordered by version",
+			files: fstest.MapFS{
+				"010_later.up.sql":   {Data: []byte("SELECT 10;")},
+				"002_first.up.sql":   {Data: []byte("SELECT 2;")},
+				"002_first.down.sql": {Data: []byte("SELECT -2;")},
+				"README.md":          {Data: []byte("ignored")},
+			},
+			want: 2,
+		},
+		{
+			name:    "Down without up",
+			files:   fstest.MapFS{"001_init.down.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: true,
+		},
+		{
+			name: "Different names of one version",
+			files: fstest.MapFS{
+				"001_init.up.sql":  {Data: []byte("SELECT 1;")},
+				"001_other.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := postgres.LoadMigrations(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(loaded) != tt.want {
+				t.Fatalf("loaded %d migrations, want %d", len(loaded), tt.want)
+			}
+			if loaded[0].Version != 2 || loaded[0].Down == "" || loaded[1].Version != 10 {
+				t.Errorf("unexpected migrations order: %+v", loaded)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// In-memory audit log, entries are chained like in the database
+type MockAuditRepo struct {
+	mu      sync.Mutex
+	Entries []models.AuditEntry
+}
+
+func NewMockAuditRepo() *MockAuditRepo {
+	return &MockAuditRepo{}
+}
+
+func (m *MockAuditRepo) Append(ctx context.Context, entry *models.AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.PrevHash = ""
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		if m.Entries[i].TenantID == entry.TenantID && m.Entries[i].Chain == entry.Chain {
+			entry.PrevHash = m.Entries[i].Hash
+			break
+		}
+	}
+	entry.ID = int64(len(m.Entries) + 1)
+	entry.Created_At = time.Now().UTC()
+	entry.Hash = entry.ComputeHash()
+	m.Entries = append(m.Entries, *entry)
+	return nil
+}
+
+func (m *MockAuditRepo) ListAudit(ctx context.Context, filter models.AuditFilter) (models.AuditPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := models.AuditPage{Entries: []models.AuditEntry{}}
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		entry := m.Entries[i]
+		if entry.TenantID != filter.TenantID || (filter.Action != "" && entry.Action != filter.Action) {
+			continue
+		}
+		if filter.UserID != 0 && entry.ActorID != filter.UserID && entry.TargetID != filter.UserID {
+			continue
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+	return page, nil
+}
+
+func (m *MockAuditRepo) WalkAudit(ctx context.Context, tenantID string, fn func(models.AuditEntry) error) error {
+	m.mu.Lock()
+	entries := append([]models.AuditEntry(nil), m.Entries...)
+	m.mu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Chain < entries[j].Chain })
+
+	for _, entry := range entries {
+		if entry.TenantID != tenantID {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
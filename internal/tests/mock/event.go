@@ -0,0 +1,50 @@
+package mock
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"slices"
+	"sync"
+)
+
+// In-memory outbox events ordered by ID
+type MockEventRepo struct {
+	mu     sync.Mutex
+	Events []models.Event
+	Purged int64
+}
+
+func NewMockEventRepo() *MockEventRepo {
+	return &MockEventRepo{}
+}
+
+func (m *MockEventRepo) Add(event models.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Events = append(m.Events, event)
+}
+
+func (m *MockEventRepo) ListEvents(ctx context.Context, tenantID string, afterID int64, types []string, limit int) ([]models.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []models.Event
+	for _, event := range m.Events {
+		if event.TenantID != tenantID || event.ID <= afterID || (len(types) > 0 && !slices.Contains(types, event.Type)) {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (m *MockEventRepo) PurgedThrough(ctx context.Context, tenantID string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.Purged, nil
+}
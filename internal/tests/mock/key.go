@@ -0,0 +1,47 @@
+package mock
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// In-memory signing keys, the newest is the first
+type MockKeyRepo struct {
+	mu   sync.Mutex
+	Keys []models.SigningKey
+}
+
+func NewMockKeyRepo() *MockKeyRepo {
+	return &MockKeyRepo{}
+}
+
+func (m *MockKeyRepo) ListKeys(ctx context.Context) ([]models.SigningKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Ключи после Retires_At не возвращаются
+	return slices.DeleteFunc(slices.Clone(m.Keys), func(key models.SigningKey) bool {
+		return key.Retires_At != nil && !key.Retires_At.After(time.Now())
+	}), nil
+}
+
+func (m *MockKeyRepo) RotateKey(ctx context.Context, key *models.SigningKey, grace time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.Created_At = time.Now()
+	retires := key.Created_At.Add(grace)
+	if len(m.Keys) == 0 {
+		m.Keys = []models.SigningKey{{ID: models.SecretKeyID, Created_At: key.Created_At, Retires_At: &retires}}
+	}
+	for i := range m.Keys {
+		if m.Keys[i].Retires_At == nil {
+			m.Keys[i].Retires_At = &retires
+		}
+	}
+	m.Keys = append([]models.SigningKey{*key}, m.Keys...)
+	return nil
+}
@@ -0,0 +1,58 @@
+package mock
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// In-memory login history, cursor is ignored
+type MockLoginRepo struct {
+	mu     sync.Mutex
+	Logins []models.LoginRecord
+}
+
+func NewMockLoginRepo() *MockLoginRepo {
+	return &MockLoginRepo{}
+}
+
+func (m *MockLoginRepo) AddLogin(ctx context.Context, login *models.LoginRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	login.ID = int64(len(m.Logins) + 1)
+	login.Created_At = time.Now()
+	m.Logins = append(m.Logins, *login)
+	return nil
+}
+
+func (m *MockLoginRepo) KnownDevice(ctx context.Context, tenantID string, userID int, fingerprint string) (bool, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	known, firstLogin := false, true
+	for _, login := range m.Logins {
+		if login.TenantID != tenantID || login.UserID != userID || !login.Success {
+			continue
+		}
+		firstLogin = false
+		if login.Fingerprint == fingerprint {
+			known = true
+		}
+	}
+	return known, firstLogin, nil
+}
+
+func (m *MockLoginRepo) ListLogins(ctx context.Context, tenantID string, userID int, cursor string, limit int) (models.LoginPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := models.LoginPage{Logins: []models.LoginRecord{}}
+	for i := len(m.Logins) - 1; i >= 0 && len(page.Logins) < limit; i-- {
+		if m.Logins[i].TenantID == tenantID && m.Logins[i].UserID == userID {
+			page.Logins = append(page.Logins, m.Logins[i])
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,168 @@
+package mock
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"context"
+	"time"
+)
+
+// In-memory organizations storage
+type MockOrgRepo struct {
+	orgs        map[int]models.Organization
+	members     map[int]map[int]string
+	invitations map[int]models.Invitation
+}
+
+func NewMockOrgRepo() *MockOrgRepo {
+	return &MockOrgRepo{
+		orgs:        make(map[int]models.Organization),
+		members:     make(map[int]map[int]string),
+		invitations: make(map[int]models.Invitation),
+	}
+}
+
+func (m *MockOrgRepo) CreateOrg(ctx context.Context, org *models.Organization) error {
+	org.ID = len(m.orgs) + 1
+	org.Created_At = time.Now()
+	m.orgs[org.ID] = *org
+	m.members[org.ID] = map[int]string{org.OwnerID: models.OrgOwnerRole}
+	return nil
+}
+
+func (m *MockOrgRepo) GetOrg(ctx context.Context, tenantID string, orgID int) (models.Organization, error) {
+	org, ok := m.orgs[orgID]
+	if !ok || org.TenantID != tenantID {
+		return models.Organization{}, repo.ErrOrgNotExist
+	}
+	return org, nil
+}
+
+func (m *MockOrgRepo) GetMembership(ctx context.Context, orgID, userID int) (models.Membership, error) {
+	role, ok := m.members[orgID][userID]
+	if !ok {
+		return models.Membership{}, repo.ErrMemberNotExist
+	}
+	return models.Membership{OrgID: orgID, UserID: userID, Role: role}, nil
+}
+
+func (m *MockOrgRepo) ListMembers(ctx context.Context, orgID int) ([]models.Membership, error) {
+	var members []models.Membership
+	for userID, role := range m.members[orgID] {
+		members = append(members, models.Membership{OrgID: orgID, UserID: userID, Role: role})
+	}
+	return members, nil
+}
+
+func (m *MockOrgRepo) ListUserMemberships(ctx context.Context, userID int) ([]models.Membership, error) {
+	members := []models.Membership{}
+	for orgID, roles := range m.members {
+		if role, ok := roles[userID]; ok {
+			members = append(members, models.Membership{OrgID: orgID, OrgName: m.orgs[orgID].Name, UserID: userID, Role: role})
+		}
+	}
+	return members, nil
+}
+
+func (m *MockOrgRepo) AddMember(ctx context.Context, orgID, userID int, role string) error {
+	if _, ok := m.members[orgID][userID]; ok {
+		return models.ErrAlreadyMember
+	}
+	m.members[orgID][userID] = role
+	return nil
+}
+
+func (m *MockOrgRepo) UpdateMemberRole(ctx context.Context, orgID, userID int, role string) error {
+	if _, ok := m.members[orgID][userID]; !ok {
+		return repo.ErrMemberNotExist
+	}
+	m.members[orgID][userID] = role
+	return nil
+}
+
+func (m *MockOrgRepo) SaveInvitation(ctx context.Context, inv *models.Invitation) error {
+	inv.ID = len(m.invitations) + 1
+	inv.Created_At = time.Now()
+	m.invitations[inv.ID] = *inv
+	return nil
+}
+
+func (m *MockOrgRepo) GetInvitation(ctx context.Context, invitationID int) (models.Invitation, error) {
+	inv, ok := m.invitations[invitationID]
+	if !ok {
+		return models.Invitation{}, repo.ErrInvitationNotExist
+	}
+	return inv, nil
+}
+
+func (m *MockOrgRepo) ListInvitations(ctx context.Context, orgID int) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	for _, inv := range m.invitations {
+		if inv.OrgID == orgID {
+			invitations = append(invitations, inv)
+		}
+	}
+	return invitations, nil
+}
+
+func (m *MockOrgRepo) AcceptInvitation(ctx context.Context, invitation models.Invitation, user *models.User, events ...models.UserEvent) error {
+	inv, ok := m.invitations[invitation.ID]
+	if !ok || !inv.IsPending() {
+		return models.ErrInvitationInvalid
+	}
+	// Новый аккаунт получает ID только если приглашение принято
+	userID := user.ID
+	if userID == 0 {
+		userID = 1
+	}
+	if _, ok := m.members[inv.OrgID][userID]; ok {
+		return models.ErrAlreadyMember
+	}
+	now := time.Now()
+	inv.Accepted_At = &now
+	m.invitations[inv.ID] = inv
+	m.members[inv.OrgID][userID] = inv.Role
+	user.ID = userID
+	return nil
+}
+
+func (m *MockOrgRepo) RevokeInvitation(ctx context.Context, orgID, invitationID int) error {
+	inv, ok := m.invitations[invitationID]
+	if !ok || inv.OrgID != orgID || !inv.IsPending() {
+		return repo.ErrInvitationNotExist
+	}
+	now := time.Now()
+	inv.Revoked_At = &now
+	m.invitations[invitationID] = inv
+	return nil
+}
+
+// Remembers the last sent link instead of delivering it
+type MockNotifier struct {
+	Email string
+	Link  string
+
+	NewDeviceLogins []models.LoginRecord
+}
+
+func NewMockNotifier() *MockNotifier {
+	return &MockNotifier{}
+}
+
+func (n *MockNotifier) SendInvitation(email string, org models.Organization, link string) error {
+	n.Email = email
+	n.Link = link
+	return nil
+}
+
+func (n *MockNotifier) SendEmailConfirmation(email, link string) error {
+	n.Email = email
+	n.Link = link
+	return nil
+}
+
+func (n *MockNotifier) SendNewDeviceLogin(email string, login models.LoginRecord) error {
+	n.Email = email
+	n.NewDeviceLogins = append(n.NewDeviceLogins, login)
+	return nil
+}
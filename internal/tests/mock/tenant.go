@@ -0,0 +1,44 @@
+package mock
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"context"
+	"time"
+)
+
+type MockTenantRepo struct {
+}
+
+func NewMockTenantRepo() *MockTenantRepo {
+	return &MockTenantRepo{}
+}
+
+func (*MockTenantRepo) GetTenant(ctx context.Context, tenantID string) (models.Tenant, error) {
+	switch tenantID {
+	case "default":
+		return models.Tenant{ID: tenantID, Name: "Default"}, nil
+	case "strict":
+		return models.Tenant{
+			ID:         tenantID,
+			Name:       "Strict",
+			AccessTTL:  time.Minute,
+			RefreshTTL: time.Hour,
+			Password: &models.PasswordPolicy{
+				MinLength:     12,
+				RequireDigit:  true,
+				RequireUpper:  true,
+				RequireSymbol: true,
+			},
+		}, nil
+	}
+	return models.Tenant{}, repo.ErrTenantNotExist
+}
+
+func (*MockTenantRepo) UpdateTenant(ctx context.Context, tenant models.Tenant) error {
+	return nil
+}
+
+func (*MockTenantRepo) CreateTenant(ctx context.Context, tenantID, name string) error {
+	return nil
+}
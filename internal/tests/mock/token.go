@@ -2,6 +2,7 @@ package mock
 
 import (
 	"auth/internal/domain/models"
+	"context"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,12 +15,13 @@ func NewMockTokenService() *MockTokenService {
 	return &MockTokenService{}
 }
 
-func (s *MockTokenService) GenerateTokens(user models.User) (models.TokenPair, error) {
+func (s *MockTokenService) GenerateTokens(ctx context.Context, user models.User) (models.TokenPair, error) {
 	return models.TokenPair{
 		AccessToken:      "accessToken",
 		AccessExpiresAt:  time.Now().Add(time.Minute * 15),
 		RefreshToken:     "refreshToken",
 		RefreshExpiresAt: time.Now().Add(time.Hour * 7),
+		CSRFToken:        "csrfToken",
 	}, nil
 }
 func (s *MockTokenService) NewAccessClaim(user models.User) jwt.Claims {
@@ -30,33 +32,49 @@ func (s *MockTokenService) NewRefreshClaim(user models.User) jwt.Claims {
 	return jwt.MapClaims{}
 }
 
-func (s *MockTokenService) Refresh(refreshToken string) (models.TokenPair, error) {
+func (s *MockTokenService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
 	return models.TokenPair{}, nil
 }
 
-func (s *MockTokenService) Validate(token string) (models.CustomClaims, error) {
+func (s *MockTokenService) Validate(ctx context.Context, token string) (models.CustomClaims, error) {
 	s.getSecret()
-	isAdmin, isRefresh, email := false, false, "defaultEmail@gmail.com"
+	id, isAdmin, isRefresh, email, role := 0, false, false, "defaultEmail@gmail.com", ""
 	switch token {
 	case "adminToken":
-		isAdmin = true
+		id, isAdmin = AdminUserID, true
 		email = "adminEmail@gmail.com"
+	case "serviceToken":
+		role = models.ServiceRole
 	case "refresh":
 		isRefresh = true
 	case "invalidToken":
 		return models.CustomClaims{}, models.ErrInvalidToken
 	case "notExistToken":
-		email = "uniqueMail@gmail.com"
+		id, email = MissingUserID, "uniqueMail@gmail.com"
 	}
 
 	return models.CustomClaims{
+		ID:        id,
+		TenantID:  "default",
 		Name:      "testName",
 		Email:     email,
 		IsAdmin:   isAdmin,
+		Role:      role,
 		IsRefresh: isRefresh,
 	}, nil
 }
 
+func (s *MockTokenService) Claims(token string) (models.CustomClaims, error) {
+	return s.Validate(context.Background(), token)
+}
+
+func (s *MockTokenService) CheckCSRF(token string, claims models.CustomClaims) error {
+	if token != "csrfToken" {
+		return models.ErrInvalidToken
+	}
+	return nil
+}
+
 func (s *MockTokenService) getSecret() string {
 	return "secretKey"
 }
@@ -3,53 +3,150 @@ package mock
 import (
 	"auth/internal/adapters/repo"
 	"auth/internal/domain/models"
+	"context"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	AdminUserID    = 12
+	DisabledUserID = 13
+	MissingUserID  = 14
+)
+
 type MockUserRepo struct {
+	mu     sync.Mutex
+	Events []models.UserEvent // Events emitted by writes
+
+	ProfileUpdates []models.ProfileUpdate // Saved profile updates
 }
 
 func NewMockUserRepo() *MockUserRepo {
 	return &MockUserRepo{}
 }
 
-func (*MockUserRepo) GetUser(email string) (models.User, error) {
+func (*MockUserRepo) GetUser(ctx context.Context, tenantID, email string) (models.User, error) {
 	passHash, _ := bcrypt.GenerateFromPassword([]byte("validPassword"), bcrypt.DefaultCost)
-	isAdmin := false
+	id, isAdmin, mustChange, status := 1, false, false, models.StatusActive
 	switch email {
+	case "tempPassword@gmail.com":
+		mustChange = true
 	case "adminEmail@gmail.com":
-		isAdmin = true
+		id, isAdmin = AdminUserID, true
+	case "disabledEmail@gmail.com":
+		status = models.StatusDisabled
 	case "uniqueMail@gmail.com":
 		return models.User{}, repo.ErrUserNotExist
 	}
 
 	user := models.User{
-		ID:         1,
+		ID:         id,
+		TenantID:   tenantID,
 		Name:       "testName",
 		Email:      email,
 		IsAdmin:    isAdmin,
+		Status:     status,
 		Created_At: time.Now(),
-		Updated_At: time.Now(),
+
+		MustChangePassword: mustChange,
+		Updated_At:         time.Now(),
 	}
 	user.SetPassword(string(passHash))
 	return user, nil
 }
 
-func (*MockUserRepo) SaveUser(user *models.User) error {
+func (m *MockUserRepo) SaveUser(ctx context.Context, user *models.User, events ...models.UserEvent) error {
 	user.ID = 1
+	m.addEvents(events)
+	return nil
+}
+
+func (m *MockUserRepo) DeleteUser(ctx context.Context, tenantID string, userID int, events ...models.UserEvent) error {
+	m.addEvents(events)
 	return nil
 }
 
-func (*MockUserRepo) DeleteUser(userID int) error {
+func (m *MockUserRepo) UpdateUser(ctx context.Context, tenantID, name, role string, userID int, events ...models.UserEvent) error {
+	m.addEvents(events)
 	return nil
 }
 
-func (*MockUserRepo) UpdateUser(name, role string, userID int) error {
+// User with ID AdminUserID is the admin, DisabledUserID is disabled, MissingUserID doesn't exist, others are active
+func (m *MockUserRepo) GetUserByID(ctx context.Context, tenantID string, userID int) (models.User, error) {
+	switch userID {
+	case AdminUserID:
+		return m.GetUser(ctx, tenantID, "adminEmail@gmail.com")
+	case DisabledUserID:
+		return m.GetUser(ctx, tenantID, "disabledEmail@gmail.com")
+	case MissingUserID:
+		return models.User{}, repo.ErrUserNotExist
+	}
+	return m.GetUser(ctx, tenantID, "defaultEmail@gmail.com")
+}
+
+func (*MockUserRepo) ListUsers(ctx context.Context, filter models.UserFilter) (models.UserPage, error) {
+	return models.UserPage{}, nil
+}
+
+func (m *MockUserRepo) SaveUsers(ctx context.Context, users []*models.User, events ...models.UserEvent) error {
+	for i, user := range users {
+		user.ID = i + 1
+		m.addEvents(events)
+	}
+	return nil
+}
+
+func (*MockUserRepo) UpdatePassword(ctx context.Context, tenantID string, userID int, passHash string, mustChange bool) error {
+	return nil
+}
+
+// Admin with email "adminEmail@gmail.com" is the only admin of every tenant
+func (m *MockUserRepo) SetAdmin(ctx context.Context, tenantID string, userID int, isAdmin bool, role string, events ...models.UserEvent) error {
+	if !isAdmin {
+		return models.ErrLastAdmin
+	}
+	m.addEvents(events)
+	return nil
+}
+
+func (*MockUserRepo) ExportUsers(ctx context.Context, filter models.UserFilter, fn func(models.User) error) error {
+	return nil
+}
+
+// Admin with ID AdminUserID is the only admin of every tenant, so it can't leave active status
+func (m *MockUserRepo) UpdateStatus(ctx context.Context, tenantID string, userID int, from []string, to string, events ...models.UserEvent) error {
+	if userID == AdminUserID && to != models.StatusActive {
+		return models.ErrLastAdmin
+	}
+	m.addEvents(events)
+	return nil
+}
+
+func (*MockUserRepo) PurgeUsers(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUserRepo) UpdateProfile(ctx context.Context, tenantID string, userID int, update models.ProfileUpdate, events ...models.UserEvent) error {
+	m.mu.Lock()
+	m.ProfileUpdates = append(m.ProfileUpdates, update)
+	m.mu.Unlock()
+	m.addEvents(events)
+	return nil
+}
+
+// Email of every mock user by ID is "defaultEmail@gmail.com", so other old email means it was already changed
+func (m *MockUserRepo) UpdateEmail(ctx context.Context, tenantID string, userID int, oldEmail, newEmail string, events ...models.UserEvent) error {
+	if oldEmail != "defaultEmail@gmail.com" {
+		return repo.ErrUserNotExist
+	}
+	m.addEvents(events)
 	return nil
 }
 
-func (*MockUserRepo) GetUserByID(userID int) (models.User, error) {
-	return models.User{}, nil
+func (m *MockUserRepo) addEvents(events []models.UserEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Events = append(m.Events, events...)
 }
@@ -0,0 +1,104 @@
+package mock
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// Failed delivery attempt saved by the dispatcher
+type FailedDelivery struct {
+	ID            int64
+	LastErr       string
+	NextAttemptAt time.Time
+	Dead          bool
+}
+
+// In-memory webhook storage, due deliveries are claimed once
+type MockWebhookRepo struct {
+	mu        sync.Mutex
+	Webhooks  []models.Webhook
+	Due       []models.WebhookDelivery
+	Delivered []int64
+	Failed    []FailedDelivery
+	FannedOut int
+	Purged    bool
+}
+
+func NewMockWebhookRepo() *MockWebhookRepo {
+	return &MockWebhookRepo{}
+}
+
+func (m *MockWebhookRepo) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhook.ID = len(m.Webhooks) + 1
+	webhook.Created_At = time.Now()
+	m.Webhooks = append(m.Webhooks, *webhook)
+	return nil
+}
+
+func (m *MockWebhookRepo) ListWebhooks(ctx context.Context, tenantID string) ([]models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhooks := []models.Webhook{}
+	for _, webhook := range m.Webhooks {
+		if webhook.TenantID == tenantID {
+			webhook.Secret = ""
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *MockWebhookRepo) DeleteWebhook(ctx context.Context, tenantID string, webhookID int) error {
+	return nil
+}
+
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, tenantID, status string, limit int) ([]models.WebhookDelivery, error) {
+	return []models.WebhookDelivery{}, nil
+}
+
+func (m *MockWebhookRepo) RetryDelivery(ctx context.Context, tenantID string, deliveryID int64) error {
+	return nil
+}
+
+func (m *MockWebhookRepo) FanOutEvents(ctx context.Context, limit int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.FannedOut++
+	return 0, nil
+}
+
+func (m *MockWebhookRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := m.Due
+	m.Due = nil
+	return due, nil
+}
+
+func (m *MockWebhookRepo) MarkDelivered(ctx context.Context, deliveryID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Delivered = append(m.Delivered, deliveryID)
+	return nil
+}
+
+func (m *MockWebhookRepo) MarkFailed(ctx context.Context, deliveryID int64, lastErr string, nextAttemptAt time.Time, dead bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Failed = append(m.Failed, FailedDelivery{ID: deliveryID, LastErr: lastErr, NextAttemptAt: nextAttemptAt, Dead: dead})
+	return nil
+}
+
+func (m *MockWebhookRepo) PurgeEvents(ctx context.Context, dispatchedBefore time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Purged = true
+	return 0, nil
+}
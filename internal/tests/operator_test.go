@@ -0,0 +1,149 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newOperator(userRepo *mock.MockUserRepo, keyRepo *mock.MockKeyRepo, auditRepo *mock.MockAuditRepo) *service.OperatorService {
+	tokenServ := mock.NewMockTokenService()
+	auditServ := service.NewAuditService(auditRepo, tokenServ, slog.Default())
+	loginServ := service.NewLoginService(mock.NewMockLoginRepo(), tokenServ, mock.NewMockNotifier(), slog.Default())
+	authServ := service.NewAuthService(userRepo, mock.NewMockTenantRepo(), tokenServ, auditServ, loginServ, models.PasswordPolicy{MinLength: 8}, slog.Default())
+	return service.NewOperatorService(userRepo, mock.NewMockTenantRepo(), keyRepo, authServ, auditServ, slog.Default())
+}
+
+func TestOperatorCreateAdmin(t *testing.T) {
+	userRepo, auditRepo := mock.NewMockUserRepo(), mock.NewMockAuditRepo()
+	operator := newOperator(userRepo, mock.NewMockKeyRepo(), auditRepo)
+
+	admin, tempPassword, err := operator.CreateAdmin(context.Background(), models.User{TenantID: "default", Name: "Operator", Email: "operator@gmail.com"}, "")
+	if err != nil {
+		t.Fatalf("CreateAdmin() error = %v", err)
+	}
+	if !admin.IsAdmin || admin.Role != models.AdminRole || !admin.MustChangePassword || tempPassword == "" {
+		t.Errorf("expected admin with temporary password, got %+v, password %q", admin, tempPassword)
+	}
+	if len(userRepo.Events) != 1 || userRepo.Events[0].Type != models.EventUserRegistered {
+		t.Errorf("expected registered event, got %v", userRepo.Events)
+	}
+	if len(auditRepo.Entries) != 1 || auditRepo.Entries[0].UserAgent != "auth-cli" {
+		t.Errorf("expected audit entry of the command line, got %+v", auditRepo.Entries)
+	}
+
+	// Пароль проверяется по политике тенанта
+	if _, _, err := operator.CreateAdmin(context.Background(), models.User{TenantID: "strict", Name: "Operator", Email: "operator@gmail.com"}, "weakpassword"); !errors.Is(err, models.ErrWeakPassword) {
+		t.Errorf("expected error = %v, got %v", models.ErrWeakPassword, err)
+	}
+}
+
+func TestOperatorAdminRights(t *testing.T) {
+	userRepo := mock.NewMockUserRepo()
+	operator := newOperator(userRepo, mock.NewMockKeyRepo(), mock.NewMockAuditRepo())
+
+	if err := operator.PromoteAdmin(context.Background(), "default", "defaultEmail@gmail.com"); err != nil {
+		t.Fatalf("PromoteAdmin() error = %v", err)
+	}
+	if len(userRepo.Events) != 2 || userRepo.Events[1].Type != models.EventUserRoleChanged {
+		t.Errorf("expected updated and role changed events, got %v", userRepo.Events)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"Promote disabled user", func() error { return operator.PromoteAdmin(context.Background(), "default", "disabledEmail@gmail.com") }, models.ErrUserInactive},
+		{"Promote unknown user", func() error { return operator.PromoteAdmin(context.Background(), "default", "uniqueMail@gmail.com") }, repo.ErrUserNotExist},
+		{"Demote last admin", func() error { return operator.DemoteAdmin(context.Background(), "default", "adminEmail@gmail.com") }, models.ErrLastAdmin},
+		{"Disable last admin", func() error { return operator.DisableUser(context.Background(), "default", "adminEmail@gmail.com") }, models.ErrLastAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, tt.want) {
+				t.Errorf("expected error = %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestOperatorResetPassword(t *testing.T) {
+	operator := newOperator(mock.NewMockUserRepo(), mock.NewMockKeyRepo(), mock.NewMockAuditRepo())
+
+	tempPassword, err := operator.ResetPassword(context.Background(), "default", "defaultEmail@gmail.com", "")
+	if err != nil || len(tempPassword) < 8 {
+		t.Fatalf("expected temporary password, got %q, err = %v", tempPassword, err)
+	}
+	if tempPassword, err := operator.ResetPassword(context.Background(), "default", "defaultEmail@gmail.com", "newPassword1"); err != nil || tempPassword != "" {
+		t.Errorf("expected no temporary password, got %q, err = %v", tempPassword, err)
+	}
+	if _, err := operator.ResetPassword(context.Background(), "default", "uniqueMail@gmail.com", ""); !errors.Is(err, repo.ErrUserNotExist) {
+		t.Errorf("expected error = %v, got %v", repo.ErrUserNotExist, err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	keyRepo := mock.NewMockKeyRepo()
+	newTokenServ := func() *service.TokenService {
+		return service.NewTokenService("supersecretkey", keyRepo, mock.NewMockUserRepo(), mock.NewMockTenantRepo(), time.Hour, time.Minute*5, slog.Default())
+	}
+	user := models.User{ID: 1, TenantID: "default", Name: "testName", Email: "defaultEmail@gmail.com", Role: models.UserRole}
+
+	// До первой ротации токены подписываются секретом из конфигурации
+	legacy, err := newTokenServ().GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	key, err := newOperator(mock.NewMockUserRepo(), keyRepo, mock.NewMockAuditRepo()).RotateKey(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("RotateKey() error = %v", err)
+	}
+
+	tokenServ := newTokenServ()
+	rotated, err := tokenServ.GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(rotated.AccessToken, jwt.MapClaims{})
+	if err != nil || parsed.Header["kid"] != key.ID {
+		t.Fatalf("expected kid %s, got %v, err = %v", key.ID, parsed.Header["kid"], err)
+	}
+
+	for name, token := range map[string]string{"legacy": legacy.AccessToken, "rotated": rotated.AccessToken} {
+		if _, err := tokenServ.Validate(context.Background(), token); err != nil {
+			t.Errorf("%s token: expected no error, got %v", name, err)
+		}
+	}
+
+	// Токен с чужим ключом не принимается
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, service.NewAccessClaim(user, "", time.Minute))
+	forged.Header["kid"] = key.ID
+	signed, _ := forged.SignedString([]byte("supersecretkey"))
+	if _, err := tokenServ.Validate(context.Background(), signed); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("expected error = %v, got %v", models.ErrInvalidToken, err)
+	}
+
+	// После grace периода первой ротации секрет больше не проверяет токены
+	retired := time.Now().Add(-time.Minute)
+	for i := range keyRepo.Keys {
+		keyRepo.Keys[i].Retires_At = &retired
+	}
+	keyRepo.Keys[0].Retires_At = nil
+	tokenServ = newTokenServ()
+	if _, err := tokenServ.Validate(context.Background(), legacy.AccessToken); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("legacy token: expected error = %v, got %v", models.ErrInvalidToken, err)
+	}
+	if _, err := tokenServ.Validate(context.Background(), rotated.AccessToken); err != nil {
+		t.Errorf("rotated token: expected no error, got %v", err)
+	}
+}
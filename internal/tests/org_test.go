@@ -0,0 +1,132 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestInvitationFlow(t *testing.T) {
+	userRepo := mock.NewMockUserRepo()
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), userRepo, mock.NewMockTenantRepo(), time.Hour, time.Minute*5, slog.Default())
+	auditRepo := mock.NewMockAuditRepo()
+	auditServ := service.NewAuditService(auditRepo, tokenServ, slog.Default())
+	loginServ := service.NewLoginService(mock.NewMockLoginRepo(), tokenServ, mock.NewMockNotifier(), slog.Default())
+	authServ := service.NewAuthService(userRepo, mock.NewMockTenantRepo(), tokenServ, auditServ, loginServ, models.PasswordPolicy{MinLength: 8}, slog.Default())
+	notifier := mock.NewMockNotifier()
+	orgServ := service.NewOrgService(mock.NewMockOrgRepo(), userRepo, authServ, tokenServ, auditServ, notifier, "http://localhost/invitations/accept", time.Hour, slog.Default())
+
+	owner, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 2, TenantID: "default", Name: "Owner", Email: "owner@gmail.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	org, err := orgServ.CreateOrg(context.Background(), "Backend team", owner.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, err := orgServ.Invite(context.Background(), org.ID, "uniqueMail@gmail.com", models.OrgMemberRole, owner.AccessToken, models.RequestMeta{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	link, err := url.Parse(notifier.Link)
+	if err != nil {
+		t.Fatalf("expected valid link, got %v", err)
+	}
+	token := link.Query().Get("token")
+
+	// Приглашение на другой email нельзя принять чужим аккаунтом
+	if _, err := orgServ.AcceptInvitation(context.Background(), token, owner.AccessToken, "", "", models.RequestMeta{}); !errors.Is(err, models.ErrInvitationEmail) {
+		t.Errorf("expected error %v, got %v", models.ErrInvitationEmail, err)
+	}
+
+	member, err := orgServ.AcceptInvitation(context.Background(), token, "", "New Member", "validPassword", models.RequestMeta{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if member.Role != models.OrgMemberRole {
+		t.Errorf("expected role %v, got %v", models.OrgMemberRole, member.Role)
+	}
+
+	// Повторно принять приглашение нельзя
+	if _, err := orgServ.AcceptInvitation(context.Background(), token, "", "New Member", "validPassword", models.RequestMeta{}); !errors.Is(err, models.ErrInvitationInvalid) {
+		t.Errorf("expected error %v, got %v", models.ErrInvitationInvalid, err)
+	}
+
+	// Рядовой участник не может приглашать
+	memberTokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: member.UserID, TenantID: "default", Email: "uniqueMail@gmail.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := orgServ.Invite(context.Background(), org.ID, "other@gmail.com", models.OrgMemberRole, memberTokens.AccessToken, models.RequestMeta{}); !errors.Is(err, models.ErrPermissionDenied) {
+		t.Errorf("expected error %v, got %v", models.ErrPermissionDenied, err)
+	}
+
+	// Админ организации приглашает участников, но не админов
+	if err := orgServ.UpdateMemberRole(context.Background(), org.ID, member.UserID, models.OrgAdminRole, owner.AccessToken, models.RequestMeta{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := orgServ.Invite(context.Background(), org.ID, "other@gmail.com", models.OrgAdminRole, memberTokens.AccessToken, models.RequestMeta{}); !errors.Is(err, models.ErrPermissionDenied) {
+		t.Errorf("expected error %v, got %v", models.ErrPermissionDenied, err)
+	}
+	if _, err := orgServ.Invite(context.Background(), org.ID, "uniqueMail@gmail.com", models.OrgMemberRole, memberTokens.AccessToken, models.RequestMeta{}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	// Роль владельца не меняется
+	if err := orgServ.UpdateMemberRole(context.Background(), org.ID, 2, models.OrgMemberRole, owner.AccessToken, models.RequestMeta{}); !errors.Is(err, models.ErrCannotChangeOwner) {
+		t.Errorf("expected error %v, got %v", models.ErrCannotChangeOwner, err)
+	}
+
+	// Регистрация откатывается вместе с приглашением, в журнал не попадает ID несохраненного аккаунта
+	link, err = url.Parse(notifier.Link)
+	if err != nil {
+		t.Fatalf("expected valid link, got %v", err)
+	}
+	if _, err := orgServ.AcceptInvitation(context.Background(), link.Query().Get("token"), "", "New Member", "validPassword", models.RequestMeta{}); !errors.Is(err, models.ErrAlreadyMember) {
+		t.Errorf("expected error %v, got %v", models.ErrAlreadyMember, err)
+	}
+	if last := auditRepo.Entries[len(auditRepo.Entries)-1]; last.Action != models.AuditRegister || last.Outcome != models.OutcomeFailure || last.TargetID != 0 {
+		t.Errorf("expected failed registration without user, got %+v", last)
+	}
+
+	// Изменения организации и отказы в доступе попадают в журнал
+	var invites, denied, roles int
+	for _, entry := range auditRepo.Entries {
+		switch {
+		case entry.Action == models.AuditOrgInvite && entry.Outcome == models.OutcomeFailure:
+			denied++
+		case entry.Action == models.AuditOrgInvite:
+			invites++
+		case entry.Action == models.AuditOrgRole:
+			roles++
+		}
+	}
+	if invites != 2 || denied != 2 || roles != 2 {
+		t.Errorf("expected 2 invites, 2 denied invites and 2 role changes in audit log, got %d, %d, %d", invites, denied, roles)
+	}
+}
+
+func TestAcceptInvitation_InvalidToken(t *testing.T) {
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), nil, mock.NewMockTenantRepo(), time.Hour, time.Minute*5, slog.Default())
+	orgServ := service.NewOrgService(mock.NewMockOrgRepo(), mock.NewMockUserRepo(), nil, tokenServ, nil, mock.NewMockNotifier(), "", time.Hour, slog.Default())
+
+	// Access token не является токеном приглашения
+	tokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, token := range []string{"", "garbage", tokens.AccessToken} {
+		if _, err := orgServ.AcceptInvitation(context.Background(), token, "", "Name", "validPassword", models.RequestMeta{}); !errors.Is(err, models.ErrInvitationInvalid) {
+			t.Errorf("expected error %v for token %q, got %v", models.ErrInvitationInvalid, token, err)
+		}
+	}
+}
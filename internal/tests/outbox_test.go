@@ -0,0 +1,103 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/migrations"
+	"auth/pkg/postgres"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// Opens database of TEST_DATABASE_URL with applied migrations, tests of repositories are skipped without it
+func testDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := postgres.NewMigrator(db, migrations.FS, slog.Default())
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	return db
+}
+
+func TestListEventsCommitOrder(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	tenantID := fmt.Sprintf("outbox-%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.Exec(`DELETE FROM Outbox WHERE TenantID = $1`, tenantID) })
+	eventDal := repo.NewEventDal(db, repo.Timeouts{Query: 5 * time.Second})
+
+	insert := func(tx *sql.Tx) int64 {
+		var id int64
+		if err := tx.QueryRowContext(ctx, `INSERT INTO Outbox (TenantID, Type, Payload) VALUES ($1, 'user.updated', '{}') RETURNING ID`, tenantID).Scan(&id); err != nil {
+			t.Fatalf("insert event: %v", err)
+		}
+		return id
+	}
+	list := func(cursor int64) []int64 {
+		events, err := eventDal.ListEvents(ctx, tenantID, cursor, nil, 100)
+		if err != nil {
+			t.Fatalf("ListEvents() error = %v", err)
+		}
+		var ids []int64
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		return ids
+	}
+
+	// Вторая транзакция получает xid раньше, а ID события позже первой и фиксируется первой
+	late, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer late.Rollback()
+	if _, err := late.ExecContext(ctx, `SELECT pg_current_xact_id()`); err != nil {
+		t.Fatalf("assign xid: %v", err)
+	}
+
+	running, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer running.Rollback()
+	low := insert(running)
+	high := insert(late)
+	if err := late.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	ids := list(0)
+	if len(ids) != 1 || ids[0] != high {
+		t.Fatalf("expected only committed event %d, got %v", high, ids)
+	}
+
+	// Событие с меньшим ID, зафиксированное позже, идет после курсора
+	if ids := list(high); len(ids) != 0 {
+		t.Fatalf("expected no events while transaction is running, got %v", ids)
+	}
+	if err := running.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if ids := list(high); len(ids) != 1 || ids[0] != low {
+		t.Fatalf("expected event %d committed after the cursor, got %v", low, ids)
+	}
+}
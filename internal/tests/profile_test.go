@@ -0,0 +1,97 @@
+package service
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestValidateProfile(t *testing.T) {
+	str := func(s string) *string { return &s }
+	testCases := []struct {
+		name    string
+		update  models.ProfileUpdate
+		wantErr bool
+	}{
+		{name: "full update", update: models.ProfileUpdate{Name: str("John Doe"), AvatarURL: str("https://cdn.example.com/a.png"),
+			Locale: str("en-US"), Timezone: str("Europe/Berlin"), Metadata: json.RawMessage(`{"theme":"dark"}`)}},
+		{name: "reset fields", update: models.ProfileUpdate{AvatarURL: str(""), Locale: str(""), Timezone: str("")}},
+		{name: "nothing to update", update: models.ProfileUpdate{}, wantErr: true},
+		{name: "short name", update: models.ProfileUpdate{Name: str("Jo")}, wantErr: true},
+		{name: "relative avatar", update: models.ProfileUpdate{AvatarURL: str("/avatar.png")}, wantErr: true},
+		{name: "javascript avatar", update: models.ProfileUpdate{AvatarURL: str("javascript:alert(1)")}, wantErr: true},
+		{name: "invalid locale", update: models.ProfileUpdate{Locale: str("english please")}, wantErr: true},
+		{name: "unknown timezone", update: models.ProfileUpdate{Timezone: str("Mars/Olympus")}, wantErr: true},
+		{name: "metadata array", update: models.ProfileUpdate{Metadata: json.RawMessage(`[1,2]`)}, wantErr: true},
+		{name: "invalid email", update: models.ProfileUpdate{Email: str("not an email")}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := validate.Profile(tc.update); (err != nil) != tc.wantErr {
+				t.Errorf("expected error = %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEmailChange(t *testing.T) {
+	userRepo := mock.NewMockUserRepo()
+	tokenServ := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), userRepo, mock.NewMockTenantRepo(), time.Hour, time.Minute*5, slog.Default())
+	notifier := mock.NewMockNotifier()
+	profileServ := service.NewProfileService(userRepo, tokenServ, notifier, "http://localhost/me/email/confirm", time.Hour, slog.Default())
+
+	tokens, err := tokenServ.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default", Name: "testName", Email: "defaultEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Занятый email нельзя запросить
+	taken, name := "adminEmail@gmail.com", "New Name"
+	if _, _, err := profileServ.UpdateProfile(context.Background(), models.ProfileUpdate{Name: &name, Email: &taken}, tokens.AccessToken); !errors.Is(err, models.ErrNotUniqueEmail) {
+		t.Errorf("expected error %v, got %v", models.ErrNotUniqueEmail, err)
+	}
+	// Остальные поля не сохраняются вместе с отклоненным email
+	if len(userRepo.ProfileUpdates) != 0 {
+		t.Errorf("expected no saved profile updates, got %+v", userRepo.ProfileUpdates)
+	}
+
+	newEmail := "uniqueMail@gmail.com"
+	user, pendingEmail, err := profileServ.UpdateProfile(context.Background(), models.ProfileUpdate{Email: &newEmail}, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if pendingEmail != newEmail || notifier.Email != newEmail {
+		t.Errorf("expected confirmation sent to %v, got pending %q sent to %q", newEmail, pendingEmail, notifier.Email)
+	}
+	// До подтверждения email не меняется
+	if user.Email != "defaultEmail@gmail.com" {
+		t.Errorf("expected email to stay unchanged, got %v", user.Email)
+	}
+
+	link, err := url.Parse(notifier.Link)
+	if err != nil {
+		t.Fatalf("expected valid link, got %v", err)
+	}
+	if _, err := profileServ.ConfirmEmail(context.Background(), link.Query().Get("token")); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	// Событие публикуется только подтвержденной сменой email
+	if len(userRepo.Events) != 1 || userRepo.Events[0].Type != models.EventUserUpdated {
+		t.Errorf("expected one %s event, got %+v", models.EventUserUpdated, userRepo.Events)
+	}
+
+	// Access token не является токеном подтверждения
+	if _, err := profileServ.ConfirmEmail(context.Background(), tokens.AccessToken); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("expected error %v, got %v", models.ErrInvalidToken, err)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"auth/pkg/logger"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDOrNew(t *testing.T) {
+	testCases := []struct {
+		name  string
+		id    string
+		keeps bool
+	}{
+		{name: "client id", id: "req-42_a.b:c", keeps: true},
+		{name: "empty id", id: ""},
+		{name: "unsafe id", id: "req 42\n"},
+		{name: "long id", id: strings.Repeat("a", 129)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := logger.RequestIDOrNew(tc.id)
+			if tc.keeps && got != tc.id {
+				t.Errorf("expected request id %q, got %q", tc.id, got)
+			}
+			if !tc.keeps && (got == tc.id || len(got) != 32) {
+				t.Errorf("expected generated request id, got %q", got)
+			}
+		})
+	}
+}
+
+func TestLoginPrincipal(t *testing.T) {
+	authServ := newAuthService()
+
+	// Неудачный вход не раскрывает владельца в журнале доступа
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+	if _, err := authServ.Login(ctx, "default", "defaultEmail@gmail.com", "notvalidPassword", models.RequestMeta{}); err == nil {
+		t.Fatal("expected login error")
+	}
+	if principal := logger.Principal(ctx); principal != "" {
+		t.Errorf("expected empty principal, got %q", principal)
+	}
+
+	ctx = logger.WithRequestID(context.Background(), "req-2")
+	if _, err := authServ.Login(ctx, "default", "defaultEmail@gmail.com", "validPassword", models.RequestMeta{}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if principal := logger.Principal(ctx); principal != "default/1" {
+		t.Errorf("expected principal default/1, got %q", principal)
+	}
+	if id := logger.RequestID(ctx); id != "req-2" {
+		t.Errorf("expected request id req-2, got %q", id)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"auth/pkg/tlsconfig"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.issue(t, 1, certFile, keyFile)
+
+	config, err := tlsconfig.New(tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, ReloadCheck: time.Nanosecond}, slog.Default())
+	if err != nil {
+		t.Fatalf("new config: %v", err)
+	}
+	if serial := handshake(t, config, ca, nil); serial != 1 {
+		t.Fatalf("expected certificate 1, got %d", serial)
+	}
+
+	// Ротация подхватывается без перезапуска
+	ca.issue(t, 2, certFile, keyFile)
+	touch(t, certFile, keyFile)
+	if serial := handshake(t, config, ca, nil); serial != 2 {
+		t.Errorf("expected rotated certificate 2, got %d", serial)
+	}
+
+	// Поврежденный файл не ломает уже загруженный сертификат
+	if err := os.WriteFile(certFile, []byte("broken"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, certFile)
+	if serial := handshake(t, config, ca, nil); serial != 2 {
+		t.Errorf("expected previous certificate 2, got %d", serial)
+	}
+}
+
+func TestTLSClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.issue(t, 1, certFile, keyFile)
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	clientCert, clientKey := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	ca.issue(t, 3, clientCert, clientKey)
+	client, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := tlsconfig.New(tlsconfig.Options{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("new config: %v", err)
+	}
+
+	if serial := handshake(t, config, ca, &client); serial != 1 {
+		t.Errorf("expected handshake with client certificate, got %d", serial)
+	}
+	if serial := handshake(t, config, ca, nil); serial != 0 {
+		t.Errorf("expected handshake without client certificate to fail")
+	}
+}
+
+func TestTLSOptions(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	ca.issue(t, 1, certFile, keyFile)
+
+	testCases := []struct {
+		name    string
+		opts    tlsconfig.Options
+		wantErr bool
+	}{
+		{name: "defaults", opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile}},
+		{name: "cipher suites", opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2",
+			CipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256"}},
+		{name: "old version", opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"}, wantErr: true},
+		{name: "insecure suite", opts: tlsconfig.Options{CertFile: certFile, KeyFile: keyFile, CipherSuites: "TLS_RSA_WITH_RC4_128_SHA"}, wantErr: true},
+		{name: "missing key", opts: tlsconfig.Options{CertFile: certFile}, wantErr: true},
+		{name: "missing file", opts: tlsconfig.Options{CertFile: certFile, KeyFile: filepath.Join(dir, "none.key")}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := tlsconfig.New(tc.opts, slog.Default())
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error = %t, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(100),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCA{cert: cert, key: key}
+}
+
+// Writes certificate for localhost signed by the CA, usable by servers and clients
+func (ca testCA) issue(t *testing.T, serial int64, certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Moves modification time forward, file systems with coarse timestamps may keep it after a quick rewrite
+func touch(t *testing.T, files ...string) {
+	t.Helper()
+	future := time.Now().Add(time.Minute)
+	for _, file := range files {
+		if err := os.Chtimes(file, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Returns serial of the server certificate or 0 when handshake failed
+func handshake(t *testing.T, config *tls.Config, ca testCA, client *tls.Certificate) int64 {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if client != nil {
+		clientConfig.Certificates = []tls.Certificate{*client}
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	server := tls.Server(serverConn, config)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Handshake()
+		// Клиент TLS 1.3 узнает об отказе только при чтении
+		server.Write([]byte{0})
+		serverConn.Close()
+	}()
+
+	conn := tls.Client(clientConn, clientConfig)
+	if err := conn.Handshake(); err != nil {
+		return 0
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return 0
+	}
+	if err := <-serverErr; err != nil {
+		return 0
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+}
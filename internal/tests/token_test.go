@@ -1,9 +1,13 @@
 package service
 
 import (
+	"auth/internal/adapters/repo"
 	"auth/internal/domain/models"
 	"auth/internal/service"
 	"auth/internal/tests/mock"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
 	"testing"
@@ -12,21 +16,24 @@ import (
 
 func TestGenerateAndValidateTokens(t *testing.T) {
 	user := models.User{
-		ID:      1,
-		Name:    "Test User",
-		Email:   "test@example.com",
-		IsAdmin: false,
+		ID:       1,
+		TenantID: "default",
+		Name:     "Test User",
+		Email:    "test@example.com",
+		IsAdmin:  false,
 	}
 
 	tokenService := service.NewTokenService(
 		"supersecretkey",
-		nil, // UserRepo не нужен для GenerateTokens и Validate
+		mock.NewMockKeyRepo(),
+		mock.NewMockUserRepo(),
+		mock.NewMockTenantRepo(),
 		time.Minute*5,
 		time.Minute*5,
 		slog.Default(),
 	)
 
-	tokens, err := tokenService.GenerateTokens(user)
+	tokens, err := tokenService.GenerateTokens(context.Background(), user)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -35,7 +42,7 @@ func TestGenerateAndValidateTokens(t *testing.T) {
 		t.Fatalf("expected non-empty tokens, got %+v", tokens)
 	}
 
-	claims, err := tokenService.Validate(tokens.AccessToken)
+	claims, err := tokenService.Validate(context.Background(), tokens.AccessToken)
 	if err != nil {
 		t.Fatalf("expected no error during validation, got %v", err)
 	}
@@ -52,77 +59,237 @@ func TestGenerateAndValidateTokens(t *testing.T) {
 	if claims.IsRefresh != false {
 		t.Errorf("expected IsRefresh false, got %v", claims.IsRefresh)
 	}
+	if claims.TenantID != user.TenantID {
+		t.Errorf("expected tenant %v, got %v", user.TenantID, claims.TenantID)
+	}
+}
+
+func TestCSRFBoundToSession(t *testing.T) {
+	user := models.User{ID: 1, TenantID: "default", Email: "test@example.com"}
+	tokenService := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), mock.NewMockUserRepo(), mock.NewMockTenantRepo(), time.Minute*5, time.Minute*5, slog.Default())
+
+	first, err := tokenService.GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	second, err := tokenService.GenerateTokens(context.Background(), user)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, token := range []string{first.AccessToken, first.RefreshToken} {
+		claims, err := tokenService.Claims(token)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := tokenService.CheckCSRF(first.CSRFToken, claims); err != nil {
+			t.Errorf("expected CSRF token of the session to pass, got %v", err)
+		}
+		// Токен другой сессии того же пользователя не подходит
+		if err := tokenService.CheckCSRF(second.CSRFToken, claims); !errors.Is(err, models.ErrInvalidToken) {
+			t.Errorf("expected error = %v for CSRF token of another session, got %v", models.ErrInvalidToken, err)
+		}
+	}
+}
+
+func TestGenerateTokens_TenantTTL(t *testing.T) {
+	tokenService := service.NewTokenService(
+		"supersecretkey",
+		mock.NewMockKeyRepo(),
+		nil,
+		mock.NewMockTenantRepo(),
+		time.Hour*24,
+		time.Minute*15,
+		slog.Default(),
+	)
+
+	// Тенант "strict" переопределяет TTL: access 1m, refresh 1h
+	tokens, err := tokenService.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "strict", Name: "Test User", Email: "test@example.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if ttl := time.Until(tokens.AccessExpiresAt); ttl > time.Minute {
+		t.Errorf("expected access TTL <= 1m, got %v", ttl)
+	}
+	if ttl := time.Until(tokens.RefreshExpiresAt); ttl > time.Hour {
+		t.Errorf("expected refresh TTL <= 1h, got %v", ttl)
+	}
+
+	if _, err := tokenService.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "unknown"}); err == nil {
+		t.Error("expected error for not existing tenant, got nil")
+	}
 }
 
 func TestValidate_InvalidToken(t *testing.T) {
 	tokenService := service.NewTokenService(
 		"supersecretkey",
+		mock.NewMockKeyRepo(),
 		nil,
+		mock.NewMockTenantRepo(),
 		time.Minute,
 		time.Minute,
 		slog.Default(),
 	)
 
-	_, err := tokenService.Validate("invalid.super.token")
+	_, err := tokenService.Validate(context.Background(), "invalid.super.token")
 	if err == nil || !strings.Contains(err.Error(), "token is invalid") {
 		t.Errorf("expected invalid token error, got %v", err)
 	}
 }
 
+func TestValidate_InactiveUser(t *testing.T) {
+	tokenService := service.NewTokenService(
+		"supersecretkey",
+		mock.NewMockKeyRepo(),
+		mock.NewMockUserRepo(),
+		mock.NewMockTenantRepo(),
+		time.Minute*5,
+		time.Minute*5,
+		slog.Default(),
+	)
+
+	// Выданные ранее токены отключенного пользователя больше не действуют
+	tokens, err := tokenService.GenerateTokens(context.Background(), models.User{ID: mock.DisabledUserID, TenantID: "default", Name: "Test User", Email: "disabledEmail@gmail.com"})
+	if err != nil {
+		t.Fatalf("GenerateTokens error: %v", err)
+	}
+
+	if _, err := tokenService.Validate(context.Background(), tokens.AccessToken); !errors.Is(err, models.ErrUserInactive) {
+		t.Errorf("expected error %v, got %v", models.ErrUserInactive, err)
+	}
+	if _, err := tokenService.Refresh(context.Background(), tokens.RefreshToken); !errors.Is(err, models.ErrUserInactive) {
+		t.Errorf("expected error %v, got %v", models.ErrUserInactive, err)
+	}
+}
+
+func TestValidate_DemotedAdmin(t *testing.T) {
+	tokenService := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), mock.NewMockUserRepo(), mock.NewMockTenantRepo(), time.Minute*5, time.Minute*5, slog.Default())
+
+	// Токен выдан админу, которого затем понизили: пользователь 1 в хранилище не админ
+	tokens, err := tokenService.GenerateTokens(context.Background(), models.User{ID: 1, TenantID: "default", Email: "defaultEmail@gmail.com", IsAdmin: true, Role: models.AdminRole})
+	if err != nil {
+		t.Fatalf("GenerateTokens error: %v", err)
+	}
+
+	claims, err := tokenService.Validate(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.IsAdmin || claims.Role == models.AdminRole {
+		t.Errorf("expected rights of the stored user, got IsAdmin = %v, role = %q", claims.IsAdmin, claims.Role)
+	}
+}
+
 func TestRefresh_Success(t *testing.T) {
 	mockDal := mock.NewMockUserRepo()
 	tokenService := service.NewTokenService(
 		"supersecretkey",
+		mock.NewMockKeyRepo(),
 		mockDal,
+		mock.NewMockTenantRepo(),
 		time.Minute*5,
 		time.Minute*5,
 		slog.Default(),
 	)
 
 	user := models.User{
-		ID:      1,
-		Name:    "Test User",
-		Email:   "test@example.com",
-		IsAdmin: false,
+		ID:       1,
+		TenantID: "default",
+		Name:     "Test User",
+		Email:    "test@example.com",
+		IsAdmin:  false,
 	}
 
-	tokens, err := tokenService.GenerateTokens(user)
+	tokens, err := tokenService.GenerateTokens(context.Background(), user)
 	if err != nil {
 		t.Fatalf("GenerateTokens error: %v", err)
 	}
 
-	refreshed, err := tokenService.Refresh(tokens.RefreshToken)
+	refreshed, err := tokenService.Refresh(context.Background(), tokens.RefreshToken)
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
 	if refreshed.AccessToken == "" || refreshed.RefreshToken == "" {
 		t.Errorf("expected non-empty tokens, got %+v", refreshed)
 	}
+
+	// Access токен не обменивается на новую пару
+	if _, err := tokenService.Refresh(context.Background(), tokens.AccessToken); !errors.Is(err, models.ErrInvalidToken) {
+		t.Errorf("expected error %v for access token, got %v", models.ErrInvalidToken, err)
+	}
+}
+
+func TestRefreshAfterEmailReuse(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userDal := repo.NewUserDal(db, repo.Timeouts{Query: 5 * time.Second})
+	tokenService := service.NewTokenService("supersecretkey", mock.NewMockKeyRepo(), userDal, mock.NewMockTenantRepo(), time.Minute*5, time.Minute*5, slog.Default())
+
+	suffix := time.Now().UnixNano()
+	oldEmail, newEmail := fmt.Sprintf("reused-%d@example.com", suffix), fmt.Sprintf("moved-%d@example.com", suffix)
+	owner := models.User{TenantID: "default", Name: "Owner", Email: oldEmail, Role: models.UserRole}
+	owner.SetPassword("hash")
+	if err := userDal.SaveUser(ctx, &owner); err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM Users WHERE ID = $1`, owner.ID) })
+
+	tokens, err := tokenService.GenerateTokens(ctx, owner)
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	// Освободившийся email регистрирует другой пользователь
+	if err := userDal.UpdateEmail(ctx, owner.TenantID, owner.ID, oldEmail, newEmail); err != nil {
+		t.Fatalf("UpdateEmail() error = %v", err)
+	}
+	other := models.User{TenantID: "default", Name: "Other", Email: oldEmail, Role: models.UserRole}
+	other.SetPassword("hash")
+	if err := userDal.SaveUser(ctx, &other); err != nil {
+		t.Fatalf("SaveUser() error = %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM Users WHERE ID = $1`, other.ID) })
+
+	refreshed, err := tokenService.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	claims, err := tokenService.Validate(ctx, refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if claims.ID != owner.ID || claims.Email != newEmail {
+		t.Errorf("expected tokens of user %d with email %s, got user %d with email %s", owner.ID, newEmail, claims.ID, claims.Email)
+	}
 }
 
 func TestRefresh_UserNotExist(t *testing.T) {
 	mockDal := mock.NewMockUserRepo()
 	tokenService := service.NewTokenService(
 		"supersecretkey",
+		mock.NewMockKeyRepo(),
 		mockDal,
+		mock.NewMockTenantRepo(),
 		time.Minute*5,
 		time.Minute*5,
 		slog.Default(),
 	)
 
 	user := models.User{
-		ID:      1,
-		Name:    "Test User",
-		Email:   "uniqueMail@gmail.com",
-		IsAdmin: false,
+		ID:       mock.MissingUserID,
+		TenantID: "default",
+		Name:     "Test User",
+		Email:    "uniqueMail@gmail.com",
+		IsAdmin:  false,
 	}
 
-	tokens, err := tokenService.GenerateTokens(user)
+	tokens, err := tokenService.GenerateTokens(context.Background(), user)
 	if err != nil {
 		t.Fatalf("GenerateTokens error: %v", err)
 	}
 
-	_, err = tokenService.Refresh(tokens.RefreshToken)
+	_, err = tokenService.Refresh(context.Background(), tokens.RefreshToken)
 	if err == nil {
 		t.Fatal("expected error for non-existing user, got nil")
 	}
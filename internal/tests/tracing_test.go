@@ -0,0 +1,49 @@
+package service
+
+import (
+	"auth/internal/domain/models"
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestLoginSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	authServ := newAuthService()
+	if _, err := authServ.Login(ctx, "default", "defaultEmail@gmail.com", "notvalidPassword", models.RequestMeta{}); err == nil {
+		t.Fatal("expected login error")
+	}
+	parent.End()
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	// Спан операции продолжает трассу запроса, а bcrypt виден отдельным дочерним спаном
+	login, ok := spans["AuthService.Login"]
+	if !ok {
+		t.Fatalf("login span is not recorded, got %v", spans)
+	}
+	if login.Parent().SpanID() != parent.SpanContext().SpanID() || login.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Errorf("login span is not a child of the request span")
+	}
+	if login.Status().Code != codes.Error {
+		t.Errorf("expected failed login span, got status %v", login.Status())
+	}
+
+	compare, ok := spans["bcrypt.Compare"]
+	if !ok {
+		t.Fatal("bcrypt span is not recorded")
+	}
+	if compare.Parent().SpanID() != login.SpanContext().SpanID() {
+		t.Errorf("bcrypt span is not a child of the login span")
+	}
+}
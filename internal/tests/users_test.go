@@ -0,0 +1,97 @@
+package service
+
+import (
+	"auth/internal/adapters/repo"
+	"auth/internal/domain/models"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestListUsersCursorOrder(t *testing.T) {
+	userDal := repo.NewUserDal(testDB(t), repo.Timeouts{Query: 5 * time.Second})
+	cursor := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	testCases := []struct {
+		name   string
+		filter models.UserFilter
+	}{
+		{name: "other sort", filter: models.UserFilter{SortBy: models.SortByCreatedAt, Cursor: cursor(`{"v":"Ann","id":1,"s":"name"}`)}},
+		{name: "other direction", filter: models.UserFilter{SortBy: models.SortByName, Desc: true, Cursor: cursor(`{"v":"Ann","id":1,"s":"name"}`)}},
+		{name: "without sort", filter: models.UserFilter{SortBy: models.SortByName, Cursor: cursor(`{"v":"Ann","id":1}`)}},
+		{name: "invalid time", filter: models.UserFilter{SortBy: models.SortByCreatedAt, Cursor: cursor(`{"v":"Ann","id":1,"s":"created_at"}`)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.filter.TenantID, tc.filter.Limit = "default", 10
+			if _, err := userDal.ListUsers(context.Background(), tc.filter); !errors.Is(err, models.ErrInvalidCursor) {
+				t.Errorf("expected error %v, got %v", models.ErrInvalidCursor, err)
+			}
+		})
+	}
+
+	// Курсор страницы продолжает листинг с той же сортировкой
+	page, err := userDal.ListUsers(context.Background(), models.UserFilter{TenantID: "default", SortBy: models.SortByName, Limit: 1})
+	if err != nil {
+		t.Fatalf("ListUsers() error = %v", err)
+	}
+	if page.NextCursor != "" {
+		if _, err := userDal.ListUsers(context.Background(), models.UserFilter{TenantID: "default", SortBy: models.SortByName, Limit: 1, Cursor: page.NextCursor}); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	}
+}
+
+func TestPurgeUsers(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	userDal := repo.NewUserDal(db, repo.Timeouts{Query: 5 * time.Second})
+
+	// Один пользователь владеет организацией, другой только состоит в ней
+	var ownerID, memberID, orgID int
+	suffix := time.Now().UnixNano()
+	for i, id := range []*int{&ownerID, &memberID} {
+		if err := db.QueryRowContext(ctx, `
+		INSERT INTO Users (TenantID, Name, Email, PassHash, Role, Status, Deleted_At)
+		VALUES ('default', 'Purged', $1, 'hash', 'user', 'pending_deletion', Now() - interval '1 hour')
+		RETURNING ID`, fmt.Sprintf("purged-%d-%d@example.com", suffix, i)).Scan(id); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	if err := db.QueryRowContext(ctx, `INSERT INTO Organizations (TenantID, Name, OwnerID) VALUES ('default', 'Purged org', $1) RETURNING ID`, ownerID).Scan(&orgID); err != nil {
+		t.Fatalf("insert organization: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM Organizations WHERE ID = $1`, orgID)
+		db.Exec(`DELETE FROM Users WHERE ID = ANY($1)`, fmt.Sprintf("{%d,%d}", ownerID, memberID))
+	})
+	if _, err := db.ExecContext(ctx, `INSERT INTO Memberships (OrgID, UserID, Role) VALUES ($1, $2, 'owner'), ($1, $3, 'member')`, orgID, ownerID, memberID); err != nil {
+		t.Fatalf("insert memberships: %v", err)
+	}
+
+	if _, err := userDal.PurgeUsers(ctx, time.Now(), 100); err != nil {
+		t.Fatalf("PurgeUsers() error = %v", err)
+	}
+
+	var status, email string
+	if err := db.QueryRowContext(ctx, `SELECT Status, Email FROM Users WHERE ID = $1`, ownerID).Scan(&status, &email); err != nil {
+		t.Fatalf("expected owner to be kept, got %v", err)
+	}
+	if status != models.StatusDeleted || email != fmt.Sprintf("deleted-%d@deleted.invalid", ownerID) {
+		t.Errorf("expected anonymized owner, got status %q email %q", status, email)
+	}
+	if err := db.QueryRowContext(ctx, `SELECT Status FROM Users WHERE ID = $1`, memberID).Scan(&status); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected member to be deleted, got status %q, err = %v", status, err)
+	}
+
+	var events int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM Outbox WHERE Type = $1 AND (Payload->'user'->>'id')::int IN ($2, $3)`,
+		models.EventUserPurged, ownerID, memberID).Scan(&events); err != nil || events != 2 {
+		t.Errorf("expected 2 %s events, got %d, err = %v", models.EventUserPurged, events, err)
+	}
+}
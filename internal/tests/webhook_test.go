@@ -0,0 +1,200 @@
+package service
+
+import (
+	validate "auth/internal/adapters/transport"
+	"auth/internal/domain/models"
+	"auth/internal/service"
+	"auth/internal/tests/mock"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatcherDelivery(t *testing.T) {
+	const secret = "whsec_test"
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(service.WebhookTimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("expected timestamp header, got %q", r.Header.Get(service.WebhookTimestampHeader))
+		}
+
+		// Получатель проверяет подпись тем же секретом
+		if got := r.Header.Get(service.WebhookSignatureHeader); got != "sha256="+service.SignWebhook(secret, timestamp, body) {
+			t.Errorf("signature mismatch: %s", got)
+		}
+		if r.Header.Get(service.WebhookEventHeader) != models.EventUserRegistered || r.Header.Get(service.WebhookIDHeader) != "7" {
+			t.Errorf("unexpected event headers: %v", r.Header)
+		}
+
+		var event models.Event
+		if err := json.Unmarshal(body, &event); err != nil || event.ID != 7 || event.Type != models.EventUserRegistered {
+			t.Errorf("unexpected event body %s, err = %v", body, err)
+		}
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	webhookRepo := mock.NewMockWebhookRepo()
+	webhookRepo.Due = []models.WebhookDelivery{{
+		ID:        1,
+		EventID:   7,
+		EventType: models.EventUserRegistered,
+		URL:       server.URL,
+		Secret:    secret,
+		Event: models.Event{
+			ID:       7,
+			TenantID: "default",
+			Type:     models.EventUserRegistered,
+			Data:     json.RawMessage(`{"user":{"id":1}}`),
+		},
+	}}
+
+	dispatcher := service.NewDispatcher(webhookRepo, time.Second, time.Second, 3, time.Second, time.Hour, true, slog.Default())
+	if sent := dispatcher.Dispatch(context.Background()); sent != 1 {
+		t.Fatalf("expected 1 sent delivery, got %d", sent)
+	}
+	if received.Load() != 1 || len(webhookRepo.Delivered) != 1 || len(webhookRepo.Failed) != 0 {
+		t.Errorf("expected delivered once, got received = %d, delivered = %v, failed = %v", received.Load(), webhookRepo.Delivered, webhookRepo.Failed)
+	}
+	if webhookRepo.FannedOut == 0 || !webhookRepo.Purged {
+		t.Errorf("expected events fan out and outbox purge")
+	}
+}
+
+func TestDispatcherRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhookRepo := mock.NewMockWebhookRepo()
+	webhookRepo.Due = []models.WebhookDelivery{
+		{ID: 1, Attempts: 0, URL: server.URL},
+		{ID: 2, Attempts: 2, URL: server.URL},
+	}
+
+	dispatcher := service.NewDispatcher(webhookRepo, time.Second, time.Second, 3, time.Minute, time.Hour, true, slog.Default())
+	started := time.Now()
+	dispatcher.Dispatch(context.Background())
+
+	if len(webhookRepo.Failed) != 2 || len(webhookRepo.Delivered) != 0 {
+		t.Fatalf("expected 2 failed deliveries, got %+v", webhookRepo.Failed)
+	}
+	for _, failed := range webhookRepo.Failed {
+		// Третья попытка последняя, доставка уходит в dead letters
+		if failed.Dead != (failed.ID == 2) {
+			t.Errorf("delivery %d: unexpected dead = %t", failed.ID, failed.Dead)
+		}
+		if failed.ID == 1 && failed.NextAttemptAt.Before(started.Add(time.Minute)) {
+			t.Errorf("expected next attempt after backoff, got %v", failed.NextAttemptAt)
+		}
+		if failed.LastErr == "" {
+			t.Errorf("delivery %d: expected last error", failed.ID)
+		}
+	}
+}
+
+func TestDispatcherInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Остановка сервиса прерывает отправку, пока получатель отвечает
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	webhookRepo := mock.NewMockWebhookRepo()
+	webhookRepo.Due = []models.WebhookDelivery{{ID: 1, URL: server.URL}}
+
+	dispatcher := service.NewDispatcher(webhookRepo, time.Second, 5*time.Second, 3, time.Minute, time.Hour, true, slog.Default())
+	dispatcher.Dispatch(ctx)
+
+	// Попытка не засчитывается, доставка повторится после истечения аренды
+	if len(webhookRepo.Failed) != 0 || len(webhookRepo.Delivered) != 0 {
+		t.Errorf("expected unmarked delivery, got delivered = %v, failed = %+v", webhookRepo.Delivered, webhookRepo.Failed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{30, 6 * time.Hour},
+	}
+	for _, tc := range testCases {
+		if got := service.RetryDelay(30*time.Second, tc.attempts); got != tc.expected {
+			t.Errorf("attempts %d: expected delay %v, got %v", tc.attempts, tc.expected, got)
+		}
+	}
+}
+
+func TestRegisterEvent(t *testing.T) {
+	userRepo := mock.NewMockUserRepo()
+	tokenServ := mock.NewMockTokenService()
+	auditServ := service.NewAuditService(mock.NewMockAuditRepo(), tokenServ, slog.Default())
+	loginServ := service.NewLoginService(mock.NewMockLoginRepo(), tokenServ, mock.NewMockNotifier(), slog.Default())
+	authServ := service.NewAuthService(userRepo, mock.NewMockTenantRepo(), tokenServ, auditServ, loginServ, models.PasswordPolicy{MinLength: 8}, slog.Default())
+
+	if _, err := authServ.Register(context.Background(), "default", "New User", "uniqueMail@gmail.com", "validPassword", models.UserRole, models.RequestMeta{}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	// Неудачная регистрация не публикует событие
+	if _, err := authServ.Register(context.Background(), "default", "New User", "defaultEmail@gmail.com", "validPassword", models.UserRole, models.RequestMeta{}); err == nil {
+		t.Fatal("expected error for not unique email")
+	}
+
+	if len(userRepo.Events) != 1 || userRepo.Events[0].Type != models.EventUserRegistered {
+		t.Errorf("expected one %s event, got %+v", models.EventUserRegistered, userRepo.Events)
+	}
+}
+
+func TestDispatcherInternalNetwork(t *testing.T) {
+	var received atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	// Получатель во внутренней сети не вызывается без WEBHOOK_ALLOW_PRIVATE
+	webhookRepo := mock.NewMockWebhookRepo()
+	webhookRepo.Due = []models.WebhookDelivery{{ID: 1, URL: server.URL}}
+	service.NewDispatcher(webhookRepo, time.Second, time.Second, 3, time.Minute, time.Hour, false, slog.Default()).Dispatch(context.Background())
+	if received.Load() != 0 || len(webhookRepo.Failed) != 1 {
+		t.Fatalf("expected rejected delivery, got received = %d, failed = %+v", received.Load(), webhookRepo.Failed)
+	}
+
+	// Редирект не выполняется, ответ 3xx считается неудачей
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+	defer redirect.Close()
+	webhookRepo = mock.NewMockWebhookRepo()
+	webhookRepo.Due = []models.WebhookDelivery{{ID: 1, URL: redirect.URL}}
+	service.NewDispatcher(webhookRepo, time.Second, time.Second, 3, time.Minute, time.Hour, true, slog.Default()).Dispatch(context.Background())
+	if received.Load() != 0 || len(webhookRepo.Failed) != 1 {
+		t.Fatalf("expected not followed redirect, got received = %d, failed = %+v", received.Load(), webhookRepo.Failed)
+	}
+
+	for _, endpoint := range []string{"http://127.0.0.1/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]:8080/", "http://10.0.0.5/", "http://localhost/", "http://[::ffff:192.168.0.1]/"} {
+		if err := validate.Webhook(endpoint, nil); err == nil {
+			t.Errorf("%s: expected error", endpoint)
+		}
+	}
+	if err := validate.Webhook("https://hooks.example.com/auth", nil); err != nil {
+		t.Errorf("expected public endpoint to be valid, got %v", err)
+	}
+}
@@ -1,6 +1,11 @@
 SET TIMEZONE = 'Asia/Almaty';
 
-CREATE TYPE role as enum ('admin', 'user');
+-- Type has no IF NOT EXISTS, schemas created before the migration runner already have it
+DO $$ BEGIN
+    CREATE TYPE role as enum ('admin', 'user');
+EXCEPTION
+    WHEN duplicate_object THEN NULL;
+END $$;
 
 CREATE TABLE IF NOT EXISTS Users (
     ID SERIAL PRIMARY KEY,
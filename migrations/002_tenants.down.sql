@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_tenant_email;
+ALTER TABLE Users DROP COLUMN IF EXISTS TenantID;
+
+-- Fails if the same email is used in several tenants
+ALTER TABLE Users ADD CONSTRAINT users_email_key UNIQUE (Email);
+CREATE INDEX IF NOT EXISTS idx_email ON Users (Email);
+
+DROP TABLE IF EXISTS Tenants;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS Tenants (
+    ID VARCHAR(64) PRIMARY KEY,
+    Name VARCHAR(100) NOT NULL,
+    AccessTTL BIGINT,        -- seconds, NULL means global ACCESSTTL
+    RefreshTTL BIGINT,       -- seconds, NULL means global REFRESHTTL
+    PasswordPolicy JSONB,    -- NULL means global password policy
+    Created_At TIMESTAMPTZ DEFAULT NOW(),
+    Updated_At TIMESTAMPTZ
+);
+
+INSERT INTO Tenants (ID, Name) VALUES ('default', 'Default') ON CONFLICT (ID) DO NOTHING;
+
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS TenantID VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES Tenants (ID);
+
+-- Email is unique only inside a tenant
+ALTER TABLE Users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email ON Users (TenantID, Email);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS Invitations;
+DROP TABLE IF EXISTS Memberships;
+DROP TABLE IF EXISTS Organizations;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS Organizations (
+    ID SERIAL PRIMARY KEY,
+    TenantID VARCHAR(64) NOT NULL REFERENCES Tenants (ID),
+    Name VARCHAR(128) NOT NULL,
+    OwnerID INT NOT NULL REFERENCES Users (ID) ON DELETE CASCADE,
+    Created_At TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_organizations_tenant ON Organizations (TenantID);
+
+-- Role inside organization: owner | admin | member
+CREATE TABLE IF NOT EXISTS Memberships (
+    OrgID INT NOT NULL REFERENCES Organizations (ID) ON DELETE CASCADE,
+    UserID INT NOT NULL REFERENCES Users (ID) ON DELETE CASCADE,
+    Role VARCHAR(20) NOT NULL,
+    Joined_At TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (OrgID, UserID)
+);
+
+CREATE INDEX IF NOT EXISTS idx_memberships_user ON Memberships (UserID);
+
+CREATE TABLE IF NOT EXISTS Invitations (
+    ID SERIAL PRIMARY KEY,
+    OrgID INT NOT NULL REFERENCES Organizations (ID) ON DELETE CASCADE,
+    Email VARCHAR(255) NOT NULL,
+    Role VARCHAR(20) NOT NULL,
+    InvitedBy INT REFERENCES Users (ID) ON DELETE SET NULL,
+    Created_At TIMESTAMPTZ DEFAULT NOW(),
+    Expires_At TIMESTAMPTZ NOT NULL,
+    Accepted_At TIMESTAMPTZ,
+    Revoked_At TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_invitations_org ON Invitations (OrgID);
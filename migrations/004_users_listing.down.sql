@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_email_trgm;
+DROP INDEX IF EXISTS idx_users_tenant_role;
+DROP INDEX IF EXISTS idx_users_tenant_status;
+DROP INDEX IF EXISTS idx_users_tenant_email_id;
+DROP INDEX IF EXISTS idx_users_tenant_name;
+DROP INDEX IF EXISTS idx_users_tenant_created;
+
+ALTER TABLE Users DROP COLUMN IF EXISTS Status;
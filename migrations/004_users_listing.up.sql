@@ -0,0 +1,13 @@
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- Keyset pagination of users listing: (sort column, ID) inside a tenant
+CREATE INDEX IF NOT EXISTS idx_users_tenant_created ON Users (TenantID, Created_At, ID);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_name ON Users (TenantID, Name, ID);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_email_id ON Users (TenantID, Email, ID);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_status ON Users (TenantID, Status);
+CREATE INDEX IF NOT EXISTS idx_users_tenant_role ON Users (TenantID, Role);
+
+-- Substring search by email and name
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON Users USING GIN (lower(Email) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON Users USING GIN (lower(Name) gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- Temporary password issued by admin must be changed at first login
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS MustChangePassword BOOLEAN NOT NULL DEFAULT false;
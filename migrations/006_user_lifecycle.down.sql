@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_pending_deletion;
+ALTER TABLE Users DROP COLUMN IF EXISTS Deleted_At;
@@ -0,0 +1,5 @@
+-- Status: active | disabled | pending_deletion | deleted
+-- Deleted_At is set while user is pending deletion and drives the retention purge
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Deleted_At TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_pending_deletion ON Users (Deleted_At) WHERE Status = 'pending_deletion';
@@ -0,0 +1,4 @@
+ALTER TABLE Users DROP COLUMN IF EXISTS Metadata;
+ALTER TABLE Users DROP COLUMN IF EXISTS Timezone;
+ALTER TABLE Users DROP COLUMN IF EXISTS Locale;
+ALTER TABLE Users DROP COLUMN IF EXISTS AvatarURL;
@@ -0,0 +1,5 @@
+-- Profile fields editable by the user
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS AvatarURL VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Locale VARCHAR(35) NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Timezone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS Metadata JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,32 @@
+-- Append-only audit log. Entries of a tenant form a hash chain:
+-- Hash = sha256(PrevHash and entry fields), PrevHash of the first entry is empty
+CREATE TABLE IF NOT EXISTS AuditLog (
+    ID BIGSERIAL PRIMARY KEY,
+    TenantID VARCHAR(64) NOT NULL,
+    ActorID INT NOT NULL DEFAULT 0,
+    TargetID INT NOT NULL DEFAULT 0,
+    Action VARCHAR(32) NOT NULL,
+    Outcome VARCHAR(16) NOT NULL,
+    Details TEXT NOT NULL DEFAULT '',
+    IP VARCHAR(64) NOT NULL DEFAULT '',
+    UserAgent VARCHAR(512) NOT NULL DEFAULT '',
+    RequestID VARCHAR(128) NOT NULL DEFAULT '',
+    Created_At TIMESTAMPTZ NOT NULL,
+    PrevHash VARCHAR(64) NOT NULL,
+    Hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_tenant ON AuditLog (TenantID, ID);
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_actor ON AuditLog (TenantID, ActorID, ID);
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_target ON AuditLog (TenantID, TargetID, ID);
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_action ON AuditLog (TenantID, Action, ID);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON AuditLog;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON AuditLog
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,15 @@
+-- Login attempts of existing users. Fingerprint identifies client device, see RequestMeta.Fingerprint
+CREATE TABLE IF NOT EXISTS LoginHistory (
+    ID BIGSERIAL PRIMARY KEY,
+    TenantID VARCHAR(64) NOT NULL,
+    UserID INT NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+    IP VARCHAR(64) NOT NULL DEFAULT '',
+    UserAgent VARCHAR(512) NOT NULL DEFAULT '',
+    Fingerprint VARCHAR(32) NOT NULL,
+    Success BOOLEAN NOT NULL,
+    Reason TEXT NOT NULL DEFAULT '',
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT Now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_history_user ON LoginHistory (UserID, ID);
+CREATE INDEX IF NOT EXISTS idx_login_history_device ON LoginHistory (UserID, Fingerprint) WHERE Success;
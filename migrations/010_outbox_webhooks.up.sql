@@ -0,0 +1,41 @@
+-- Transactional outbox: events are inserted in the same transaction as the user write
+CREATE TABLE IF NOT EXISTS Outbox (
+    ID BIGSERIAL PRIMARY KEY,
+    TenantID VARCHAR(64) NOT NULL,
+    Type VARCHAR(64) NOT NULL,
+    Payload JSONB NOT NULL,
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT Now(),
+    Dispatched_At TIMESTAMPTZ -- Set when deliveries to webhooks are created
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON Outbox (ID) WHERE Dispatched_At IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_dispatched ON Outbox (Dispatched_At) WHERE Dispatched_At IS NOT NULL;
+
+-- Events empty array means all event types
+CREATE TABLE IF NOT EXISTS Webhooks (
+    ID SERIAL PRIMARY KEY,
+    TenantID VARCHAR(64) NOT NULL REFERENCES Tenants (ID),
+    URL TEXT NOT NULL,
+    Secret VARCHAR(128) NOT NULL,
+    Events TEXT[] NOT NULL DEFAULT '{}',
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT Now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant ON Webhooks (TenantID);
+
+-- Status: pending | delivered | dead
+CREATE TABLE IF NOT EXISTS WebhookDeliveries (
+    ID BIGSERIAL PRIMARY KEY,
+    WebhookID INT NOT NULL REFERENCES Webhooks (ID) ON DELETE CASCADE,
+    EventID BIGINT NOT NULL REFERENCES Outbox (ID) ON DELETE CASCADE,
+    Status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    Attempts INT NOT NULL DEFAULT 0,
+    LastError TEXT NOT NULL DEFAULT '',
+    NextAttemptAt TIMESTAMPTZ NOT NULL DEFAULT Now(),
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT Now(),
+    Updated_At TIMESTAMPTZ NOT NULL DEFAULT Now(),
+    UNIQUE (WebhookID, EventID)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_due ON WebhookDeliveries (NextAttemptAt) WHERE Status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_deliveries_webhook_status ON WebhookDeliveries (WebhookID, Status, ID);
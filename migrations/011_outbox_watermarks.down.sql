@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_tenant;
+DROP TABLE IF EXISTS OutboxWatermarks;
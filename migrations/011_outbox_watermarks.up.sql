@@ -0,0 +1,7 @@
+-- Highest outbox event ID purged per tenant. Watch cursors below it can't be resumed
+CREATE TABLE IF NOT EXISTS OutboxWatermarks (
+    TenantID VARCHAR(64) PRIMARY KEY,
+    PurgedThrough BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_tenant ON Outbox (TenantID, ID);
@@ -0,0 +1,2 @@
+-- Enum values can't be removed, service accounts are demoted to users instead
+UPDATE Users SET Role = 'user' WHERE Role = 'service';
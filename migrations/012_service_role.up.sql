@@ -0,0 +1,2 @@
+-- Service accounts of internal consumers, e.g. user event subscribers
+ALTER TYPE role ADD VALUE IF NOT EXISTS 'service';
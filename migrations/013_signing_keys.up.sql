@@ -0,0 +1,8 @@
+-- Token signing keys. The newest one signs, retired ones verify tokens until Retires_At.
+-- Tokens without key id are signed with the SECRET setting until the first rotation
+CREATE TABLE IF NOT EXISTS SigningKeys (
+    ID VARCHAR(32) PRIMARY KEY,
+    Secret BYTEA NOT NULL,
+    Created_At TIMESTAMPTZ NOT NULL DEFAULT Now(),
+    Retires_At TIMESTAMPTZ
+);
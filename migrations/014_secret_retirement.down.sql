@@ -0,0 +1 @@
+DELETE FROM SigningKeys WHERE ID = '';
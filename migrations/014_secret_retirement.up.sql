@@ -0,0 +1,7 @@
+-- SECRET verifies tokens without kid until the row with empty ID retires. Installations rotated before
+-- it was written retire SECRET with the previous keys, or a week after the first rotation
+INSERT INTO SigningKeys (ID, Secret, Created_At, Retires_At)
+SELECT '', ''::bytea, MIN(Created_At), COALESCE(MAX(Retires_At), MIN(Created_At) + interval '168 hours')
+FROM SigningKeys
+HAVING COUNT(*) > 0
+ON CONFLICT (ID) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_outbox_tenant_xid;
+ALTER TABLE Outbox DROP COLUMN IF EXISTS Xid;
@@ -0,0 +1,5 @@
+-- Transaction that wrote the event. Events are streamed in order of commit safe (Xid, ID), since IDs are taken
+-- before commit and a running transaction may still commit an event below the streamed ones
+ALTER TABLE Outbox ADD COLUMN IF NOT EXISTS Xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_outbox_tenant_xid ON Outbox (TenantID, Xid, ID);
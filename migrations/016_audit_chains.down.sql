@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_tenant_chain;
+ALTER TABLE AuditLog DROP COLUMN IF EXISTS Chain;
@@ -0,0 +1,5 @@
+-- Entries of a tenant are spread over several independent hash chains, so concurrent writers
+-- don't wait for a single tenant lock. Existing entries stay in chain 0
+ALTER TABLE AuditLog ADD COLUMN IF NOT EXISTS Chain SMALLINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_chain ON AuditLog (TenantID, Chain, ID);
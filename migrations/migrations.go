@@ -0,0 +1,8 @@
+// Package migrations embeds numbered SQL migrations of the database schema.
+// Version NNN is applied by NNN_name.up.sql and reverted by NNN_name.down.sql
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
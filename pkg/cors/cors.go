@@ -0,0 +1,139 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Options struct {
+	AllowedOrigins   []string      // Exact origins like https://app.example.com, "*" - any origin
+	AllowedMethods   []string      // Methods allowed in preflight
+	AllowedHeaders   []string      // Request headers allowed in preflight
+	ExposedHeaders   []string      // Response headers readable by scripts
+	AllowCredentials bool          // Send cookies with cross-origin requests, not allowed with "*"
+	MaxAge           time.Duration // How long browsers cache preflight results
+}
+
+type cors struct {
+	opts    Options
+	any     bool
+	origins map[string]bool
+	headers map[string]bool
+
+	// Значения заголовков ответа, собранные один раз
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// Returns middleware answering preflight requests and adding CORS headers to responses for allowed origins.
+// Requests of other origins are passed without CORS headers, so browsers don't give their responses to scripts
+func New(opts Options) (func(http.Handler) http.Handler, error) {
+	c := &cors{
+		opts:          opts,
+		origins:       make(map[string]bool),
+		headers:       make(map[string]bool),
+		allowMethods:  strings.Join(opts.AllowedMethods, ", "),
+		allowHeaders:  strings.Join(opts.AllowedHeaders, ", "),
+		exposeHeaders: strings.Join(opts.ExposedHeaders, ", "),
+		maxAge:        strconv.Itoa(int(opts.MaxAge / time.Second)),
+	}
+	for _, origin := range opts.AllowedOrigins {
+		if origin == "*" {
+			c.any = true
+			continue
+		}
+		c.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+	if c.any && opts.AllowCredentials {
+		return nil, errors.New("credentials can't be allowed for any origin, list the origins")
+	}
+	for _, header := range opts.AllowedHeaders {
+		c.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	return c.handler, nil
+}
+
+func (c *cors) handler(next http.Handler) http.Handler {
+	if !c.any && len(c.origins) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		// Ответ зависит от Origin, кэши не должны отдавать его другим источникам
+		w.Header().Add("Vary", "Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed := c.any || c.origins[origin]
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !allowed || !c.preflightAllowed(r) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			c.setOrigin(w, origin)
+			w.Header().Set("Access-Control-Allow-Methods", c.allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", c.allowHeaders)
+			if c.opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", c.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			c.setOrigin(w, origin)
+			if c.exposeHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", c.exposeHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.any {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if c.opts.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// Checks requested method and headers of the preflight
+func (c *cors) preflightAllowed(r *http.Request) bool {
+	if !slices.Contains(c.opts.AllowedMethods, r.Header.Get("Access-Control-Request-Method")) {
+		return false
+	}
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !c.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// Splits comma separated list of the config, empty items are skipped
+func List(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
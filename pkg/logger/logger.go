@@ -1,8 +1,12 @@
 package logger
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"os"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -15,21 +19,52 @@ type Logger interface {
 	Info(msg string, args ...any)
 }
 
-func SetLogger(env string) *slog.Logger {
+func SetLogger(env string, cfg LogConf) *slog.Logger {
 	var log slog.Logger
 
 	switch env {
 	case envLocal:
-		log = *slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = *New(os.Stdout, slog.LevelDebug, cfg)
 	case envDev:
-		log = *slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		log = *New(os.Stdout, slog.LevelDebug, cfg)
 	case envProd:
-		log = *slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		log = *New(os.Stdout, slog.LevelInfo, cfg)
 	}
 
 	return &log
 }
 
+// Creates JSON logger with request context attrs and redaction of secrets
+func New(w io.Writer, level slog.Level, cfg LogConf) *slog.Logger {
+	return slog.New(contextHandler{redactHandler{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}), cfg: cfg}})
+}
+
+// Adds request_id, trace_id and span_id from the record context, so all logs of a request can be found together
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
 // Возвращает лог-обертку для ошибки
 func Err(err error) slog.Attr {
 	return slog.String("error", err.Error())
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Replaces values of secrets in log records
+const Redacted = "[REDACTED]"
+
+type LogConf struct {
+	RedactEmails bool `env:"LOG_REDACT_EMAILS" default:"true"` // Mask emails in logs as j***@example.com
+}
+
+// Registry of secrets. Log attr keys and proto field names are matched after lowering and removing "_" and "-",
+// so "admin_token", "adminToken" and "AdminToken" are the same key
+var (
+	secretKeys     = map[string]bool{"authorization": true, "cookie": true}
+	secretSuffixes = []string{"password", "token", "secret"}
+)
+
+// Keys of emails, masked when LOG_REDACT_EMAILS is on
+var emailKeys = map[string]bool{
+	"email":    true,
+	"newemail": true,
+	"oldemail": true,
+}
+
+var (
+	// Signed tokens of the service are JWT, their header always starts with eyJ
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+func isSecret(key string) bool {
+	if secretKeys[key] {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Masks local part of the email except its first letter
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return Redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// Removes secrets from log records: values of secret keys, JWTs in any string, secret fields of proto messages
+// and, if configured, emails
+type redactHandler struct {
+	slog.Handler
+	cfg LogConf
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return redactHandler{Handler: h.Handler.WithAttrs(redacted), cfg: h.cfg}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{Handler: h.Handler.WithGroup(name), cfg: h.cfg}
+}
+
+func (h redactHandler) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := normalizeKey(a.Key)
+
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redacted[i] = h.redactAttr(attr)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+	case isSecret(key) && (a.Value.Kind() == slog.KindString || a.Value.Kind() == slog.KindAny):
+		// Флаги вроде must_change_password секретом не являются
+		return slog.String(a.Key, Redacted)
+	case emailKeys[key] && h.cfg.RedactEmails && a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	case a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, h.redactString(a.Value.String()))
+	case a.Value.Kind() == slog.KindAny:
+		return slog.Attr{Key: a.Key, Value: h.redactAny(a.Value.Any())}
+	}
+	return a
+}
+
+func (h redactHandler) redactAny(v any) slog.Value {
+	switch v := v.(type) {
+	case proto.Message:
+		// Сообщение выводится как JSON без секретных полей
+		msg := proto.Clone(v)
+		h.redactMessage(msg.ProtoReflect())
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			return slog.StringValue(Redacted)
+		}
+		return slog.AnyValue(json.RawMessage(data))
+	case error:
+		return slog.StringValue(h.redactString(v.Error()))
+	}
+	return slog.AnyValue(v)
+}
+
+func (h redactHandler) redactMessage(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		key := normalizeKey(string(fd.Name()))
+		switch {
+		case fd.IsMap():
+		case fd.Kind() == protoreflect.StringKind && !fd.IsList():
+			switch {
+			case isSecret(key):
+				m.Set(fd, protoreflect.ValueOfString(Redacted))
+			case emailKeys[key] && h.cfg.RedactEmails:
+				m.Set(fd, protoreflect.ValueOfString(MaskEmail(v.String())))
+			default:
+				m.Set(fd, protoreflect.ValueOfString(h.redactString(v.String())))
+			}
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			list := m.Mutable(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				h.redactMessage(list.Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			h.redactMessage(m.Mutable(fd).Message())
+		}
+		return true
+	})
+}
+
+// Masks JWTs and, if configured, emails inside free text
+func (h redactHandler) redactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	if h.cfg.RedactEmails {
+		s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	}
+	return s
+}
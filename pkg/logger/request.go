@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+const maxRequestIDLength = 128
+
+type requestKey struct{}
+
+// Values of one request shared by the transport and the services it calls
+type request struct {
+	id string
+
+	mu        sync.Mutex
+	principal string
+}
+
+// Returns context of the request with the given id. Principal is empty until SetPrincipal
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{id: id})
+}
+
+// Returns id of the request, empty outside of a request
+func RequestID(ctx context.Context) string {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		return req.id
+	}
+	return ""
+}
+
+// Records who made the request once the token or credentials are checked. Ignored outside of a request
+func SetPrincipal(ctx context.Context, principal string) {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		req.mu.Lock()
+		req.principal = principal
+		req.mu.Unlock()
+	}
+}
+
+// Returns principal set by SetPrincipal, empty for anonymous requests
+func Principal(ctx context.Context) string {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		req.mu.Lock()
+		defer req.mu.Unlock()
+		return req.principal
+	}
+	return ""
+}
+
+// Generates random request id
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Returns id from the client if it is safe to log and echo, otherwise a new one
+func RequestIDOrNew(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return NewRequestID()
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return NewRequestID()
+		}
+	}
+	return id
+}
@@ -0,0 +1,59 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("address of internal network is not allowed")
+
+// Ranges not routed in the public internet, besides loopback, private and link-local ones
+var internal = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// Reports whether the address is routed in the public internet. Loopback, private, link-local
+// (including cloud metadata 169.254.169.254), multicast and reserved addresses are not public
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range internal {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dialer control rejecting connections to not public addresses. It checks the address actually dialed,
+// so hostnames resolving to internal addresses and DNS rebinding are rejected too
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !Public(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// Reports whether the host of URL is localhost or not public IP, so it is rejected without lookup
+func InternalHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !Public(addr)
+}
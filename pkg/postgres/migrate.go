@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Key of the advisory lock held while migrations run, so concurrent instances wait for each other
+const migrationLockKey int64 = 0x61757468 // "auth"
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var ErrNoDownMigration = errors.New("down migration is missing")
+
+// Schema version with its up and down SQL
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migration state in the database. Applied_At is nil for pending migrations
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied_At *time.Time
+}
+
+// Applies and reverts migrations recording versions in schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	log        *slog.Logger
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS, log *slog.Logger) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, log: log}, nil
+}
+
+// Reads NNN_name.up.sql and NNN_name.down.sql files ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	const op = "postgres.LoadMigrations"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%s: version %d has different names: %s and %s", op, version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%s: version %d has no up migration", op, migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Applies all pending migrations in order of versions. Returns count of applied migrations
+func (m *Migrator) Up() (int, error) {
+	const op = "Migrator.Up"
+
+	var count int
+	err := m.locked(func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.run(conn, migration.Up, `INSERT INTO schema_migrations (Version, Name) VALUES ($1, $2)`,
+				migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("version %d %s: %w", migration.Version, migration.Name, err)
+			}
+			m.log.Info("Migration applied", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+
+		// Версии из более новой сборки не трогаем, но предупреждаем о них
+		for version := range applied {
+			if m.find(version) == nil {
+				m.log.Warn("Applied migration is unknown to this build", "version", version)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// Reverts the given count of the latest applied migrations. Returns count of reverted migrations
+func (m *Migrator) Down(steps int) (int, error) {
+	const op = "Migrator.Down"
+
+	var count int
+	err := m.locked(func(conn *sql.Conn, applied map[int]time.Time) error {
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			migration := m.find(version)
+			if migration == nil || migration.Down == "" {
+				return fmt.Errorf("version %d: %w", version, ErrNoDownMigration)
+			}
+
+			if err := m.run(conn, migration.Down, `DELETE FROM schema_migrations WHERE Version = $1`, version); err != nil {
+				return fmt.Errorf("version %d %s: %w", version, migration.Name, err)
+			}
+			m.log.Info("Migration reverted", "version", version, "name", migration.Name)
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, fmt.Errorf("%s: %w", op, err)
+	}
+	return count, nil
+}
+
+// Returns known and applied migrations ordered by version
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	const op = "Migrator.Status"
+
+	var statuses []MigrationStatus
+	err := m.locked(func(conn *sql.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.Applied_At = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		for version, appliedAt := range applied {
+			if m.find(version) == nil {
+				statuses = append(statuses, MigrationStatus{Version: version, Name: "unknown", Applied_At: &appliedAt})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Returns count of known migrations that are not applied yet. It doesn't take the
+// migration lock, so it can be called by health checks while migrations run
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	const op = "Migrator.Pending"
+
+	rows, err := m.db.QueryContext(ctx, `SELECT Version FROM schema_migrations`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pending := 0
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Runs fn on one connection holding the migration lock with versions applied so far.
+// Session advisory lock belongs to the connection, so it can't be taken on the pool
+func (m *Migrator) locked(fn func(conn *sql.Conn, applied map[int]time.Time) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			m.log.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		Version INT PRIMARY KEY,
+		Name VARCHAR(255) NOT NULL,
+		Applied_At TIMESTAMPTZ NOT NULL DEFAULT Now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT Version, Applied_At FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return err
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	return fn(conn, applied)
+}
+
+// Executes migration SQL and the version bookkeeping statement in one transaction
+func (m *Migrator) run(conn *sql.Conn, migration, record string, args ...any) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
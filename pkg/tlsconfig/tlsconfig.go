@@ -0,0 +1,178 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default min interval between checks of certificate files, rotated files are picked up by the next handshake after it
+const reloadCheck = 10 * time.Second
+
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+type Options struct {
+	CertFile     string             // PEM certificate chain
+	KeyFile      string             // PEM private key
+	MinVersion   string             // 1.2 | 1.3
+	CipherSuites string             // Comma separated IANA names of TLS 1.2 suites, empty - Go defaults
+	ClientCAFile string             // PEM CAs of client certificates, empty - client certificates are not requested
+	ClientAuth   tls.ClientAuthType // Verification of client certificates when ClientCAFile is set
+	NextProtos   []string           // ALPN protocols
+	ReloadCheck  time.Duration      // Min interval between checks of the files, default 10s
+}
+
+// Loads server certificate and reloads it and client CAs when their files change
+type reloader struct {
+	opts Options
+	base *tls.Config
+	log  *slog.Logger
+
+	mu      sync.Mutex
+	config  *tls.Config
+	checked time.Time
+	modTime map[string]time.Time
+}
+
+// Builds server TLS config. Files are read now, so a broken certificate fails the start, later they are
+// reloaded on handshakes after rotation. A failed reload keeps the previous certificate
+func New(opts Options, log *slog.Logger) (*tls.Config, error) {
+	const op = "tlsconfig.New"
+
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, fmt.Errorf("%s: certificate and key files are required", op)
+	}
+
+	base := &tls.Config{NextProtos: opts.NextProtos}
+	if opts.MinVersion == "" {
+		opts.MinVersion = "1.2"
+	}
+	if opts.ReloadCheck <= 0 {
+		opts.ReloadCheck = reloadCheck
+	}
+	version, ok := versions[opts.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported min version %q", op, opts.MinVersion)
+	}
+	base.MinVersion = version
+
+	if opts.CipherSuites != "" {
+		suites, err := cipherSuites(opts.CipherSuites)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		base.CipherSuites = suites
+	}
+
+	r := &reloader{opts: opts, base: base, log: log, modTime: make(map[string]time.Time)}
+	if err := r.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &tls.Config{
+		MinVersion:         base.MinVersion,
+		NextProtos:         opts.NextProtos,
+		GetConfigForClient: r.getConfig,
+	}, nil
+}
+
+func (r *reloader) getConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= r.opts.ReloadCheck {
+		r.checked = time.Now()
+		if r.changed() {
+			if err := r.load(); err != nil {
+				r.log.Error("Failed to reload TLS certificate, keeping previous one", "error", err)
+			} else {
+				r.log.Info("TLS certificate reloaded", "cert", r.opts.CertFile)
+			}
+		}
+	}
+	return r.config, nil
+}
+
+// Reports whether any file was modified since the last load
+func (r *reloader) changed() bool {
+	for file, loaded := range r.modTime {
+		info, err := os.Stat(file)
+		if err != nil {
+			// Файл может отсутствовать в момент замены, проверим при следующем рукопожатии
+			continue
+		}
+		if !info.ModTime().Equal(loaded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reloader) load() error {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+
+	// Время изменения запоминается до чтения, чтобы не пропустить запись во время загрузки
+	modTime := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTime[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", r.opts.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = r.opts.ClientAuth
+	}
+
+	r.config = config
+	r.modTime = modTime
+	return nil
+}
+
+func cipherSuites(names string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := known[name]
+		if !ok {
+			return nil, errors.New("unknown or insecure cipher suite " + name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -5,26 +5,12 @@ import (
 	"auth/internal/domain/models"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
-	"os"
 
 	"google.golang.org/grpc/codes"
 )
 
-func ShowHelp() {
-	text :=
-		`Auth Service
-Flags:		
-	--help 	   [ Shows help message ]
-	--port     [ Default auth service port number ]
-	--host     [ Default auth service host settings ]
-	--env      [ Application environment: local | dev | prod ]`
-	fmt.Println(text)
-	os.Exit(0)
-}
-
 func SendMessage(w http.ResponseWriter, code int, message string) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -38,11 +24,17 @@ func SendMessage(w http.ResponseWriter, code int, message string) error {
 	return nil
 }
 
+// Header with id of the request, set on the response by the server middleware
+const RequestIDHeader = "X-Request-ID"
+
+// Writes error message with id of the request, so clients can report it
 func SendError(w http.ResponseWriter, err error, code int) error {
 	errMessage := struct {
-		Message string `json:"message"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id,omitempty"`
 	}{
-		Message: err.Error(),
+		Message:   err.Error(),
+		RequestID: w.Header().Get(RequestIDHeader),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -58,14 +50,26 @@ func GetHTTpStatus(err error) int {
 	switch {
 	case errors.Is(err, models.ErrInvalidToken), errors.Is(err, models.ErrInvalidCredentials):
 		return http.StatusUnauthorized
-	case errors.Is(err, models.ErrPermissionDenied):
+	case errors.Is(err, models.ErrPermissionDenied), errors.Is(err, models.ErrInvitationEmail),
+		errors.Is(err, models.ErrPasswordChangeRequired), errors.Is(err, models.ErrUserInactive):
 		return http.StatusForbidden
-	case errors.Is(err, repo.ErrUserNotExist):
+	case errors.Is(err, repo.ErrUserNotExist), errors.Is(err, repo.ErrTenantNotExist),
+		errors.Is(err, repo.ErrOrgNotExist), errors.Is(err, repo.ErrMemberNotExist), errors.Is(err, repo.ErrInvitationNotExist),
+		errors.Is(err, repo.ErrWebhookNotExist), errors.Is(err, repo.ErrDeliveryNotExist):
 		return http.StatusNotFound
-	case errors.Is(err, models.ErrNotUniqueEmail), errors.Is(err, models.ErrCannotDeleteSelf):
+	case errors.Is(err, models.ErrNotUniqueEmail), errors.Is(err, models.ErrCannotDeleteSelf),
+		errors.Is(err, models.ErrAlreadyMember), errors.Is(err, models.ErrStatusTransition):
 		return http.StatusConflict
-	case errors.Is(err, models.ErrCannotCreateAdmin), errors.Is(err, models.ErrCannotDeleteSelf):
+	case errors.Is(err, models.ErrCannotCreateAdmin), errors.Is(err, models.ErrCannotDeleteSelf),
+		errors.Is(err, models.ErrTenantRequired), errors.Is(err, models.ErrWeakPassword),
+		errors.Is(err, models.ErrInvitationInvalid), errors.Is(err, models.ErrCannotChangeOwner),
+		errors.Is(err, models.ErrEmptyName), errors.Is(err, models.ErrInvalidCursor),
+		errors.Is(err, models.ErrSamePassword), errors.Is(err, models.ErrCannotDisableSelf):
 		return http.StatusBadRequest
+	case errors.Is(err, models.ErrCursorExpired):
+		return http.StatusGone
+	case errors.Is(err, models.ErrNotReady):
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
@@ -75,14 +79,28 @@ func GetGRPCStatus(err error) codes.Code {
 	switch {
 	case errors.Is(err, models.ErrInvalidToken), errors.Is(err, models.ErrInvalidCredentials):
 		return codes.Unauthenticated
-	case errors.Is(err, models.ErrPermissionDenied):
+	case errors.Is(err, models.ErrPermissionDenied), errors.Is(err, models.ErrInvitationEmail),
+		errors.Is(err, models.ErrUserInactive):
 		return codes.PermissionDenied
-	case errors.Is(err, repo.ErrUserNotExist):
+	case errors.Is(err, repo.ErrUserNotExist), errors.Is(err, repo.ErrTenantNotExist),
+		errors.Is(err, repo.ErrOrgNotExist), errors.Is(err, repo.ErrMemberNotExist), errors.Is(err, repo.ErrInvitationNotExist),
+		errors.Is(err, repo.ErrWebhookNotExist), errors.Is(err, repo.ErrDeliveryNotExist):
 		return codes.NotFound
-	case errors.Is(err, models.ErrNotUniqueEmail):
+	case errors.Is(err, models.ErrNotUniqueEmail), errors.Is(err, models.ErrAlreadyMember):
 		return codes.AlreadyExists
-	case errors.Is(err, models.ErrCannotCreateAdmin), errors.Is(err, models.ErrCannotDeleteSelf):
+	case errors.Is(err, models.ErrInvitationInvalid), errors.Is(err, models.ErrPasswordChangeRequired),
+		errors.Is(err, models.ErrStatusTransition):
+		return codes.FailedPrecondition
+	case errors.Is(err, models.ErrCannotCreateAdmin), errors.Is(err, models.ErrCannotDeleteSelf),
+		errors.Is(err, models.ErrTenantRequired), errors.Is(err, models.ErrWeakPassword),
+		errors.Is(err, models.ErrCannotChangeOwner), errors.Is(err, models.ErrEmptyName),
+		errors.Is(err, models.ErrInvalidCursor), errors.Is(err, models.ErrSamePassword),
+		errors.Is(err, models.ErrCannotDisableSelf):
 		return codes.InvalidArgument
+	case errors.Is(err, models.ErrCursorExpired):
+		return codes.OutOfRange
+	case errors.Is(err, models.ErrNotReady):
+		return codes.Unavailable
 	default:
 		return codes.Internal
 	}